@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 )
 
 type AppConfig struct {
@@ -26,6 +27,34 @@ type AppConfig struct {
 		Brokers string `mapstructure:"address"`
 		Topic   string `mapstructure:"topic_order_status_changed"`
 	} `mapstructure:"kafka"`
+
+	Orders struct {
+		DeletedRetention   time.Duration `mapstructure:"deleted_retention"`
+		PendingTTL         time.Duration `mapstructure:"pending_ttl"`
+		AutoCancelInterval time.Duration `mapstructure:"auto_cancel_interval"`
+	} `mapstructure:"orders"`
+
+	Currency struct {
+		Base      string `mapstructure:"base"`       // валюта отчетности, в нее пересчитываются суммы заказов
+		RatesFile string `mapstructure:"rates_file"` // CSV с курсами, загружаемый при старте; пустая строка - не загружать
+	} `mapstructure:"currency"`
+
+	Analytics struct {
+		CacheTTL time.Duration `mapstructure:"cache_ttl"` // сколько хранятся в кэше отчеты по продажам
+	} `mapstructure:"analytics"`
+
+	Webhooks struct {
+		MaxAttempts    int           `mapstructure:"max_attempts"`    // попыток отправки события, включая первую
+		InitialBackoff time.Duration `mapstructure:"initial_backoff"` // пауза перед второй попыткой, удваивается после каждой неудачи
+		Timeout        time.Duration `mapstructure:"timeout"`         // таймаут одного запроса к вебхуку
+		PendingTimeout time.Duration `mapstructure:"pending_timeout"` // через сколько доставка в статусе pending считается прерванной; больше самой длинной паузы между попытками
+		ResendInterval time.Duration `mapstructure:"resend_interval"` // как часто прерванные доставки отправляются повторно
+	} `mapstructure:"webhooks"`
+
+	Idempotency struct {
+		KeyTTL        time.Duration `mapstructure:"key_ttl"`
+		PurgeInterval time.Duration `mapstructure:"purge_interval"` // как часто удаляются просроченные ключи
+	} `mapstructure:"idempotency"`
 }
 
 var Config AppConfig
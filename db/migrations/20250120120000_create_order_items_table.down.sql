@@ -0,0 +1,16 @@
+ALTER TABLE orders ADD COLUMN product_id BIGINT REFERENCES products(id);
+
+-- Возвращаем в заказ продукт первой позиции
+UPDATE orders o
+SET product_id = oi.product_id
+FROM (
+    SELECT DISTINCT ON (order_id) order_id, product_id
+    FROM order_items
+    ORDER BY order_id, id
+) oi
+WHERE oi.order_id = o.id;
+
+DROP INDEX IF EXISTS idx_order_items_product_id;
+DROP INDEX IF EXISTS idx_order_items_order_id;
+
+DROP TABLE IF EXISTS order_items;
@@ -0,0 +1,21 @@
+CREATE TABLE order_items (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор позиции
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,  -- заказ, к которому относится позиция
+    product_id BIGINT NOT NULL REFERENCES products(id),  -- продукт позиции
+    quantity INT NOT NULL CHECK(quantity > 0),  -- количество единиц продукта
+    unit_price DECIMAL(10, 2) NOT NULL  -- цена за единицу на момент заказа
+);
+
+-- Индекс для выборки позиций заказа
+CREATE INDEX idx_order_items_order_id ON order_items(order_id);
+
+-- Индекс для поиска заказов по продукту
+CREATE INDEX idx_order_items_product_id ON order_items(product_id);
+
+-- Переносим существующие однопозиционные заказы в order_items
+INSERT INTO order_items (order_id, product_id, quantity, unit_price)
+SELECT id, product_id, 1, total_price
+FROM orders
+WHERE product_id IS NOT NULL;
+
+ALTER TABLE orders DROP COLUMN product_id;
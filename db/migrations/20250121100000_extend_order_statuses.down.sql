@@ -0,0 +1,7 @@
+-- Заказы в статусах доставки считаем подтвержденными
+UPDATE orders SET status = 'confirmed' WHERE status IN ('shipped', 'delivered');
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK(status IN ('pending', 'confirmed', 'cancelled'));
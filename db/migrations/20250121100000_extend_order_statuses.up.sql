@@ -0,0 +1,5 @@
+-- Добавляем статусы доставки в допустимые статусы заказа
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK(status IN ('pending', 'confirmed', 'shipped', 'delivered', 'cancelled'));
@@ -0,0 +1,4 @@
+-- Индексы для keyset-пагинации списка заказов
+CREATE INDEX idx_orders_created_at_id ON orders(created_at, id) WHERE is_deleted = false;
+
+CREATE INDEX idx_orders_status_id ON orders(status, id) WHERE is_deleted = false;
@@ -0,0 +1,2 @@
+-- Индекс на поле updated_at для фильтра updated_since
+CREATE INDEX idx_orders_updated_at ON orders(updated_at);
@@ -0,0 +1,12 @@
+CREATE TABLE order_status_history (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор записи
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,  -- заказ
+    old_status VARCHAR(50) NOT NULL,  -- статус до изменения
+    new_status VARCHAR(50) NOT NULL,  -- статус после изменения
+    changed_by BIGINT REFERENCES users(id),  -- пользователь, изменивший статус
+    reason TEXT,  -- причина изменения (необязательно)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- время изменения
+);
+
+-- Индекс для выборки истории заказа в хронологическом порядке
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, created_at);
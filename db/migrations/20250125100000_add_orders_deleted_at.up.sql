@@ -0,0 +1,7 @@
+ALTER TABLE orders ADD COLUMN deleted_at TIMESTAMP;  -- время "мягкого" удаления
+
+-- Для ранее удаленных заказов считаем временем удаления время последнего обновления
+UPDATE orders SET deleted_at = updated_at WHERE is_deleted = true;
+
+-- Индекс для очистки давно удаленных заказов
+CREATE INDEX idx_orders_deleted_at ON orders(deleted_at) WHERE is_deleted = true;
@@ -0,0 +1,2 @@
+-- Версия заказа для оптимистичной блокировки: увеличивается при каждом изменении заказа
+ALTER TABLE orders ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- пользователь, отправивший запрос
+    idempotency_key VARCHAR(255) NOT NULL,  -- значение заголовка Idempotency-Key
+    request_hash VARCHAR(64) NOT NULL,  -- SHA-256 тела запроса
+    response_status INTEGER,  -- HTTP статус ответа; NULL, пока запрос обрабатывается
+    response_headers JSONB,  -- заголовки ответа (Content-Type, ETag, Location) для повторной отдачи
+    response_body BYTEA,  -- тело ответа для повторной отдачи
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,  -- время первого запроса
+    expires_at TIMESTAMP NOT NULL,  -- время, после которого ключ можно использовать повторно
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+-- Индекс для очистки просроченных ключей
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
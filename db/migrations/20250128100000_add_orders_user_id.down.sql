@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_user_id_created_at;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,5 @@
+-- Пользователь, создавший заказ. Для заказов, созданных до появления колонки, владелец неизвестен.
+ALTER TABLE orders ADD COLUMN user_id BIGINT REFERENCES users(id);
+
+-- Индекс для выборки заказов пользователя
+CREATE INDEX idx_orders_user_id_created_at ON orders(user_id, created_at);
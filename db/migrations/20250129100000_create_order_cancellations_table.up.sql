@@ -0,0 +1,10 @@
+CREATE TABLE order_cancellations (
+    order_id BIGINT PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,  -- отмененный заказ
+    reason_code VARCHAR(50) NOT NULL,  -- код причины отмены
+    note TEXT,  -- комментарий к отмене (необязательно)
+    cancelled_by BIGINT REFERENCES users(id),  -- пользователь, отменивший заказ
+    cancelled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP  -- время отмены
+);
+
+-- Индекс для отчетов по причинам отмены
+CREATE INDEX idx_order_cancellations_reason_code ON order_cancellations(reason_code, cancelled_at);
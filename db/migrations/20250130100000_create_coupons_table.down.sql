@@ -0,0 +1,6 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS coupon_code;
+
+DROP TABLE IF EXISTS coupon_redemptions;
+
+DROP TABLE IF EXISTS coupons;
@@ -0,0 +1,31 @@
+CREATE TABLE coupons (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор купона
+    code VARCHAR(64) NOT NULL UNIQUE,  -- код купона, хранится в верхнем регистре
+    discount_type VARCHAR(20) CHECK(discount_type IN ('percentage', 'fixed')) NOT NULL,  -- тип скидки
+    discount_value DECIMAL(10, 2) NOT NULL CHECK(discount_value > 0),  -- процент или фиксированная сумма скидки
+    min_order_value DECIMAL(10, 2) NOT NULL DEFAULT 0,  -- минимальная сумма заказа
+    valid_from TIMESTAMP,  -- начало действия (необязательно)
+    valid_to TIMESTAMP,  -- окончание действия (необязательно)
+    max_uses INT NOT NULL DEFAULT 0,  -- лимит использований, 0 - без ограничений
+    max_uses_per_user INT NOT NULL DEFAULT 0,  -- лимит использований одним пользователем, 0 - без ограничений
+    used_count INT NOT NULL DEFAULT 0,  -- количество использований
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,  -- купон отключен администратором
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,  -- дата создания
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- дата последнего обновления
+);
+
+CREATE TABLE coupon_redemptions (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор записи
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,  -- примененный купон
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,  -- заказ
+    user_id BIGINT REFERENCES users(id),  -- пользователь, применивший купон
+    discount_amount DECIMAL(10, 2) NOT NULL,  -- сумма скидки
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- время применения
+);
+
+-- Индекс для проверки лимита использований купона пользователем
+CREATE INDEX idx_coupon_redemptions_coupon_id_user_id ON coupon_redemptions(coupon_id, user_id);
+
+-- Скидка, примененная к заказу. total_price хранит сумму с учетом скидки.
+ALTER TABLE orders ADD COLUMN coupon_code VARCHAR(64);
+ALTER TABLE orders ADD COLUMN discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS subtotal;
+ALTER TABLE orders DROP COLUMN IF EXISTS region;
+
+DROP TABLE IF EXISTS tax_rules;
+
+ALTER TABLE products DROP COLUMN IF EXISTS category;
@@ -0,0 +1,22 @@
+-- Категория продукта, по которой выбирается налоговая ставка
+ALTER TABLE products ADD COLUMN category VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE TABLE tax_rules (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор правила
+    region VARCHAR(50) NOT NULL DEFAULT '',  -- регион доставки, пустая строка - любой регион
+    category VARCHAR(100) NOT NULL DEFAULT '',  -- категория продукта, пустая строка - любая категория
+    rate DECIMAL(6, 4) NOT NULL CHECK(rate >= 0),  -- ставка налога, 0.2000 = 20%
+    inclusive BOOLEAN NOT NULL DEFAULT FALSE,  -- цены каталога уже включают налог
+    UNIQUE (region, category)
+);
+
+-- Регион доставки заказа и разбивка суммы заказа. total_price хранит итоговую сумму с налогом.
+ALTER TABLE orders ADD COLUMN region VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN subtotal DECIMAL(10, 2);
+ALTER TABLE orders ADD COLUMN tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- Для существующих заказов налог не рассчитывался: подытог равен сумме до скидки
+UPDATE orders SET subtotal = total_price + discount_amount;
+
+ALTER TABLE orders ALTER COLUMN subtotal SET NOT NULL;
+ALTER TABLE orders ALTER COLUMN subtotal SET DEFAULT 0;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+DROP INDEX IF EXISTS idx_orders_base_total_price_id;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS base_total_price;
+ALTER TABLE orders DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
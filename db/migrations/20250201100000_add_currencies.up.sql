@@ -0,0 +1,22 @@
+-- Валюта цены продукта и валюта заказа, код ISO 4217.
+-- Базовая валюта задается в конфигурации (currency.base), поэтому миграция не заполняет валюту существующих
+-- продуктов и заказов: при запуске приложение записывает в них базовую валюту, курс 1 и сумму в базовой валюте.
+ALTER TABLE products ADD COLUMN currency VARCHAR(3);
+ALTER TABLE orders ADD COLUMN currency VARCHAR(3);
+
+-- Курс валюты заказа к базовой валюте на момент создания заказа и итоговая сумма в базовой валюте для отчетов
+ALTER TABLE orders ADD COLUMN exchange_rate DECIMAL(18, 8);
+ALTER TABLE orders ADD COLUMN base_total_price DECIMAL(12, 2);
+
+-- Сортировка по total_price и фильтры по сумме используют сумму в базовой валюте,
+-- поэтому индекс для keyset-пагинации строится по base_total_price
+CREATE INDEX idx_orders_base_total_price_id ON orders(base_total_price, id) WHERE is_deleted = false;
+
+CREATE TABLE exchange_rates (
+    from_currency VARCHAR(3) NOT NULL,  -- исходная валюта
+    to_currency VARCHAR(3) NOT NULL,  -- валюта, в которую переводится сумма
+    rate DECIMAL(18, 8) NOT NULL CHECK(rate > 0),  -- сколько единиц to_currency стоит одна единица from_currency
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,  -- дата последнего обновления курса
+    PRIMARY KEY (from_currency, to_currency),
+    CHECK (from_currency <> to_currency)
+);
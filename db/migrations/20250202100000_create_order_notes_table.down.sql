@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_order_notes_order_id;
+
+DROP TABLE IF EXISTS order_notes;
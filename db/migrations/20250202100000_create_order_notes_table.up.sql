@@ -0,0 +1,11 @@
+CREATE TABLE order_notes (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор заметки
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,  -- заказ
+    author_id BIGINT REFERENCES users(id),  -- автор заметки
+    text TEXT NOT NULL,  -- текст заметки
+    is_internal BOOLEAN NOT NULL DEFAULT TRUE,  -- заметка видна только сотрудникам
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- время создания
+);
+
+-- Индекс для выборки заметок заказа в хронологическом порядке
+CREATE INDEX idx_order_notes_order_id ON order_notes(order_id, created_at);
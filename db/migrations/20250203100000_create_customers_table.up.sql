@@ -0,0 +1,31 @@
+CREATE TABLE customers (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор клиента
+    name VARCHAR(255) NOT NULL,  -- имя клиента
+    email VARCHAR(255) UNIQUE,  -- адрес электронной почты, хранится в нижнем регистре (необязательно)
+    phone VARCHAR(50),  -- телефон (необязательно)
+    addresses JSONB NOT NULL DEFAULT '[]',  -- адреса доставки
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,  -- дата создания
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- дата последнего обновления
+);
+
+-- Клиент заказа. customer_name остается в заказе как имя на момент оформления.
+ALTER TABLE orders ADD COLUMN customer_id BIGINT REFERENCES customers(id) ON DELETE SET NULL;
+
+-- Клиенты существующих заказов. Имена, отличающиеся только регистром и пробелами по краям, считаются одним клиентом,
+-- в качестве имени берется написание из самого раннего заказа.
+INSERT INTO customers (name, created_at)
+SELECT DISTINCT ON (LOWER(TRIM(customer_name))) TRIM(customer_name), created_at
+FROM orders
+WHERE TRIM(customer_name) <> ''
+ORDER BY LOWER(TRIM(customer_name)), created_at;
+
+UPDATE orders
+SET customer_id = customers.id
+FROM customers
+WHERE LOWER(TRIM(orders.customer_name)) = LOWER(customers.name);
+
+-- Индекс для поиска клиента по имени без учета регистра при оформлении заказа без customer_id
+CREATE INDEX idx_customers_lower_name ON customers(LOWER(name));
+
+-- Индекс для выборки заказов клиента
+CREATE INDEX idx_orders_customer_id ON orders(customer_id);
@@ -0,0 +1,28 @@
+CREATE TABLE webhooks (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор подписки
+    url TEXT NOT NULL,  -- адрес, на который отправляются события
+    secret VARCHAR(255) NOT NULL,  -- ключ для подписи тела запроса HMAC-SHA256
+    event_types TEXT[] NOT NULL,  -- типы событий, на которые оформлена подписка
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,  -- отправляются ли события по подписке
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,  -- дата создания
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- дата последнего обновления
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,  -- автоинкрементируемый идентификатор доставки
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,  -- подписка
+    event_type VARCHAR(50) NOT NULL,  -- тип события
+    payload BYTEA NOT NULL,  -- тело запроса байт в байт: повторная отправка совпадает с исходной вместе с подписью
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, succeeded или failed
+    attempts INT NOT NULL DEFAULT 0,  -- количество выполненных попыток отправки
+    response_status INT,  -- HTTP статус ответа на последнюю попытку
+    last_error TEXT,  -- ошибка последней неудачной попытки
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,  -- дата создания
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- дата последней попытки
+);
+
+-- Индекс для выборки истории доставок подписки
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at);
+
+-- Индекс для поиска зависших доставок, которые нужно отправить повторно
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries(updated_at) WHERE status = 'pending';
@@ -15,48 +15,105 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/login": {
-            "post": {
-                "description": "Logs in a user and returns a JWT token",
-                "consumes": [
-                    "application/json"
+        "/analytics/orders-by-status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Get the number of orders and their total in the base currency for each status. Deleted orders are excluded. Results are cached for a short time",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "analytics"
                 ],
-                "summary": "Log in a user",
+                "summary": "Get orders by status",
                 "parameters": [
                     {
-                        "description": "User login credentials",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.AuthData"
-                        }
+                        "type": "string",
+                        "description": "Orders created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Orders created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Token: {token}",
+                        "description": "Orders by status",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderStatusStats"
                             }
                         }
                     },
                     "400": {
-                        "description": "Invalid login data",
+                        "description": "Invalid parameters",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/analytics/revenue": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the number of orders and revenue in the base currency grouped by day, week or month. Cancelled and deleted orders are excluded. Results are cached for a short time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get revenue by period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "day",
+                        "description": "Grouping interval: day, week or month",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Orders created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Orders created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Revenue by period",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevenueReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -70,59 +127,91 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders": {
+        "/analytics/top-products": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get all orders or filter them by status, min price, and max price",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the products with the highest revenue in the base currency. Each order total, after discounts and with tax, is split between its items in proportion to their value, so product revenue adds up to the other reports. Cancelled and deleted orders are excluded. Results are cached for a short time",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "analytics"
                 ],
-                "summary": "Get orders by filters",
+                "summary": "Get top selling products",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Order status",
-                        "name": "status",
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of products (max 100)",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "Minimum order price",
-                        "name": "min_price",
+                        "type": "string",
+                        "description": "Orders created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
                         "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "Maximum order price",
-                        "name": "max_price",
+                        "type": "string",
+                        "description": "Orders created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of orders",
+                        "description": "Top selling products",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Order"
+                                "$ref": "#/definitions/models.ProductSales"
                             }
                         }
                     },
                     "400": {
-                        "description": "Invalid filter parameters",
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/coupons": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a list of all coupons",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Get all coupons",
+                "responses": {
+                    "200": {
+                        "description": "List of all coupons",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Coupon"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal server error",
@@ -138,7 +227,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a new order by providing order data",
+                "description": "Create a percentage or fixed amount coupon. Codes are case-insensitive and stored in upper case",
                 "consumes": [
                     "application/json"
                 ],
@@ -146,35 +235,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "coupons"
                 ],
-                "summary": "Create a new order",
+                "summary": "Create a coupon",
                 "parameters": [
                     {
-                        "description": "Order data",
-                        "name": "order",
+                        "description": "Coupon data",
+                        "name": "coupon",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created",
+                        "description": "Coupon created successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid coupon data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Coupon with this code already exists",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -182,28 +277,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{id}": {
+        "/coupons/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get a specific order by providing the order ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get a coupon with its usage counter",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "coupons"
                 ],
-                "summary": "Get an order by ID",
+                "summary": "Get a coupon by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Order ID",
+                        "description": "Coupon ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -211,13 +303,19 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order details",
+                        "description": "Coupon details",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     },
                     "400": {
-                        "description": "Invalid order ID",
+                        "description": "Invalid coupon ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Coupon not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -236,7 +334,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update an existing order by providing order data",
+                "description": "Replace the conditions of a coupon. The usage counter is kept",
                 "consumes": [
                     "application/json"
                 ],
@@ -244,36 +342,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "coupons"
                 ],
-                "summary": "Update an existing order",
+                "summary": "Update a coupon",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Order ID",
+                        "description": "Coupon ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated order data",
-                        "name": "order",
+                        "description": "Updated coupon data",
+                        "name": "coupon",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order updated successfully",
+                        "description": "Coupon updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Coupon"
                         }
                     },
                     "400": {
-                        "description": "Invalid order ID or data",
+                        "description": "Invalid coupon ID or data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Coupon not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Coupon with this code already exists",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -292,21 +402,15 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete an order by providing order ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Delete a coupon. Discounts already applied to orders are kept",
                 "tags": [
-                    "orders"
+                    "coupons"
                 ],
-                "summary": "Delete an order",
+                "summary": "Delete a coupon",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Order ID",
+                        "description": "Coupon ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -314,10 +418,16 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "204": {
-                        "description": "Order deleted successfully"
+                        "description": "Coupon deleted successfully"
                     },
                     "400": {
-                        "description": "Invalid order ID",
+                        "description": "Invalid coupon ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Coupon not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -331,31 +441,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/products": {
+        "/customers": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve a list of all products",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieve a list of all customers",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "customers"
                 ],
-                "summary": "Get all products",
+                "summary": "Get all customers",
                 "responses": {
                     "200": {
-                        "description": "List of all products",
+                        "description": "List of all customers",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Product"
+                                "$ref": "#/definitions/models.Customer"
                             }
                         }
                     },
@@ -373,7 +480,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a new product by providing product data",
+                "description": "Create a customer with contact details and delivery addresses. Emails are case-insensitive and must be unique",
                 "consumes": [
                     "application/json"
                 ],
@@ -381,29 +488,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "customers"
                 ],
-                "summary": "Create a new product",
+                "summary": "Create a customer",
                 "parameters": [
                     {
-                        "description": "Product data",
-                        "name": "product",
+                        "description": "Customer data",
+                        "name": "customer",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.Customer"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Product successfully created",
+                        "description": "Customer created successfully",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.Customer"
                         }
                     },
                     "400": {
-                        "description": "Invalid product data",
+                        "description": "Invalid customer data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Customer with this email already exists",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -417,28 +530,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/{id}": {
+        "/customers/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get a specific product by providing the product ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get a customer with contact details and addresses",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "customers"
                 ],
-                "summary": "Get a product by ID",
+                "summary": "Get a customer by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Product ID",
+                        "description": "Customer ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -446,19 +556,19 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product details",
+                        "description": "Customer details",
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.Customer"
                         }
                     },
                     "400": {
-                        "description": "Invalid product ID",
+                        "description": "Invalid customer ID",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Product not found",
+                        "description": "Customer not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -477,7 +587,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update an existing product by providing product data",
+                "description": "Replace the contact details and addresses of a customer. Names in existing orders are not changed",
                 "consumes": [
                     "application/json"
                 ],
@@ -485,36 +595,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "customers"
                 ],
-                "summary": "Update an existing product",
+                "summary": "Update a customer",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Product ID",
+                        "description": "Customer ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated product data",
-                        "name": "product",
+                        "description": "Updated customer data",
+                        "name": "customer",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.Customer"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product successfully updated",
+                        "description": "Customer updated successfully",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.Customer"
                         }
                     },
                     "400": {
-                        "description": "Invalid product ID or data",
+                        "description": "Invalid customer ID or data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Customer with this email already exists",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -533,41 +655,32 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a product by providing product ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Delete a customer. Orders of the customer are kept with the customer name they were placed with",
                 "tags": [
-                    "products"
+                    "customers"
                 ],
-                "summary": "Delete a product",
+                "summary": "Delete a customer",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Product ID",
+                        "description": "Customer ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Product successfully deleted",
-                        "schema": {
-                            "type": "string"
-                        }
+                    "204": {
+                        "description": "Customer deleted successfully"
                     },
                     "400": {
-                        "description": "Invalid product ID",
+                        "description": "Invalid customer ID",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Product not found",
+                        "description": "Customer not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -581,10 +694,220 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
-            "post": {
-                "description": "Registers a new user by providing user data",
-                "consumes": [
+        "/customers/{id}/orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a page of the customer's orders. Accepts the same filters, sorting and pagination parameters as GET /orders",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Get orders of a customer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated order statuses, e.g. pending,confirmed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Sort field: created_at, total_price or status; prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of orders",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid customer ID or filter parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchange-rates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve the stored rates of all currency pairs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "Get all exchange rates",
+                "responses": {
+                    "200": {
+                        "description": "List of exchange rates",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ExchangeRate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create or replace the rate of a currency pair: one unit of from_currency costs rate units of to_currency.\nThe reverse pair is derived automatically when it is not set explicitly",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "Set an exchange rate",
+                "parameters": [
+                    {
+                        "description": "Exchange rate",
+                        "name": "rate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExchangeRate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exchange rate saved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExchangeRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid exchange rate data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchange-rates/{from}/{to}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete the rate of a currency pair. Orders that were already created keep their exchange rate",
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "Delete an exchange rate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "EUR",
+                        "description": "Source currency",
+                        "name": "from",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "RUB",
+                        "description": "Target currency",
+                        "name": "to",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Exchange rate deleted successfully"
+                    },
+                    "404": {
+                        "description": "Exchange rate not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Logs in a user and returns a JWT token",
+                "consumes": [
                     "application/json"
                 ],
                 "produces": [
@@ -593,27 +916,36 @@ const docTemplate = `{
                 "tags": [
                     "auth"
                 ],
-                "summary": "Register a new user",
+                "summary": "Log in a user",
                 "parameters": [
                     {
-                        "description": "User registration data",
-                        "name": "user",
+                        "description": "User login credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.RegisterData"
+                            "$ref": "#/definitions/handlers.AuthData"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User successfully registered",
+                    "200": {
+                        "description": "Token: {token}",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid user data",
+                        "description": "Invalid login data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -626,70 +958,2559 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.AuthData": {
+        },
+        "/orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a page of orders matching the given filters. Users only see orders they created. Pages are navigated with the next_cursor value from the previous response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get orders by filters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated order statuses, e.g. pending,confirmed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum order price in the base currency",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum order price in the base currency",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Updated at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "updated_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Orders containing the product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Orders of the customer",
+                        "name": "customer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive part of the customer name",
+                        "name": "customer_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Sort field: created_at, total_price or status; prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "List soft-deleted orders instead of active ones (Admin only)",
+                        "name": "deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of orders",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Deleted orders are available to admins only",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new order by providing customer data and a list of order items. The customer is given either by customer_id or by customer_name; a customer with the same name (ignoring case) is reused, otherwise a new customer record is created. An optional coupon_code applies a discount to the order subtotal, and region selects the tax rules used to calculate tax_amount.\nPrices are converted to the order currency (the base currency by default) using the stored exchange rates\nRetries with the same Idempotency-Key and body replay the original response instead of creating another order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Create a new order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client-generated key that makes retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Order data",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Product is out of stock or a request with the same key is still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Coupon cannot be applied, no exchange rate for the order currency, or idempotency key was already used with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create up to 500 orders in one request. Each order is validated and stored separately, the response reports the outcome of every order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Create orders in bulk",
+                "parameters": [
+                    {
+                        "description": "Orders to create",
+                        "name": "orders",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BulkCreateOrdersRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per-order results",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BulkOrdersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data or batch size",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/bulk-status": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Apply up to 500 status transitions in one request. Each transition follows the same rules as POST /orders/{id}/transitions, the response reports the outcome of every transition",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Change order statuses in bulk",
+                "parameters": [
+                    {
+                        "description": "Status transitions",
+                        "name": "updates",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BulkStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per-order results",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BulkOrdersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data or batch size",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Download all orders matching the filters as a CSV or XLSX file. Accepts the same filters as GET /orders, limit and cursor are ignored",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Export orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "File format: csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated order statuses, e.g. pending,confirmed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum order price in the base currency",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum order price in the base currency",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Updated at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "updated_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Orders containing the product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Orders of the customer",
+                        "name": "customer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive part of the customer name",
+                        "name": "customer_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Export soft-deleted orders instead of active ones",
+                        "name": "deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Sort field: created_at, total_price or status; prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Orders file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format or filter parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/purge": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Permanently remove orders that were soft-deleted longer than the configured retention period ago",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Purge deleted orders",
+                "responses": {
+                    "200": {
+                        "description": "Number of purged orders",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PurgeOrdersResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a specific order by providing the order ID. Users can only access orders they created",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get an order by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order details",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Order version to pass in If-Match when updating the order"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update an existing order by providing order data. Orders cannot be cancelled here, use POST /orders/{id}/cancel",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Update an existing order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order version being updated, or * to skip the check",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated order data",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New order version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID or data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Illegal status transition",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Order has been modified since the given version",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is required",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete an order by providing order ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Delete an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Order deleted successfully"
+                    },
+                    "400": {
+                        "description": "Invalid order ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found or already deleted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Apply a JSON Merge Patch (RFC 7396) document to an order. Omitted fields keep their stored values",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Partially update an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order version being updated, or * to skip the check",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch document",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New order version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID or data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Illegal status transition",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Order has been modified since the given version",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is required",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cancel a pending or confirmed order with a reason code and an optional note. Reserved stock is returned to the catalog",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Cancel an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cancellation reason",
+                        "name": "cancellation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CancelOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order cancelled successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New order version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID or cancellation reason",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Order can no longer be cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the timeline of status changes of an order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get order status history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status changes in chronological order",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderStatusChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/notes": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the notes of an order in chronological order. Users see only non-internal notes of their own orders",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get order notes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notes in chronological order",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderNote"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add a note to an order on behalf of the current user. Internal notes are visible to administrators only and can be added by administrators only; other notes are also visible to the order owner.\nIf is_internal is omitted, notes of administrators are internal and notes of other users are visible to the order owner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Add a note to an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Note",
+                        "name": "note",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OrderNoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Note added successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderNote"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID or note",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only administrators can add internal notes",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restore a soft-deleted order. Stock is reserved again for orders that have not been shipped",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Restore a deleted order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order restored successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Deleted order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Insufficient stock to restore the order",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/transitions": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Move an order to a new status. Allowed transitions: pending→confirmed→shipped→delivered\nOrders are cancelled with a reason code through POST /orders/{id}/cancel",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Change order status",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target status",
+                        "name": "transition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OrderTransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order status changed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID or status",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Illegal status transition",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a list of all products",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get all products",
+                "responses": {
+                    "200": {
+                        "description": "List of all products",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new product by providing product data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Create a new product",
+                "parameters": [
+                    {
+                        "description": "Product data",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Product successfully created",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create and update products from a CSV file with the header name,price,quantity and optional id, currency and category columns.\nNew products without a currency get the base currency, existing products keep their currency if it is not given.\nThe category determines the tax rate. Without the category column existing products keep their category and new products get none.\nRows with an id update the existing product, rows without it create a new one. The file can be sent as the request body or as the \"file\" field of a multipart form",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Import products from CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per-row import report",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid CSV header or file",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "CSV file is too large",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a specific product by providing the product ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get a product by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product details",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update an existing product by providing product data. If the currency is omitted, the current currency of the product is kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Update an existing product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated product data",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product successfully updated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID or data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a product by providing product ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Delete a product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product successfully deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Apply a JSON Merge Patch (RFC 7396) document to a product. Omitted fields keep their stored values",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Partially update a product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch document",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product successfully updated",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID or data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Registers a new user by providing user data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User successfully registered",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all webhook subscriptions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get all webhooks",
+                "responses": {
+                    "200": {
+                        "description": "List of webhooks",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Subscribe a URL to order events (order_status_changed, order_cancelled). Events are sent as POST requests with the X-Webhook-Event, X-Webhook-Delivery and X-Webhook-Signature headers. The signature is \"sha256=\" followed by the hex HMAC-SHA256 of the request body keyed with the webhook secret. The secret is returned only in this response. Failed deliveries are retried with exponential backoff. Deliveries interrupted by a restart are resent automatically once they have been pending for the configured timeout",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create a webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook data",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Webhook created successfully, the secret is returned only in this response",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WebhookCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send the payload of a delivery to its webhook again, even if the webhook is inactive. The replay is recorded as a new delivery and sent in the background",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Replay a webhook delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Replay scheduled",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid delivery ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Delivery or webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a webhook subscription",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a webhook by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook details",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace the URL, event types and state of a webhook. The secret is kept if it is not given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Update a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated webhook data",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID or data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a webhook together with its delivery history",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Webhook deleted successfully"
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the deliveries of a webhook, newest first, with the number of attempts and the result of the last one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of deliveries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.AuthData": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BulkCreateOrdersRequest": {
+            "type": "object",
+            "properties": {
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                }
+            }
+        },
+        "handlers.BulkOrdersResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BulkOrderResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "handlers.BulkStatusRequest": {
+            "type": "object",
+            "properties": {
+                "updates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderStatusUpdate"
+                    }
+                }
+            }
+        },
+        "handlers.CancelOrderRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "description": "обязателен для кода other",
+                    "type": "string",
+                    "example": "Customer changed their mind"
+                },
+                "reason_code": {
+                    "type": "string",
+                    "enum": [
+                        "customer_request",
+                        "payment_failed",
+                        "out_of_stock",
+                        "duplicate",
+                        "fraud_suspected",
+                        "other"
+                    ],
+                    "example": "customer_request"
+                }
+            }
+        },
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.OrderNoteRequest": {
+            "type": "object",
+            "properties": {
+                "is_internal": {
+                    "description": "видна только администраторам; по умолчанию true для администраторов",
+                    "type": "boolean",
+                    "example": true
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Customer asked to deliver after 6 pm"
+                }
+            }
+        },
+        "handlers.OrderTransitionRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Payment received"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "confirmed"
+                }
+            }
+        },
+        "handlers.PurgeOrdersResponse": {
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.RegisterData": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.WebhookCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "order_status_changed",
+                        "order_cancelled"
+                    ]
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "3f1c9a7e5b2d4f6a8c0e1b3d5f7a9c2e"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/orders"
+                }
+            }
+        },
+        "handlers.WebhookRequest": {
+            "type": "object",
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "order_status_changed",
+                        "order_cancelled"
+                    ]
+                },
+                "is_active": {
+                    "description": "по умолчанию true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "secret": {
+                    "description": "если не задан, генерируется (при замене - сохраняется прежний)",
+                    "type": "string",
+                    "example": "3f1c9a7e5b2d4f6a8c0e1b3d5f7a9c2e"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/orders"
+                }
+            }
+        },
+        "models.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "example": "Moscow"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "RU"
+                },
+                "line1": {
+                    "type": "string",
+                    "example": "Tverskaya st. 1"
+                },
+                "line2": {
+                    "type": "string",
+                    "example": "apt. 12"
+                },
+                "postal_code": {
+                    "type": "string",
+                    "example": "125009"
+                }
+            }
+        },
+        "models.BulkOrderResult": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "HTTP статус, который вернул бы одиночный запрос",
+                    "type": "integer",
+                    "example": 201
+                },
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "description": "позиция элемента в запросе",
+                    "type": "integer",
+                    "example": 0
+                },
+                "order": {
+                    "$ref": "#/definitions/models.Order"
+                },
+                "order_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.Coupon": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "WELCOME10"
+                },
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "disabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "discount_type": {
+                    "type": "string",
+                    "enum": [
+                        "percentage",
+                        "fixed"
+                    ],
+                    "example": "percentage"
+                },
+                "discount_value": {
+                    "description": "процент для percentage, сумма в базовой валюте для fixed",
+                    "type": "number",
+                    "example": 10
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "max_uses": {
+                    "description": "0 - без ограничений",
+                    "type": "integer",
+                    "example": 100
+                },
+                "max_uses_per_user": {
+                    "description": "0 - без ограничений",
+                    "type": "integer",
+                    "example": 1
+                },
+                "min_order_value": {
+                    "description": "в базовой валюте",
+                    "type": "number",
+                    "example": 50
+                },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "used_count": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 0
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Customer": {
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Address"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "email": {
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+7 900 123-45-67"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                }
+            }
+        },
+        "models.ExchangeRate": {
+            "type": "object",
+            "properties": {
+                "from_currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 98.5
+                },
+                "to_currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                }
+            }
+        },
+        "models.Order": {
+            "description": "Order struct",
+            "type": "object",
+            "properties": {
+                "base_total_price": {
+                    "description": "итоговая сумма в базовой валюте для отчетов",
+                    "type": "number",
+                    "readOnly": true,
+                    "example": 9899.25
+                },
+                "cancellation": {
+                    "description": "заполняется для заказов, отмененных через POST /orders/{id}/cancel",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrderCancellation"
+                        }
+                    ],
+                    "readOnly": true
+                },
+                "coupon_code": {
+                    "description": "промокод, применяемый при создании заказа",
+                    "type": "string",
+                    "example": "WELCOME10"
+                },
+                "currency": {
+                    "description": "валюта заказа, по умолчанию базовая валюта",
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "customer_id": {
+                    "description": "клиент заказа; если не указан, клиент находится или создается по customer_name",
+                    "type": "integer",
+                    "example": 1
+                },
+                "customer_name": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "discount_amount": {
+                    "description": "скидка по промокоду, уже вычтенная из total_price",
+                    "type": "number",
+                    "readOnly": true,
+                    "example": 10.05
+                },
+                "exchange_rate": {
+                    "description": "курс валюты заказа к базовой валюте на момент создания",
+                    "type": "number",
+                    "readOnly": true,
+                    "example": 98.5
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderItem"
+                    }
+                },
+                "region": {
+                    "description": "регион доставки, определяет налоговые ставки",
+                    "type": "string",
+                    "example": "DE"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "subtotal": {
+                    "description": "стоимость позиций без налога и скидки",
+                    "type": "number",
+                    "readOnly": true,
+                    "example": 90.45
+                },
+                "tax_amount": {
+                    "description": "налог с учетом скидки",
+                    "type": "number",
+                    "readOnly": true,
+                    "example": 17.19
+                },
+                "total_price": {
+                    "description": "итоговая сумма: subtotal - discount_amount + tax_amount",
+                    "type": "number",
+                    "readOnly": true,
+                    "example": 100.5
+                },
+                "user_id": {
+                    "description": "пользователь, создавший заказ",
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "version": {
+                    "description": "увеличивается при каждом изменении заказа",
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                }
+            }
+        },
+        "models.OrderCancellation": {
             "type": "object",
             "properties": {
-                "password": {
+                "cancelled_at": {
                     "type": "string"
                 },
-                "username": {
-                    "type": "string"
+                "cancelled_by": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "note": {
+                    "type": "string",
+                    "example": "Customer changed their mind"
+                },
+                "order_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason_code": {
+                    "type": "string",
+                    "example": "customer_request"
                 }
             }
         },
-        "handlers.ErrorResponse": {
+        "models.OrderItem": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "integer"
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "message": {
-                    "type": "string"
+                "quantity": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "unit_price": {
+                    "description": "цена продукта на момент создания заказа",
+                    "type": "number",
+                    "readOnly": true,
+                    "example": 50.25
                 }
             }
         },
-        "handlers.RegisterData": {
+        "models.OrderNote": {
             "type": "object",
             "properties": {
-                "password": {
-                    "type": "string"
+                "author_id": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "role": {
+                "created_at": {
                     "type": "string"
                 },
-                "username": {
-                    "type": "string"
+                "id": {
+                    "type": "integer"
+                },
+                "is_internal": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Customer asked to deliver after 6 pm"
                 }
             }
         },
-        "models.Order": {
-            "description": "Order struct",
+        "models.OrderPage": {
             "type": "object",
             "properties": {
-                "customer_name": {
+                "next_cursor": {
                     "type": "string",
-                    "example": "John Doe"
+                    "example": "eyJzIjoiLWNyZWF0ZWRfYXQiLCJ2IjoiMjAyNS0wMS0xMFQxMTozNjowM1oiLCJpZCI6NDJ9"
                 },
-                "product_id": {
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                },
+                "total_count": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "models.OrderStatusChange": {
+            "type": "object",
+            "properties": {
+                "changed_by": {
                     "type": "integer",
                     "example": 1
                 },
-                "status": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "new_status": {
+                    "type": "string",
+                    "example": "confirmed"
+                },
+                "old_status": {
                     "type": "string",
                     "example": "pending"
                 },
-                "total_price": {
+                "order_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Payment received"
+                }
+            }
+        },
+        "models.OrderStatusStats": {
+            "type": "object",
+            "properties": {
+                "orders": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "revenue": {
                     "type": "number",
-                    "example": 100.5
+                    "example": 53210
+                },
+                "status": {
+                    "type": "string",
+                    "example": "confirmed"
+                }
+            }
+        },
+        "models.OrderStatusUpdate": {
+            "type": "object",
+            "properties": {
+                "order_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Handed to courier"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "shipped"
                 }
             }
         },
         "models.Product": {
             "type": "object",
             "properties": {
+                "category": {
+                    "description": "определяет налоговую ставку",
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "валюта цены, по умолчанию базовая валюта",
+                    "type": "string",
+                    "example": "RUB"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -700,6 +3521,189 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "models.ProductImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImportRow"
+                    }
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.ProductImportRow": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "row": {
+                    "description": "номер строки в файле, заголовок - строка 1",
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "type": "string",
+                    "example": "created"
+                }
+            }
+        },
+        "models.ProductSales": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Laptop"
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 17
+                },
+                "revenue": {
+                    "type": "number",
+                    "example": 849983
+                }
+            }
+        },
+        "models.RevenuePoint": {
+            "type": "object",
+            "properties": {
+                "average_order_value": {
+                    "type": "number",
+                    "example": 1285.04
+                },
+                "orders": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "revenue": {
+                    "type": "number",
+                    "example": 15420.5
+                }
+            }
+        },
+        "models.RevenueReport": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "day"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RevenuePoint"
+                    }
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "order_status_changed",
+                        "order_cancelled"
+                    ]
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true,
+                    "example": 1
+                },
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/orders"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "order_status_changed"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "тело запроса байт в байт, как оно было подписано",
+                    "type": "object"
+                },
+                "response_status": {
+                    "description": "HTTP статус ответа на последнюю попытку",
+                    "type": "integer",
+                    "example": 200
+                },
+                "status": {
+                    "type": "string",
+                    "example": "succeeded"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -6,13 +6,16 @@ import (
 	"TestTask/internal/cache"
 	"TestTask/internal/handlers"
 	"TestTask/internal/kafka"
+	"TestTask/internal/models"
 	"TestTask/internal/repository"
 	"TestTask/internal/routes"
 	"TestTask/internal/service"
+	"context"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"log"
 	"net/http"
+	"strings"
 )
 
 func Run() {
@@ -31,6 +34,13 @@ func Run() {
 	productRepository := repository.NewProductRepository(database.DB)
 	userRepository := repository.NewUserRepository(database.DB)
 	logRepository := repository.NewLogRepository(database.DB)
+	idempotencyRepository := repository.NewIdempotencyRepository(database.DB)
+	couponRepository := repository.NewCouponRepository(database.DB)
+	customerRepository := repository.NewCustomerRepository(database.DB)
+	taxRuleRepository := repository.NewTaxRuleRepository(database.DB)
+	exchangeRateRepository := repository.NewExchangeRateRepository(database.DB)
+	analyticsRepository := repository.NewAnalyticsRepository(database.DB)
+	webhookRepository := repository.NewWebhookRepository(database.DB)
 
 	log.Println("Repositories initialized")
 
@@ -40,17 +50,57 @@ func Run() {
 	log.Println("Kafka producer initialized")
 
 	cacheService := cache.NewCacheService()
-	eventService := service.NewEventService(kafkaProducer)
-	orderService := service.NewOrderService(orderRepository, cacheService, eventService)
-	productService := service.NewProductService(productRepository)
+	webhooksConfig := config.Config.Webhooks
+	webhookService := service.NewWebhookService(webhookRepository, &http.Client{Timeout: webhooksConfig.Timeout}, webhooksConfig.MaxAttempts, webhooksConfig.InitialBackoff, webhooksConfig.PendingTimeout)
+	eventService := service.NewEventService(kafkaProducer, webhookService)
+	taxCalculator := service.NewTaxCalculator(taxRuleRepository)
+	currencyConfig := config.Config.Currency
+	baseCurrency := strings.ToUpper(currencyConfig.Base)
+	if !models.IsSupportedCurrency(baseCurrency) {
+		log.Fatalf("Unsupported base currency: %q", currencyConfig.Base)
+	}
+	currencyConverter := service.NewCurrencyConverter(exchangeRateRepository, baseCurrency)
+	assigned, err := currencyConverter.AssignBaseCurrency()
+	if err != nil {
+		log.Fatalf("Could not assign base currency %s: %v", baseCurrency, err)
+	}
+	if assigned > 0 {
+		log.Printf("Assigned base currency %s to %d products and orders", baseCurrency, assigned)
+	}
+	ordersConfig := config.Config.Orders
+	orderService := service.NewOrderService(orderRepository, productRepository, customerRepository, couponRepository, taxCalculator, currencyConverter, cacheService, eventService, ordersConfig.DeletedRetention, ordersConfig.PendingTTL)
+	productService := service.NewProductService(productRepository, baseCurrency)
+	exchangeRateService := service.NewExchangeRateService(exchangeRateRepository)
+	couponService := service.NewCouponService(couponRepository)
+	customerService := service.NewCustomerService(customerRepository)
+	analyticsService := service.NewAnalyticsService(analyticsRepository, cacheService, baseCurrency, config.Config.Analytics.CacheTTL)
 	userService := service.NewUserService(userRepository)
 	authService := service.NewAuthService(userService)
 	logService := service.NewLogService(logRepository)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepository, config.Config.Idempotency.KeyTTL)
 
 	log.Println("Services initialized")
 
-	orderHandler := handlers.NewOrderHandler(orderService, logService)
+	if currencyConfig.RatesFile != "" {
+		loaded, err := exchangeRateService.LoadExchangeRatesFile(currencyConfig.RatesFile)
+		if err != nil {
+			log.Printf("Could not load exchange rates from %s: %v", currencyConfig.RatesFile, err)
+		} else {
+			log.Printf("Loaded %d exchange rates from %s", loaded, currencyConfig.RatesFile)
+		}
+	}
+
+	go orderService.RunAutoCancel(context.Background(), ordersConfig.AutoCancelInterval)
+	go webhookService.RunPendingDeliveries(context.Background(), webhooksConfig.ResendInterval)
+	go idempotencyService.RunPurgeExpired(context.Background(), config.Config.Idempotency.PurgeInterval)
+
+	orderHandler := handlers.NewOrderHandler(orderService, logService, idempotencyService)
 	productHandler := handlers.NewProductHandler(productService)
+	couponHandler := handlers.NewCouponHandler(couponService)
+	customerHandler := handlers.NewCustomerHandler(customerService, orderService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateService)
 	authHandler := handlers.NewAuthHandlers(authService)
 
 	log.Println("Handlers initialized")
@@ -60,12 +110,17 @@ func Run() {
 
 	apiRoutes.SetupOrderRoutes(orderHandler)
 	apiRoutes.SetupProductRoutes(productHandler)
+	apiRoutes.SetupCouponRoutes(couponHandler)
+	apiRoutes.SetupCustomerRoutes(customerHandler)
+	apiRoutes.SetupExchangeRateRoutes(exchangeRateHandler)
+	apiRoutes.SetupAnalyticsRoutes(analyticsHandler)
+	apiRoutes.SetupWebhookRoutes(webhookHandler)
 	apiRoutes.SetupAuthRoutes(authHandler)
 	apiRoutes.SetupSwagger()
 
 	appConfig := config.Config.App
 	log.Println("Server is running on port: ", appConfig.Port)
-	err := http.ListenAndServe(fmt.Sprintf(":%d", appConfig.Port), router)
+	err = http.ListenAndServe(fmt.Sprintf(":%d", appConfig.Port), router)
 	if err != nil {
 		panic(err)
 	}
@@ -43,18 +43,37 @@ func (c *CacheService) DeleteOrder(orderID int) {
 	log.Printf("Order with ID %d has been removed from cache under key '%s'", orderID, key)
 }
 
-func (c *CacheService) SetOrders(key string, orders []models.Order) {
-	c.cache.Set(key, orders, cache.DefaultExpiration)
+func (c *CacheService) SetOrders(key string, page *models.OrderPage) {
+	c.cache.Set(key, page, cache.DefaultExpiration)
 	log.Printf("Orders have been cached under key '%s'", key)
 }
 
-func (c *CacheService) GetOrders(key string) ([]models.Order, bool) {
-	orders, found := c.cache.Get(key)
+func (c *CacheService) GetOrders(key string) (*models.OrderPage, bool) {
+	page, found := c.cache.Get(key)
 	if found {
 		log.Printf("Orders found in cache under key '%s'", key)
-		return orders.([]models.Order), true
+		return page.(*models.OrderPage), true
 	}
 
 	log.Printf("Orders not found in cache under key '%s'", key)
 	return nil, false
 }
+
+// SetAnalytics кэширует отчет по продажам на время ttl, независимо от срока хранения заказов
+func (c *CacheService) SetAnalytics(key string, report interface{}, ttl time.Duration) {
+	key = "analytics_" + key
+	c.cache.Set(key, report, ttl)
+	log.Printf("Analytics report has been cached under key '%s'", key)
+}
+
+func (c *CacheService) GetAnalytics(key string) (interface{}, bool) {
+	key = "analytics_" + key
+	report, found := c.cache.Get(key)
+	if found {
+		log.Printf("Analytics report found in cache under key '%s'", key)
+		return report, true
+	}
+
+	log.Printf("Analytics report not found in cache under key '%s'", key)
+	return nil, false
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"TestTask/internal/models"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Поддерживаемые форматы выгрузки
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Writer построчно записывает табличные данные, не накапливая их в памяти.
+// Значения ячеек могут быть строками, целыми и дробными числами или time.Time.
+type Writer interface {
+	WriteRow(values ...interface{}) error
+	// Close дописывает служебные данные формата и сбрасывает буферы. Закрывать исходный io.Writer не нужно.
+	Close() error
+}
+
+// NewWriter создает Writer для указанного формата
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ContentType возвращает MIME-тип файла выгрузки
+func ContentType(format string) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// IsSupportedFormat проверяет, поддерживается ли формат выгрузки
+func IsSupportedFormat(format string) bool {
+	return format == FormatCSV || format == FormatXLSX
+}
+
+// formatValue приводит значение ячейки к строке
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case models.Money:
+		return v.String()
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
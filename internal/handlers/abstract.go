@@ -1,13 +1,27 @@
 package handlers
 
-import "TestTask/internal/models"
+import (
+	"TestTask/internal/models"
+	"io"
+)
 
 type OrderServiceInterface interface {
 	CreateOrder(order *models.Order) error
-	UpdateOrder(order *models.Order) error
+	BulkCreateOrders(orders []models.Order) ([]models.BulkOrderResult, error)
+	BulkTransitionOrders(updates []models.OrderStatusUpdate, actor models.Actor) ([]models.BulkOrderResult, error)
+	UpdateOrder(order *models.Order, actor models.Actor) error
+	PatchOrder(orderID int, patch []byte, version int, actor models.Actor) (*models.Order, error)
+	TransitionOrder(orderID int, status string, actor models.Actor, reason string) (*models.Order, error)
+	CancelOrder(orderID int, reasonCode, note string, actor models.Actor) (*models.Order, error)
 	DeleteOrder(orderID int) error
-	GetOrderByID(orderID int) (*models.Order, error)
-	GetOrdersByFilters(status string, minPrice, maxPrice float64) ([]models.Order, error)
+	RestoreOrder(orderID int) error
+	PurgeDeletedOrders() (int, error)
+	GetOrderByID(orderID int, actor models.Actor) (*models.Order, error)
+	GetOrderStatusHistory(orderID int, actor models.Actor) ([]models.OrderStatusChange, error)
+	AddOrderNote(orderID int, text string, internal bool, actor models.Actor) (*models.OrderNote, error)
+	GetOrderNotes(orderID int, actor models.Actor) ([]models.OrderNote, error)
+	GetOrdersByFilters(filter models.OrderFilter, actor models.Actor) (*models.OrderPage, error)
+	ExportOrders(filter models.OrderFilter, fn func(order *models.Order) error) error
 }
 
 type AuthServiceInterface interface {
@@ -18,11 +32,57 @@ type AuthServiceInterface interface {
 type ProductServiceInterface interface {
 	CreateProduct(product *models.Product) error
 	UpdateProduct(product *models.Product) error
+	PatchProduct(productID int, patch []byte) (*models.Product, error)
+	ImportProducts(r io.Reader) (*models.ProductImportReport, error)
 	DeleteProduct(productID int) error
 	GetProductByID(productID int) (*models.Product, error)
 	GetAllProducts() ([]models.Product, error)
 }
 
+type CouponServiceInterface interface {
+	CreateCoupon(coupon *models.Coupon) error
+	UpdateCoupon(coupon *models.Coupon) error
+	DeleteCoupon(couponID int) error
+	GetCouponByID(couponID int) (*models.Coupon, error)
+	GetAllCoupons() ([]models.Coupon, error)
+}
+
+type CustomerServiceInterface interface {
+	CreateCustomer(customer *models.Customer) error
+	UpdateCustomer(customer *models.Customer) error
+	DeleteCustomer(customerID int) error
+	GetCustomerByID(customerID int) (*models.Customer, error)
+	GetAllCustomers() ([]models.Customer, error)
+}
+
+type ExchangeRateServiceInterface interface {
+	SetExchangeRate(rate *models.ExchangeRate) error
+	DeleteExchangeRate(fromCurrency, toCurrency string) error
+	GetAllExchangeRates() ([]models.ExchangeRate, error)
+}
+
+type WebhookServiceInterface interface {
+	CreateWebhook(webhook *models.Webhook) error
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(webhookID int) error
+	GetWebhookByID(webhookID int) (*models.Webhook, error)
+	GetAllWebhooks() ([]models.Webhook, error)
+	GetWebhookDeliveries(webhookID int) ([]models.WebhookDelivery, error)
+	ReplayDelivery(deliveryID int) (*models.WebhookDelivery, error)
+}
+
+type AnalyticsServiceInterface interface {
+	GetRevenue(filter models.AnalyticsFilter) (*models.RevenueReport, error)
+	GetOrdersByStatus(filter models.AnalyticsFilter) ([]models.OrderStatusStats, error)
+	GetTopProducts(filter models.AnalyticsFilter) ([]models.ProductSales, error)
+}
+
 type LogServiceInterface interface {
 	CreateLog(action, details string, userID int) error
 }
+
+type IdempotencyServiceInterface interface {
+	Begin(userID int, key, requestHash string) (*models.IdempotencyRecord, error)
+	Complete(userID int, key string, status int, headers map[string]string, body []byte) error
+	Release(userID int, key string) error
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"TestTask/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type AnalyticsHandler struct {
+	service AnalyticsServiceInterface
+}
+
+func NewAnalyticsHandler(service AnalyticsServiceInterface) *AnalyticsHandler {
+	return &AnalyticsHandler{service: service}
+}
+
+// analyticsErrorStatus сопоставляет ошибки сервиса аналитики с HTTP статусами
+func analyticsErrorStatus(err error) int {
+	if errors.Is(err, models.ErrInvalidFilter) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetRevenue godoc
+// @Summary Get revenue by period
+// @Description Get the number of orders and revenue in the base currency grouped by day, week or month. Cancelled and deleted orders are excluded. Results are cached for a short time
+// @Tags analytics
+// @Produce json
+// @Param interval query string false "Grouping interval: day, week or month" default(day)
+// @Param from query string false "Orders created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Orders created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)"
+// @Success 200 {object} models.RevenueReport "Revenue by period"
+// @Failure 400 {object} ErrorResponse "Invalid parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /analytics/revenue [get]
+func (h *AnalyticsHandler) GetRevenue(rw http.ResponseWriter, r *http.Request) {
+	filter, err := parseAnalyticsFilter(r.URL.Query())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetRevenue(filter)
+	if err != nil {
+		http.Error(rw, err.Error(), analyticsErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(report)
+}
+
+// GetOrdersByStatus godoc
+// @Summary Get orders by status
+// @Description Get the number of orders and their total in the base currency for each status. Deleted orders are excluded. Results are cached for a short time
+// @Tags analytics
+// @Produce json
+// @Param from query string false "Orders created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Orders created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)"
+// @Success 200 {array} models.OrderStatusStats "Orders by status"
+// @Failure 400 {object} ErrorResponse "Invalid parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /analytics/orders-by-status [get]
+func (h *AnalyticsHandler) GetOrdersByStatus(rw http.ResponseWriter, r *http.Request) {
+	filter, err := parseAnalyticsFilter(r.URL.Query())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.service.GetOrdersByStatus(filter)
+	if err != nil {
+		http.Error(rw, err.Error(), analyticsErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(stats)
+}
+
+// GetTopProducts godoc
+// @Summary Get top selling products
+// @Description Get the products with the highest revenue in the base currency. Each order total, after discounts and with tax, is split between its items in proportion to their value, so product revenue adds up to the other reports. Cancelled and deleted orders are excluded. Results are cached for a short time
+// @Tags analytics
+// @Produce json
+// @Param limit query int false "Number of products (max 100)" default(10)
+// @Param from query string false "Orders created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Orders created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)"
+// @Success 200 {array} models.ProductSales "Top selling products"
+// @Failure 400 {object} ErrorResponse "Invalid parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /analytics/top-products [get]
+func (h *AnalyticsHandler) GetTopProducts(rw http.ResponseWriter, r *http.Request) {
+	filter, err := parseAnalyticsFilter(r.URL.Query())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.service.GetTopProducts(filter)
+	if err != nil {
+		http.Error(rw, err.Error(), analyticsErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(products)
+}
+
+// parseAnalyticsFilter разбирает параметры запроса отчетов по продажам
+func parseAnalyticsFilter(query url.Values) (models.AnalyticsFilter, error) {
+	filter := models.AnalyticsFilter{Interval: query.Get("interval")}
+
+	var err error
+
+	if filter.From, err = parseFilterTime(query.Get("from"), false); err != nil {
+		return filter, fmt.Errorf("%w: invalid from parameter", models.ErrInvalidFilter)
+	}
+
+	if filter.To, err = parseFilterTime(query.Get("to"), true); err != nil {
+		return filter, fmt.Errorf("%w: invalid to parameter", models.ErrInvalidFilter)
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		filter.Limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid limit parameter", models.ErrInvalidFilter)
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"TestTask/internal/middleware"
+	"TestTask/internal/models"
+	"net/http"
+)
+
+// userIDFromContext возвращает ID пользователя, добавленный в контекст AuthMiddleware
+func userIDFromContext(r *http.Request) (int, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	return userID, ok
+}
+
+// roleFromContext возвращает роль пользователя, добавленную в контекст AuthMiddleware
+func roleFromContext(r *http.Request) string {
+	role, _ := r.Context().Value(middleware.UserRoleKey).(string)
+	return role
+}
+
+// actorFromContext возвращает пользователя, от имени которого выполняется запрос
+func actorFromContext(r *http.Request) (models.Actor, bool) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		return models.Actor{}, false
+	}
+	return models.Actor{UserID: userID, Role: roleFromContext(r)}, true
+}
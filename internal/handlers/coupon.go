@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"TestTask/internal/models"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+type CouponHandler struct {
+	service CouponServiceInterface
+}
+
+func NewCouponHandler(service CouponServiceInterface) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+// couponErrorStatus сопоставляет ошибки сервиса купонов с HTTP статусами
+func couponErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidCouponData):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrCouponNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrCouponCodeTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateCoupon godoc
+// @Summary Create a coupon
+// @Description Create a percentage or fixed amount coupon. Codes are case-insensitive and stored in upper case
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param coupon body models.Coupon true "Coupon data"
+// @Success 201 {object} models.Coupon "Coupon created successfully"
+// @Failure 400 {object} ErrorResponse "Invalid coupon data"
+// @Failure 409 {object} ErrorResponse "Coupon with this code already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /coupons [post]
+func (h *CouponHandler) CreateCoupon(rw http.ResponseWriter, r *http.Request) {
+	var coupon models.Coupon
+	err := json.NewDecoder(r.Body).Decode(&coupon)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.CreateCoupon(&coupon)
+	if err != nil {
+		http.Error(rw, err.Error(), couponErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(coupon)
+}
+
+// UpdateCoupon godoc
+// @Summary Update a coupon
+// @Description Replace the conditions of a coupon. The usage counter is kept
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param id path int true "Coupon ID"
+// @Param coupon body models.Coupon true "Updated coupon data"
+// @Success 200 {object} models.Coupon "Coupon updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid coupon ID or data"
+// @Failure 404 {object} ErrorResponse "Coupon not found"
+// @Failure 409 {object} ErrorResponse "Coupon with this code already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /coupons/{id} [put]
+func (h *CouponHandler) UpdateCoupon(rw http.ResponseWriter, r *http.Request) {
+	couponID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid coupon ID", http.StatusBadRequest)
+		return
+	}
+
+	var coupon models.Coupon
+	err = json.NewDecoder(r.Body).Decode(&coupon)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+	coupon.ID = couponID
+
+	err = h.service.UpdateCoupon(&coupon)
+	if err != nil {
+		http.Error(rw, err.Error(), couponErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(coupon)
+}
+
+// DeleteCoupon godoc
+// @Summary Delete a coupon
+// @Description Delete a coupon. Discounts already applied to orders are kept
+// @Tags coupons
+// @Param id path int true "Coupon ID"
+// @Success 204 "Coupon deleted successfully"
+// @Failure 400 {object} ErrorResponse "Invalid coupon ID"
+// @Failure 404 {object} ErrorResponse "Coupon not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /coupons/{id} [delete]
+func (h *CouponHandler) DeleteCoupon(rw http.ResponseWriter, r *http.Request) {
+	couponID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid coupon ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.DeleteCoupon(couponID)
+	if err != nil {
+		http.Error(rw, err.Error(), couponErrorStatus(err))
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// GetCouponByID godoc
+// @Summary Get a coupon by ID
+// @Description Get a coupon with its usage counter
+// @Tags coupons
+// @Produce json
+// @Param id path int true "Coupon ID"
+// @Success 200 {object} models.Coupon "Coupon details"
+// @Failure 400 {object} ErrorResponse "Invalid coupon ID"
+// @Failure 404 {object} ErrorResponse "Coupon not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /coupons/{id} [get]
+func (h *CouponHandler) GetCouponByID(rw http.ResponseWriter, r *http.Request) {
+	couponID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid coupon ID", http.StatusBadRequest)
+		return
+	}
+
+	coupon, err := h.service.GetCouponByID(couponID)
+	if err != nil {
+		http.Error(rw, err.Error(), couponErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(coupon)
+}
+
+// GetAllCoupons godoc
+// @Summary Get all coupons
+// @Description Retrieve a list of all coupons
+// @Tags coupons
+// @Produce json
+// @Success 200 {array} models.Coupon "List of all coupons"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /coupons [get]
+func (h *CouponHandler) GetAllCoupons(rw http.ResponseWriter, r *http.Request) {
+	coupons, err := h.service.GetAllCoupons()
+	if err != nil {
+		http.Error(rw, err.Error(), couponErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(coupons)
+}
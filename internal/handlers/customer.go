@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"TestTask/internal/models"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+type CustomerHandler struct {
+	service      CustomerServiceInterface
+	orderService OrderServiceInterface
+}
+
+func NewCustomerHandler(service CustomerServiceInterface, orderService OrderServiceInterface) *CustomerHandler {
+	return &CustomerHandler{service: service, orderService: orderService}
+}
+
+// customerErrorStatus сопоставляет ошибки сервиса клиентов с HTTP статусами
+func customerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidCustomerData):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrCustomerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrCustomerEmailTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateCustomer godoc
+// @Summary Create a customer
+// @Description Create a customer with contact details and delivery addresses. Emails are case-insensitive and must be unique
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param customer body models.Customer true "Customer data"
+// @Success 201 {object} models.Customer "Customer created successfully"
+// @Failure 400 {object} ErrorResponse "Invalid customer data"
+// @Failure 409 {object} ErrorResponse "Customer with this email already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /customers [post]
+func (h *CustomerHandler) CreateCustomer(rw http.ResponseWriter, r *http.Request) {
+	var customer models.Customer
+	err := json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.CreateCustomer(&customer)
+	if err != nil {
+		http.Error(rw, err.Error(), customerErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(customer)
+}
+
+// UpdateCustomer godoc
+// @Summary Update a customer
+// @Description Replace the contact details and addresses of a customer. Names in existing orders are not changed
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param customer body models.Customer true "Updated customer data"
+// @Success 200 {object} models.Customer "Customer updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid customer ID or data"
+// @Failure 404 {object} ErrorResponse "Customer not found"
+// @Failure 409 {object} ErrorResponse "Customer with this email already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /customers/{id} [put]
+func (h *CustomerHandler) UpdateCustomer(rw http.ResponseWriter, r *http.Request) {
+	customerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	var customer models.Customer
+	err = json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+	customer.ID = customerID
+
+	err = h.service.UpdateCustomer(&customer)
+	if err != nil {
+		http.Error(rw, err.Error(), customerErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(customer)
+}
+
+// DeleteCustomer godoc
+// @Summary Delete a customer
+// @Description Delete a customer. Orders of the customer are kept with the customer name they were placed with
+// @Tags customers
+// @Param id path int true "Customer ID"
+// @Success 204 "Customer deleted successfully"
+// @Failure 400 {object} ErrorResponse "Invalid customer ID"
+// @Failure 404 {object} ErrorResponse "Customer not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /customers/{id} [delete]
+func (h *CustomerHandler) DeleteCustomer(rw http.ResponseWriter, r *http.Request) {
+	customerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.DeleteCustomer(customerID)
+	if err != nil {
+		http.Error(rw, err.Error(), customerErrorStatus(err))
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// GetCustomerByID godoc
+// @Summary Get a customer by ID
+// @Description Get a customer with contact details and addresses
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} models.Customer "Customer details"
+// @Failure 400 {object} ErrorResponse "Invalid customer ID"
+// @Failure 404 {object} ErrorResponse "Customer not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /customers/{id} [get]
+func (h *CustomerHandler) GetCustomerByID(rw http.ResponseWriter, r *http.Request) {
+	customerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.service.GetCustomerByID(customerID)
+	if err != nil {
+		http.Error(rw, err.Error(), customerErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(customer)
+}
+
+// GetAllCustomers godoc
+// @Summary Get all customers
+// @Description Retrieve a list of all customers
+// @Tags customers
+// @Produce json
+// @Success 200 {array} models.Customer "List of all customers"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /customers [get]
+func (h *CustomerHandler) GetAllCustomers(rw http.ResponseWriter, r *http.Request) {
+	customers, err := h.service.GetAllCustomers()
+	if err != nil {
+		http.Error(rw, err.Error(), customerErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(customers)
+}
+
+// GetCustomerOrders godoc
+// @Summary Get orders of a customer
+// @Description Get a page of the customer's orders. Accepts the same filters, sorting and pagination parameters as GET /orders
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param status query string false "Comma-separated order statuses, e.g. pending,confirmed"
+// @Param sort query string false "Sort field: created_at, total_price or status; prefix with - for descending order" default(-created_at)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} models.OrderPage "Page of orders"
+// @Failure 400 {object} ErrorResponse "Invalid customer ID or filter parameters"
+// @Failure 404 {object} ErrorResponse "Customer not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /customers/{id}/orders [get]
+func (h *CustomerHandler) GetCustomerOrders(rw http.ResponseWriter, r *http.Request) {
+	customerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.CustomerID = customerID
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	_, err = h.service.GetCustomerByID(customerID)
+	if err != nil {
+		http.Error(rw, err.Error(), customerErrorStatus(err))
+		return
+	}
+
+	page, err := h.orderService.GetOrdersByFilters(filter, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(page)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"TestTask/internal/models"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+type ExchangeRateHandler struct {
+	service ExchangeRateServiceInterface
+}
+
+func NewExchangeRateHandler(service ExchangeRateServiceInterface) *ExchangeRateHandler {
+	return &ExchangeRateHandler{service: service}
+}
+
+// exchangeRateErrorStatus сопоставляет ошибки сервиса курсов валют с HTTP статусами
+func exchangeRateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidExchangeRate):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// SetExchangeRate godoc
+// @Summary Set an exchange rate
+// @Description Create or replace the rate of a currency pair: one unit of from_currency costs rate units of to_currency.
+// @Description The reverse pair is derived automatically when it is not set explicitly
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param rate body models.ExchangeRate true "Exchange rate"
+// @Success 200 {object} models.ExchangeRate "Exchange rate saved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid exchange rate data"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /exchange-rates [put]
+func (h *ExchangeRateHandler) SetExchangeRate(rw http.ResponseWriter, r *http.Request) {
+	var rate models.ExchangeRate
+	err := json.NewDecoder(r.Body).Decode(&rate)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.SetExchangeRate(&rate)
+	if err != nil {
+		http.Error(rw, err.Error(), exchangeRateErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(rate)
+}
+
+// DeleteExchangeRate godoc
+// @Summary Delete an exchange rate
+// @Description Delete the rate of a currency pair. Orders that were already created keep their exchange rate
+// @Tags exchange-rates
+// @Param from path string true "Source currency" example(EUR)
+// @Param to path string true "Target currency" example(RUB)
+// @Success 204 "Exchange rate deleted successfully"
+// @Failure 404 {object} ErrorResponse "Exchange rate not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /exchange-rates/{from}/{to} [delete]
+func (h *ExchangeRateHandler) DeleteExchangeRate(rw http.ResponseWriter, r *http.Request) {
+	err := h.service.DeleteExchangeRate(chi.URLParam(r, "from"), chi.URLParam(r, "to"))
+	if err != nil {
+		http.Error(rw, err.Error(), exchangeRateErrorStatus(err))
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// GetAllExchangeRates godoc
+// @Summary Get all exchange rates
+// @Description Retrieve the stored rates of all currency pairs
+// @Tags exchange-rates
+// @Produce json
+// @Success 200 {array} models.ExchangeRate "List of exchange rates"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /exchange-rates [get]
+func (h *ExchangeRateHandler) GetAllExchangeRates(rw http.ResponseWriter, r *http.Request) {
+	rates, err := h.service.GetAllExchangeRates()
+	if err != nil {
+		http.Error(rw, err.Error(), exchangeRateErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(rates)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"TestTask/internal/models"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotentResponseHeaders заголовки ответа, которые сохраняются и отдаются повторно вместе с телом:
+// без ETag клиент, повторивший создание заказа, не сможет обновить его с If-Match
+var idempotentResponseHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// responseRecorder запоминает статус и тело ответа, одновременно передавая их клиенту
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// serveIdempotent выполняет next не более одного раза для пары (пользователь, Idempotency-Key).
+// Успешный ответ (статус, заголовки idempotentResponseHeaders и тело) сохраняется и отдается повторно
+// на ретраи с тем же телом запроса;
+// тот же ключ с другим телом отклоняется со статусом 422. Если next завершился ошибкой или паникой,
+// ключ освобождается, чтобы запрос можно было повторить. Запросы без заголовка передаются next как есть.
+func serveIdempotent(service IdempotencyServiceInterface, rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key == "" {
+		next(rw, r)
+		return
+	}
+
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+
+	stored, err := service.Begin(userID, key, hex.EncodeToString(hash[:]))
+	switch {
+	case errors.Is(err, models.ErrInvalidIdempotencyKey):
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, models.ErrIdempotencyKeyReused):
+		http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, models.ErrIdempotencyKeyInUse):
+		http.Error(rw, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if stored != nil {
+		rw.Header().Set("Content-Type", "application/json")
+		for name, value := range stored.ResponseHeaders {
+			rw.Header().Set(name, value)
+		}
+		rw.Header().Set(idempotentReplayedHeader, "true")
+		rw.WriteHeader(stored.ResponseStatus)
+		rw.Write(stored.ResponseBody)
+		return
+	}
+
+	// Паника в next не должна оставить ключ занятым до истечения срока: все повторы получали бы 409
+	defer func() {
+		if p := recover(); p != nil {
+			if err := service.Release(userID, key); err != nil {
+				log.Printf("failed to release idempotency key %q after panic: %v", key, err)
+			}
+			panic(p)
+		}
+	}()
+
+	rec := &responseRecorder{ResponseWriter: rw}
+	next(rec, r)
+
+	// Ответ уже отправлен клиенту, поэтому ошибки сохранения только логируются
+	if rec.status >= 200 && rec.status < 300 {
+		headers := map[string]string{}
+		for _, name := range idempotentResponseHeaders {
+			if value := rec.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		err = service.Complete(userID, key, rec.status, headers, rec.body.Bytes())
+	} else {
+		err = service.Release(userID, key)
+	}
+	if err != nil {
+		log.Printf("failed to finish idempotent request with key %q: %v", key, err)
+	}
+}
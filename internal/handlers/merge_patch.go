@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+)
+
+// mergePatchContentType тип содержимого документа JSON Merge Patch (RFC 7396)
+const mergePatchContentType = "application/merge-patch+json"
+
+// readMergePatch проверяет тип содержимого запроса и читает тело документа JSON Merge Patch.
+// Для удобства клиентов также принимается application/json.
+func readMergePatch(rw http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != mergePatchContentType && mediaType != "application/json") {
+		rw.Header().Set("Accept-Patch", mergePatchContentType)
+		http.Error(rw, "Content-Type must be "+mergePatchContentType, http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return nil, false
+	}
+	return patch, true
+}
@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"TestTask/internal/export"
 	"TestTask/internal/models"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type OrderHandler struct {
-	service    OrderServiceInterface
-	logService LogServiceInterface
+	service            OrderServiceInterface
+	logService         LogServiceInterface
+	idempotencyService IdempotencyServiceInterface
 }
 
 type ErrorResponse struct {
@@ -19,24 +26,88 @@ type ErrorResponse struct {
 	Code    int    `json:"code"`
 }
 
-func NewOrderHandler(service OrderServiceInterface, logService LogServiceInterface) *OrderHandler {
-	return &OrderHandler{service: service, logService: logService}
+// OrderTransitionRequest структура запроса на смену статуса заказа
+type OrderTransitionRequest struct {
+	Status string `json:"status" example:"confirmed"`
+	Reason string `json:"reason,omitempty" example:"Payment received"`
+}
+
+// CancelOrderRequest структура запроса на отмену заказа
+type CancelOrderRequest struct {
+	ReasonCode string `json:"reason_code" example:"customer_request" enums:"customer_request,payment_failed,out_of_stock,duplicate,fraud_suspected,other"`
+	Note       string `json:"note,omitempty" example:"Customer changed their mind"` // обязателен для кода other
+}
+
+// OrderNoteRequest структура запроса на добавление заметки к заказу
+type OrderNoteRequest struct {
+	Text       string `json:"text" example:"Customer asked to deliver after 6 pm"`
+	IsInternal *bool  `json:"is_internal,omitempty" example:"true"` // видна только администраторам; по умолчанию true для администраторов
+}
+
+// BulkCreateOrdersRequest структура запроса на пакетное создание заказов
+type BulkCreateOrdersRequest struct {
+	Orders []models.Order `json:"orders"`
+}
+
+// BulkStatusRequest структура запроса на пакетную смену статусов заказов
+type BulkStatusRequest struct {
+	Updates []models.OrderStatusUpdate `json:"updates"`
+}
+
+// BulkOrdersResponse отчет о пакетной обработке заказов
+type BulkOrdersResponse struct {
+	Results   []models.BulkOrderResult `json:"results"`
+	Succeeded int                      `json:"succeeded" example:"2"`
+	Failed    int                      `json:"failed" example:"1"`
+}
+
+func NewOrderHandler(service OrderServiceInterface, logService LogServiceInterface, idempotencyService IdempotencyServiceInterface) *OrderHandler {
+	return &OrderHandler{service: service, logService: logService, idempotencyService: idempotencyService}
+}
+
+// orderErrorStatus сопоставляет ошибку сервиса заказов с HTTP статусом
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidOrderData), errors.Is(err, models.ErrInvalidFilter):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrOrderNoteForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrInvalidStatusTransition), errors.Is(err, models.ErrOutOfStock):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, models.ErrCouponNotApplicable), errors.Is(err, models.ErrExchangeRateNotFound):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Create a new order by providing order data
+// @Description Create a new order by providing customer data and a list of order items. The customer is given either by customer_id or by customer_name; a customer with the same name (ignoring case) is reused, otherwise a new customer record is created. An optional coupon_code applies a discount to the order subtotal, and region selects the tax rules used to calculate tax_amount.
+// @Description Prices are converted to the order currency (the base currency by default) using the stored exchange rates
+// @Description Retries with the same Idempotency-Key and body replay the original response instead of creating another order
 // @Tags orders
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Client-generated key that makes retries of this request safe"
 // @Param order body models.Order true "Order data"
 // @Success 201 {object} models.Order
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Product is out of stock or a request with the same key is still in progress"
+// @Failure 422 {object} ErrorResponse "Coupon cannot be applied, no exchange rate for the order currency, or idempotency key was already used with a different request"
 // @Failure 500 {object} ErrorResponse
 // @Security ApiKeyAuth
 // @Roles User, Admin
 // @Router /orders [post]
 func (h *OrderHandler) CreateOrder(rw http.ResponseWriter, r *http.Request) {
+	serveIdempotent(h.idempotencyService, rw, r, h.createOrder)
+}
+
+func (h *OrderHandler) createOrder(rw http.ResponseWriter, r *http.Request) {
 	var order models.Order
 
 	decoder := json.NewDecoder(r.Body)
@@ -46,27 +117,19 @@ func (h *OrderHandler) CreateOrder(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.CreateOrder(&order)
-	if err != nil {
-		if strings.Contains(err.Error(), "invalid order data") {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
-		}
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
 		return
 	}
+	order.UserID = userID
 
-	cookie, err := r.Cookie("user_id")
-	if err != nil || cookie == nil {
-		http.Error(rw, "User ID cookie not found", http.StatusUnauthorized)
-		return
-	}
-
-	userID, err := strconv.Atoi(cookie.Value)
+	err = h.service.CreateOrder(&order)
 	if err != nil {
-		http.Error(rw, "Invalid User ID", http.StatusUnauthorized)
+		http.Error(rw, err.Error(), orderErrorStatus(err))
 		return
 	}
+
 	action := "create_order"
 	details := "Order created successfully"
 
@@ -76,20 +139,141 @@ func (h *OrderHandler) CreateOrder(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rw.Header().Set("ETag", orderETag(&order))
 	rw.WriteHeader(http.StatusCreated)
 	json.NewEncoder(rw).Encode(order)
 }
 
+// BulkCreateOrders godoc
+// @Summary Create orders in bulk
+// @Description Create up to 500 orders in one request. Each order is validated and stored separately, the response reports the outcome of every order
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param orders body BulkCreateOrdersRequest true "Orders to create"
+// @Success 200 {object} BulkOrdersResponse "Per-order results"
+// @Failure 400 {object} ErrorResponse "Invalid input data or batch size"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles User, Admin
+// @Router /orders/bulk [post]
+func (h *OrderHandler) BulkCreateOrders(rw http.ResponseWriter, r *http.Request) {
+	var request BulkCreateOrdersRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	for i := range request.Orders {
+		request.Orders[i].UserID = userID
+	}
+
+	results, err := h.service.BulkCreateOrders(request.Orders)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	response := newBulkOrdersResponse(results, http.StatusCreated)
+
+	details := fmt.Sprintf("Bulk created %d of %d orders", response.Succeeded, len(results))
+	err = h.logService.CreateLog("bulk_create_orders", details, userID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(response)
+}
+
+// BulkTransitionOrders godoc
+// @Summary Change order statuses in bulk
+// @Description Apply up to 500 status transitions in one request. Each transition follows the same rules as POST /orders/{id}/transitions, the response reports the outcome of every transition
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param updates body BulkStatusRequest true "Status transitions"
+// @Success 200 {object} BulkOrdersResponse "Per-order results"
+// @Failure 400 {object} ErrorResponse "Invalid input data or batch size"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles User, Admin
+// @Router /orders/bulk-status [post]
+func (h *OrderHandler) BulkTransitionOrders(rw http.ResponseWriter, r *http.Request) {
+	var request BulkStatusRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	results, err := h.service.BulkTransitionOrders(request.Updates, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	response := newBulkOrdersResponse(results, http.StatusOK)
+
+	details := fmt.Sprintf("Bulk changed status of %d of %d orders", response.Succeeded, len(results))
+	err = h.logService.CreateLog("bulk_transition_orders", details, actor.UserID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(response)
+}
+
+// newBulkOrdersResponse заполняет коды результатов пакетной обработки и подсчитывает итоги
+func newBulkOrdersResponse(results []models.BulkOrderResult, successCode int) BulkOrdersResponse {
+	response := BulkOrdersResponse{Results: results}
+	for i := range results {
+		if results[i].Err != nil {
+			results[i].Code = orderErrorStatus(results[i].Err)
+			results[i].Error = results[i].Err.Error()
+			response.Failed++
+			continue
+		}
+		results[i].Code = successCode
+		response.Succeeded++
+	}
+	return response
+}
+
 // UpdateOrder godoc
 // @Summary Update an existing order
-// @Description Update an existing order by providing order data
+// @Description Update an existing order by providing order data. Orders cannot be cancelled here, use POST /orders/{id}/cancel
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param id path int true "Order ID"
+// @Param If-Match header string true "ETag of the order version being updated, or * to skip the check"
 // @Param order body models.Order true "Updated order data"
 // @Success 200 {object} models.Order "Order updated successfully"
+// @Header 200 {string} ETag "New order version"
 // @Failure 400 {object} ErrorResponse "Invalid order ID or data"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 409 {object} ErrorResponse "Illegal status transition"
+// @Failure 412 {object} ErrorResponse "Order has been modified since the given version"
+// @Failure 428 {object} ErrorResponse "If-Match header is required"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Roles User, Admin
@@ -107,6 +291,18 @@ func (h *OrderHandler) UpdateOrder(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		http.Error(rw, "If-Match header is required", http.StatusPreconditionRequired)
+		return
+	}
+
+	version, err := parseOrderETag(ifMatch)
+	if err != nil {
+		http.Error(rw, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+
 	var order models.Order
 
 	decoder := json.NewDecoder(r.Body)
@@ -116,41 +312,353 @@ func (h *OrderHandler) UpdateOrder(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 	order.ID = orderID
+	order.Version = version
 
-	err = h.service.UpdateOrder(&order)
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.service.UpdateOrder(&order, actor)
 	if err != nil {
-		if strings.Contains(err.Error(), "invalid order data") {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
-		}
+		http.Error(rw, err.Error(), orderErrorStatus(err))
 		return
 	}
 
-	cookie, err := r.Cookie("user_id")
-	if err != nil || cookie == nil {
-		http.Error(rw, "User ID cookie not found", http.StatusUnauthorized)
+	action := "update_order"
+	details := "Order updated successfully"
+
+	err = h.logService.CreateLog(action, details, actor.UserID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	userID, err := strconv.Atoi(cookie.Value)
+	rw.Header().Set("ETag", orderETag(&order))
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(order)
+}
+
+// PatchOrder godoc
+// @Summary Partially update an order
+// @Description Apply a JSON Merge Patch (RFC 7396) document to an order. Omitted fields keep their stored values
+// @Tags orders
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param If-Match header string true "ETag of the order version being updated, or * to skip the check"
+// @Param patch body models.Order true "Merge patch document"
+// @Success 200 {object} models.Order "Order updated successfully"
+// @Header 200 {string} ETag "New order version"
+// @Failure 400 {object} ErrorResponse "Invalid order ID or data"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 409 {object} ErrorResponse "Illegal status transition"
+// @Failure 412 {object} ErrorResponse "Order has been modified since the given version"
+// @Failure 415 {object} ErrorResponse "Unsupported patch format"
+// @Failure 428 {object} ErrorResponse "If-Match header is required"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles User, Admin
+// @Router /orders/{id} [patch]
+func (h *OrderHandler) PatchOrder(rw http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(rw, "Invalid User ID", http.StatusUnauthorized)
+		http.Error(rw, "Invalid order ID", http.StatusBadRequest)
 		return
 	}
-	action := "update_order"
-	details := "Order updated successfully"
 
-	err = h.logService.CreateLog(action, details, userID)
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		http.Error(rw, "If-Match header is required", http.StatusPreconditionRequired)
+		return
+	}
+
+	version, err := parseOrderETag(ifMatch)
+	if err != nil {
+		http.Error(rw, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+
+	patch, ok := readMergePatch(rw, r)
+	if !ok {
+		return
+	}
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.service.PatchOrder(orderID, patch, version, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	err = h.logService.CreateLog("update_order", "Order updated successfully", actor.UserID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("ETag", orderETag(order))
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(order)
+}
+
+// TransitionOrder godoc
+// @Summary Change order status
+// @Description Move an order to a new status. Allowed transitions: pending→confirmed→shipped→delivered
+// @Description Orders are cancelled with a reason code through POST /orders/{id}/cancel
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param transition body OrderTransitionRequest true "Target status"
+// @Success 200 {object} models.Order "Order status changed successfully"
+// @Failure 400 {object} ErrorResponse "Invalid order ID or status"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 409 {object} ErrorResponse "Illegal status transition"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles User, Admin
+// @Router /orders/{id}/transitions [post]
+func (h *OrderHandler) TransitionOrder(rw http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var transition OrderTransitionRequest
+	err = json.NewDecoder(r.Body).Decode(&transition)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.service.TransitionOrder(orderID, transition.Status, actor, transition.Reason)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	details := fmt.Sprintf("Order %d moved to status %s", orderID, order.Status)
+	err = h.logService.CreateLog("transition_order", details, actor.UserID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("ETag", orderETag(order))
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(order)
+}
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Cancel a pending or confirmed order with a reason code and an optional note. Reserved stock is returned to the catalog
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param cancellation body CancelOrderRequest true "Cancellation reason"
+// @Success 200 {object} models.Order "Order cancelled successfully"
+// @Header 200 {string} ETag "New order version"
+// @Failure 400 {object} ErrorResponse "Invalid order ID or cancellation reason"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 409 {object} ErrorResponse "Order can no longer be cancelled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles User, Admin
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(rw http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request CancelOrderRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.service.CancelOrder(orderID, request.ReasonCode, request.Note, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	details := fmt.Sprintf("Order %d cancelled with reason %s", orderID, request.ReasonCode)
+	err = h.logService.CreateLog("cancel_order", details, actor.UserID)
 	if err != nil {
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	rw.Header().Set("ETag", orderETag(order))
+	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
 	json.NewEncoder(rw).Encode(order)
 }
 
+// GetOrderStatusHistory godoc
+// @Summary Get order status history
+// @Description Get the timeline of status changes of an order
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.OrderStatusChange "Status changes in chronological order"
+// @Failure 400 {object} ErrorResponse "Invalid order ID"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles User, Admin
+// @Router /orders/{id}/history [get]
+func (h *OrderHandler) GetOrderStatusHistory(rw http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	history, err := h.service.GetOrderStatusHistory(orderID, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(history)
+}
+
+// AddOrderNote godoc
+// @Summary Add a note to an order
+// @Description Add a note to an order on behalf of the current user. Internal notes are visible to administrators only and can be added by administrators only; other notes are also visible to the order owner.
+// @Description If is_internal is omitted, notes of administrators are internal and notes of other users are visible to the order owner
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param note body OrderNoteRequest true "Note"
+// @Success 201 {object} models.OrderNote "Note added successfully"
+// @Failure 400 {object} ErrorResponse "Invalid order ID or note"
+// @Failure 403 {object} ErrorResponse "Only administrators can add internal notes"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles User, Admin
+// @Router /orders/{id}/notes [post]
+func (h *OrderHandler) AddOrderNote(rw http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request OrderNoteRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	// Как и в базе данных, заметка администратора по умолчанию внутренняя, чтобы ее не увидел клиент
+	internal := actor.IsAdmin()
+	if request.IsInternal != nil {
+		internal = *request.IsInternal
+	}
+
+	note, err := h.service.AddOrderNote(orderID, request.Text, internal, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	details := fmt.Sprintf("Note %d added to order %d", note.ID, orderID)
+	err = h.logService.CreateLog("add_order_note", details, actor.UserID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(note)
+}
+
+// GetOrderNotes godoc
+// @Summary Get order notes
+// @Description Get the notes of an order in chronological order. Users see only non-internal notes of their own orders
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.OrderNote "Notes in chronological order"
+// @Failure 400 {object} ErrorResponse "Invalid order ID"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles User, Admin
+// @Router /orders/{id}/notes [get]
+func (h *OrderHandler) GetOrderNotes(rw http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	notes, err := h.service.GetOrderNotes(orderID, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(notes)
+}
+
 // DeleteOrder godoc
 // @Summary Delete an order
 // @Description Delete an order by providing order ID
@@ -160,6 +668,8 @@ func (h *OrderHandler) UpdateOrder(rw http.ResponseWriter, r *http.Request) {
 // @Param id path int true "Order ID"
 // @Success 204 "Order deleted successfully"
 // @Failure 400 {object} ErrorResponse "Invalid order ID"
+// @Failure 401 {object} ErrorResponse "User ID not found"
+// @Failure 404 {object} ErrorResponse "Order not found or already deleted"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Roles Admin
@@ -177,44 +687,134 @@ func (h *OrderHandler) DeleteOrder(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
 	err = h.service.DeleteOrder(orderID)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	action := "delete_order"
+	details := "Order deleted successfully"
+
+	err = h.logService.CreateLog(action, details, actor.UserID)
 	if err != nil {
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	cookie, err := r.Cookie("user_id")
-	if err != nil || cookie == nil {
-		http.Error(rw, "User ID cookie not found", http.StatusUnauthorized)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreOrder godoc
+// @Summary Restore a deleted order
+// @Description Restore a soft-deleted order. Stock is reserved again for orders that have not been shipped
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.Order "Order restored successfully"
+// @Failure 400 {object} ErrorResponse "Invalid order ID"
+// @Failure 404 {object} ErrorResponse "Deleted order not found"
+// @Failure 409 {object} ErrorResponse "Insufficient stock to restore the order"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /orders/{id}/restore [post]
+func (h *OrderHandler) RestoreOrder(rw http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
 		return
 	}
 
-	userID, err := strconv.Atoi(cookie.Value)
+	err = h.service.RestoreOrder(orderID)
 	if err != nil {
-		http.Error(rw, "Invalid User ID", http.StatusUnauthorized)
+		http.Error(rw, err.Error(), orderErrorStatus(err))
 		return
 	}
-	action := "delete_order"
-	details := "Order deleted successfully"
 
-	err = h.logService.CreateLog(action, details, userID)
+	details := fmt.Sprintf("Order %d restored", orderID)
+	err = h.logService.CreateLog("restore_order", details, actor.UserID)
 	if err != nil {
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	rw.WriteHeader(http.StatusNoContent)
+	order, err := h.service.GetOrderByID(orderID, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(order)
+}
+
+// PurgeOrdersResponse результат окончательной очистки удаленных заказов
+type PurgeOrdersResponse struct {
+	Purged int `json:"purged"`
+}
+
+// PurgeDeletedOrders godoc
+// @Summary Purge deleted orders
+// @Description Permanently remove orders that were soft-deleted longer than the configured retention period ago
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Success 200 {object} PurgeOrdersResponse "Number of purged orders"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /orders/purge [post]
+func (h *OrderHandler) PurgeDeletedOrders(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	purged, err := h.service.PurgeDeletedOrders()
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	details := fmt.Sprintf("Purged %d deleted orders", purged)
+	err = h.logService.CreateLog("purge_orders", details, userID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(PurgeOrdersResponse{Purged: purged})
 }
 
 // GetOrderByID godoc
 // @Summary Get an order by ID
-// @Description Get a specific order by providing the order ID
+// @Description Get a specific order by providing the order ID. Users can only access orders they created
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param id path int true "Order ID"
 // @Success 200 {object} models.Order "Order details"
+// @Header 200 {string} ETag "Order version to pass in If-Match when updating the order"
 // @Failure 400 {object} ErrorResponse "Invalid order ID"
+// @Failure 404 {object} ErrorResponse "Order not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Roles User, Admin
@@ -232,61 +832,316 @@ func (h *OrderHandler) GetOrderByID(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.service.GetOrderByID(orderID)
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.service.GetOrderByID(orderID, actor)
 	if err != nil {
-		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		http.Error(rw, err.Error(), orderErrorStatus(err))
 		return
 	}
 
+	rw.Header().Set("ETag", orderETag(order))
 	rw.WriteHeader(http.StatusOK)
 	json.NewEncoder(rw).Encode(order)
 }
 
 // GetOrdersByFilters godoc
 // @Summary Get orders by filters
-// @Description Get all orders or filter them by status, min price, and max price
+// @Description Get a page of orders matching the given filters. Users only see orders they created. Pages are navigated with the next_cursor value from the previous response
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param status query string false "Order status"
-// @Param min_price query float64 false "Minimum order price"
-// @Param max_price query float64 false "Maximum order price"
-// @Success 200 {array} models.Order "List of orders"
+// @Param status query string false "Comma-separated order statuses, e.g. pending,confirmed"
+// @Param min_price query float64 false "Minimum order price in the base currency"
+// @Param max_price query float64 false "Maximum order price in the base currency"
+// @Param created_from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)"
+// @Param updated_since query string false "Updated at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param product_id query int false "Orders containing the product"
+// @Param customer_id query int false "Orders of the customer"
+// @Param customer_name query string false "Case-insensitive part of the customer name"
+// @Param sort query string false "Sort field: created_at, total_price or status; prefix with - for descending order" default(-created_at)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param deleted query bool false "List soft-deleted orders instead of active ones (Admin only)"
+// @Success 200 {object} models.OrderPage "Page of orders"
 // @Failure 400 {object} ErrorResponse "Invalid filter parameters"
+// @Failure 403 {object} ErrorResponse "Deleted orders are available to admins only"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Router /orders [get]
 func (h *OrderHandler) GetOrdersByFilters(rw http.ResponseWriter, r *http.Request) {
-	status := r.URL.Query().Get("status")
-	minPriceStr := r.URL.Query().Get("min_price")
-	maxPriceStr := r.URL.Query().Get("max_price")
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	var minPrice, maxPrice float64
-	var err error
+	actor, ok := actorFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
 
-	if minPriceStr != "" {
-		minPrice, err = strconv.ParseFloat(minPriceStr, 64)
+	if filter.Deleted && !actor.IsAdmin() {
+		http.Error(rw, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	page, err := h.service.GetOrdersByFilters(filter, actor)
+	if err != nil {
+		http.Error(rw, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	if filter.Deleted {
+		details := fmt.Sprintf("Listed %d deleted orders", len(page.Orders))
+		err = h.logService.CreateLog("list_deleted_orders", details, actor.UserID)
 		if err != nil {
-			http.Error(rw, "Invalid min_price parameter", http.StatusBadRequest)
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
 
-	if maxPriceStr != "" {
-		maxPrice, err = strconv.ParseFloat(maxPriceStr, 64)
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(page)
+}
+
+// orderETag возвращает ETag заказа, построенный по его версии
+func orderETag(order *models.Order) string {
+	return strconv.Quote(strconv.Itoa(order.Version))
+}
+
+// parseOrderETag разбирает значение заголовка If-Match. Для "*" возвращается 0:
+// заказ обновляется без проверки версии.
+func parseOrderETag(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid order version %q", unquoted)
+	}
+	return version, nil
+}
+
+// ExportOrders godoc
+// @Summary Export orders
+// @Description Download all orders matching the filters as a CSV or XLSX file. Accepts the same filters as GET /orders, limit and cursor are ignored
+// @Tags orders
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format: csv or xlsx" default(csv)
+// @Param status query string false "Comma-separated order statuses, e.g. pending,confirmed"
+// @Param min_price query float64 false "Minimum order price in the base currency"
+// @Param max_price query float64 false "Maximum order price in the base currency"
+// @Param created_from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created at or before (RFC 3339 or YYYY-MM-DD, the whole day is included)"
+// @Param updated_since query string false "Updated at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param product_id query int false "Orders containing the product"
+// @Param customer_id query int false "Orders of the customer"
+// @Param customer_name query string false "Case-insensitive part of the customer name"
+// @Param deleted query bool false "Export soft-deleted orders instead of active ones"
+// @Param sort query string false "Sort field: created_at, total_price or status; prefix with - for descending order" default(-created_at)
+// @Success 200 {file} file "Orders file"
+// @Failure 400 {object} ErrorResponse "Invalid format or filter parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /orders/export [get]
+func (h *OrderHandler) ExportOrders(rw http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if !export.IsSupportedFormat(format) {
+		http.Error(rw, "Unsupported export format", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(rw, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.logService.CreateLog("export_orders", fmt.Sprintf("Orders exported as %s", format), userID)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Заголовки отправляются вместе с первой строкой файла, до этого момента ошибку еще можно вернуть клиенту
+	var writer export.Writer
+	started := false
+	start := func() error {
+		rw.Header().Set("Content-Type", export.ContentType(format))
+		rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="orders-%s.%s"`, time.Now().Format("20060102"), format))
+
+		w, err := export.NewWriter(format, rw)
 		if err != nil {
-			http.Error(rw, "Invalid max_price parameter", http.StatusBadRequest)
-			return
+			return err
 		}
+		writer = w
+		started = true
+		return writer.WriteRow(
+			"id", "customer_id", "customer_name", "status", "region", "currency", "subtotal", "discount_amount", "tax_amount", "total_price",
+			"exchange_rate", "base_total_price", "items", "created_at", "updated_at",
+		)
 	}
 
-	orders, err := h.service.GetOrdersByFilters(status, minPrice, maxPrice)
+	err = h.service.ExportOrders(filter, func(order *models.Order) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return writer.WriteRow(
+			order.ID, order.CustomerID, order.CustomerName, order.Status, order.Region, order.Currency,
+			order.Subtotal, order.DiscountAmount, order.TaxAmount, order.TotalPrice,
+			order.ExchangeRate, order.BaseTotalPrice, formatOrderItems(order.Items), order.CreatedAt, order.UpdatedAt,
+		)
+	})
+	if err == nil && !started {
+		err = start()
+	}
 	if err != nil {
-		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		if !started {
+			http.Error(rw, err.Error(), orderErrorStatus(err))
+			return
+		}
+		// Часть файла уже отправлена, статус ответа изменить нельзя
+		log.Printf("order export failed: %v", err)
 		return
 	}
 
-	rw.Header().Set("Content-Type", "application/json")
-	rw.WriteHeader(http.StatusOK)
-	json.NewEncoder(rw).Encode(orders)
+	err = writer.Close()
+	if err != nil {
+		log.Printf("order export failed: %v", err)
+	}
+}
+
+// formatOrderItems описывает позиции заказа одной строкой: "product_id x quantity @ unit_price; ..."
+func formatOrderItems(items []models.OrderItem) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = fmt.Sprintf("%d x %d @ %s", item.ProductID, item.Quantity, item.UnitPrice)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseOrderFilter разбирает параметры запроса списка заказов
+func parseOrderFilter(query url.Values) (models.OrderFilter, error) {
+	filter := models.OrderFilter{
+		CustomerName: strings.TrimSpace(query.Get("customer_name")),
+		Sort:         query.Get("sort"),
+		Cursor:       query.Get("cursor"),
+	}
+
+	var err error
+
+	if statusStr := query.Get("status"); statusStr != "" {
+		for _, status := range strings.Split(statusStr, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+	}
+
+	if minPriceStr := query.Get("min_price"); minPriceStr != "" {
+		filter.MinPrice, err = models.ParseMoney(minPriceStr)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid min_price parameter", models.ErrInvalidFilter)
+		}
+	}
+
+	if maxPriceStr := query.Get("max_price"); maxPriceStr != "" {
+		filter.MaxPrice, err = models.ParseMoney(maxPriceStr)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid max_price parameter", models.ErrInvalidFilter)
+		}
+	}
+
+	if filter.CreatedFrom, err = parseFilterTime(query.Get("created_from"), false); err != nil {
+		return filter, fmt.Errorf("%w: invalid created_from parameter", models.ErrInvalidFilter)
+	}
+
+	if filter.CreatedTo, err = parseFilterTime(query.Get("created_to"), true); err != nil {
+		return filter, fmt.Errorf("%w: invalid created_to parameter", models.ErrInvalidFilter)
+	}
+
+	if filter.UpdatedSince, err = parseFilterTime(query.Get("updated_since"), false); err != nil {
+		return filter, fmt.Errorf("%w: invalid updated_since parameter", models.ErrInvalidFilter)
+	}
+
+	if productIDStr := query.Get("product_id"); productIDStr != "" {
+		filter.ProductID, err = strconv.Atoi(productIDStr)
+		if err != nil || filter.ProductID <= 0 {
+			return filter, fmt.Errorf("%w: invalid product_id parameter", models.ErrInvalidFilter)
+		}
+	}
+
+	if customerIDStr := query.Get("customer_id"); customerIDStr != "" {
+		filter.CustomerID, err = strconv.Atoi(customerIDStr)
+		if err != nil || filter.CustomerID <= 0 {
+			return filter, fmt.Errorf("%w: invalid customer_id parameter", models.ErrInvalidFilter)
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		filter.Limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid limit parameter", models.ErrInvalidFilter)
+		}
+	}
+
+	if deletedStr := query.Get("deleted"); deletedStr != "" {
+		filter.Deleted, err = strconv.ParseBool(deletedStr)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid deleted parameter", models.ErrInvalidFilter)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseFilterTime разбирает время в формате RFC 3339 или дату YYYY-MM-DD.
+// Для верхней границы периода дата без времени включает весь день.
+func parseFilterTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+	return t, nil
 }
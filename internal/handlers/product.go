@@ -3,12 +3,18 @@ package handlers
 import (
 	"TestTask/internal/models"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 )
 
+// maxProductImportSize максимальный размер файла импорта каталога
+const maxProductImportSize = 32 << 20
+
 type ProductHandler struct {
 	service ProductServiceInterface
 }
@@ -51,7 +57,7 @@ func (h *ProductHandler) CreateProduct(rw http.ResponseWriter, r *http.Request)
 
 // UpdateProduct godoc
 // @Summary Update an existing product
-// @Description Update an existing product by providing product data
+// @Description Update an existing product by providing product data. If the currency is omitted, the current currency of the product is kept
 // @Tags products
 // @Accept json
 // @Produce json
@@ -91,6 +97,105 @@ func (h *ProductHandler) UpdateProduct(rw http.ResponseWriter, r *http.Request)
 	rw.Write([]byte("Product successfully updated"))
 }
 
+// ImportProducts godoc
+// @Summary Import products from CSV
+// @Description Create and update products from a CSV file with the header name,price,quantity and optional id, currency and category columns.
+// @Description New products without a currency get the base currency, existing products keep their currency if it is not given.
+// @Description The category determines the tax rate. Without the category column existing products keep their category and new products get none.
+// @Description Rows with an id update the existing product, rows without it create a new one. The file can be sent as the request body or as the "file" field of a multipart form
+// @Tags products
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file false "CSV file"
+// @Success 200 {object} models.ProductImportReport "Per-row import report"
+// @Failure 400 {object} ErrorResponse "Invalid CSV header or file"
+// @Failure 413 {object} ErrorResponse "CSV file is too large"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /products/import [post]
+func (h *ProductHandler) ImportProducts(rw http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(rw, r.Body, maxProductImportSize)
+
+	var file io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		formFile, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(rw, "CSV file is required in the \"file\" field", http.StatusBadRequest)
+			return
+		}
+		defer formFile.Close()
+		file = formFile
+	}
+
+	report, err := h.service.ImportProducts(file)
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		http.Error(rw, "CSV file is too large", http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, models.ErrInvalidProductData):
+		http.Error(rw, fmt.Sprintf("Product import failed: %v", err), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(report)
+}
+
+// PatchProduct godoc
+// @Summary Partially update a product
+// @Description Apply a JSON Merge Patch (RFC 7396) document to a product. Omitted fields keep their stored values
+// @Tags products
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param patch body models.Product true "Merge patch document"
+// @Success 200 {object} models.Product "Product successfully updated"
+// @Failure 400 {object} ErrorResponse "Invalid product ID or data"
+// @Failure 404 {object} ErrorResponse "Product not found"
+// @Failure 415 {object} ErrorResponse "Unsupported patch format"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /products/{id} [patch]
+func (h *ProductHandler) PatchProduct(rw http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, ok := readMergePatch(rw, r)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.PatchProduct(productID, patch)
+	switch {
+	case errors.Is(err, models.ErrProductNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, models.ErrInvalidProductData):
+		http.Error(rw, fmt.Sprintf("Product update failed: %v", err), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(product)
+}
+
 // DeleteProduct godoc
 // @Summary Delete a product
 // @Description Delete a product by providing product ID
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"TestTask/internal/models"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+type WebhookHandler struct {
+	service WebhookServiceInterface
+}
+
+func NewWebhookHandler(service WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// WebhookRequest структура запроса на создание или замену подписки на события заказов
+type WebhookRequest struct {
+	URL        string   `json:"url" example:"https://partner.example.com/hooks/orders"`
+	Secret     string   `json:"secret,omitempty" example:"3f1c9a7e5b2d4f6a8c0e1b3d5f7a9c2e"` // если не задан, генерируется (при замене - сохраняется прежний)
+	EventTypes []string `json:"event_types" example:"order_status_changed,order_cancelled"`
+	IsActive   *bool    `json:"is_active,omitempty" example:"true"` // по умолчанию true
+}
+
+// WebhookCreatedResponse созданная подписка вместе с ключом подписи. Ключ возвращается только в этом ответе.
+type WebhookCreatedResponse struct {
+	models.Webhook
+	Secret string `json:"secret" example:"3f1c9a7e5b2d4f6a8c0e1b3d5f7a9c2e"`
+}
+
+func (req WebhookRequest) toWebhook() models.Webhook {
+	webhook := models.Webhook{URL: req.URL, Secret: req.Secret, EventTypes: req.EventTypes, IsActive: true}
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+	return webhook
+}
+
+// webhookErrorStatus сопоставляет ошибки сервиса вебхуков с HTTP статусами
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidWebhookData):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrWebhookNotFound), errors.Is(err, models.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook
+// @Description Subscribe a URL to order events (order_status_changed, order_cancelled). Events are sent as POST requests with the X-Webhook-Event, X-Webhook-Delivery and X-Webhook-Signature headers. The signature is "sha256=" followed by the hex HMAC-SHA256 of the request body keyed with the webhook secret. The secret is returned only in this response. Failed deliveries are retried with exponential backoff. Deliveries interrupted by a restart are resent automatically once they have been pending for the configured timeout
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookRequest true "Webhook data"
+// @Success 201 {object} WebhookCreatedResponse "Webhook created successfully, the secret is returned only in this response"
+// @Failure 400 {object} ErrorResponse "Invalid webhook data"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(rw http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	webhook := req.toWebhook()
+	err = h.service.CreateWebhook(&webhook)
+	if err != nil {
+		http.Error(rw, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(WebhookCreatedResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Replace the URL, event types and state of a webhook. The secret is kept if it is not given
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body WebhookRequest true "Updated webhook data"
+// @Success 200 {object} models.Webhook "Webhook updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid webhook ID or data"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(rw http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	var req WebhookRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(rw, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	webhook := req.toWebhook()
+	webhook.ID = webhookID
+	err = h.service.UpdateWebhook(&webhook)
+	if err != nil {
+		http.Error(rw, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook together with its delivery history
+// @Tags webhooks
+// @Param id path int true "Webhook ID"
+// @Success 204 "Webhook deleted successfully"
+// @Failure 400 {object} ErrorResponse "Invalid webhook ID"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(rw http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.DeleteWebhook(webhookID)
+	if err != nil {
+		http.Error(rw, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookByID godoc
+// @Summary Get a webhook by ID
+// @Description Get a webhook subscription
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook "Webhook details"
+// @Failure 400 {object} ErrorResponse "Invalid webhook ID"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhookByID(rw http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.service.GetWebhookByID(webhookID)
+	if err != nil {
+		http.Error(rw, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(webhook)
+}
+
+// GetAllWebhooks godoc
+// @Summary Get all webhooks
+// @Description Get all webhook subscriptions
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook "List of webhooks"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetAllWebhooks(rw http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.GetAllWebhooks()
+	if err != nil {
+		http.Error(rw, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(webhooks)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Get webhook deliveries
+// @Description Get the deliveries of a webhook, newest first, with the number of attempts and the result of the last one
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {array} models.WebhookDelivery "List of deliveries"
+// @Failure 400 {object} ErrorResponse "Invalid webhook ID"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(rw http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.service.GetWebhookDeliveries(webhookID)
+	if err != nil {
+		http.Error(rw, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(deliveries)
+}
+
+// ReplayWebhookDelivery godoc
+// @Summary Replay a webhook delivery
+// @Description Send the payload of a delivery to its webhook again, even if the webhook is inactive. The replay is recorded as a new delivery and sent in the background
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery "Replay scheduled"
+// @Failure 400 {object} ErrorResponse "Invalid delivery ID"
+// @Failure 404 {object} ErrorResponse "Delivery or webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Roles Admin
+// @Router /webhooks/deliveries/{id}/replay [post]
+func (h *WebhookHandler) ReplayWebhookDelivery(rw http.ResponseWriter, r *http.Request) {
+	deliveryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.service.ReplayDelivery(deliveryID)
+	if err != nil {
+		http.Error(rw, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(rw).Encode(delivery)
+}
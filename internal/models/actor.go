@@ -0,0 +1,22 @@
+package models
+
+// Роли пользователей
+const (
+	RoleUser  = "User"
+	RoleAdmin = "Admin"
+)
+
+// Actor пользователь, от имени которого выполняется операция
+type Actor struct {
+	UserID int
+	Role   string
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == RoleAdmin
+}
+
+// CanAccessOrder проверяет доступ к заказу: Admin видит все заказы, остальные - только созданные ими
+func (a Actor) CanAccessOrder(order *Order) bool {
+	return a.IsAdmin() || (order != nil && order.UserID == a.UserID)
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Интервалы группировки выручки
+const (
+	AnalyticsIntervalDay   = "day"
+	AnalyticsIntervalWeek  = "week"
+	AnalyticsIntervalMonth = "month"
+)
+
+// AnalyticsFilter параметры отчетов по продажам. Нулевые границы периода означают отсутствие ограничения.
+type AnalyticsFilter struct {
+	From     time.Time
+	To       time.Time
+	Interval string // day, week или month, только для отчета о выручке
+	Limit    int    // количество продуктов в отчете о самых продаваемых продуктах
+}
+
+// RevenuePoint выручка за один интервал. Суммы указаны в базовой валюте.
+type RevenuePoint struct {
+	PeriodStart       time.Time `json:"period_start"`
+	Orders            int       `json:"orders" example:"12"`
+	Revenue           Money     `json:"revenue" swaggertype:"number" example:"15420.5"`
+	AverageOrderValue Money     `json:"average_order_value" swaggertype:"number" example:"1285.04"`
+}
+
+// RevenueReport выручка по интервалам. Отмененные и удаленные заказы не учитываются.
+type RevenueReport struct {
+	Interval string         `json:"interval" example:"day"`
+	Currency string         `json:"currency" example:"RUB"`
+	Points   []RevenuePoint `json:"points"`
+}
+
+// OrderStatusStats количество и сумма заказов в одном статусе. Сумма указана в базовой валюте.
+type OrderStatusStats struct {
+	Status  string `json:"status" example:"confirmed"`
+	Orders  int    `json:"orders" example:"42"`
+	Revenue Money  `json:"revenue" swaggertype:"number" example:"53210"`
+}
+
+// ProductSales продажи продукта. Выручка указана в базовой валюте: это доля итоговых сумм заказов
+// со скидками и налогом, пропорциональная стоимости позиций продукта.
+type ProductSales struct {
+	ProductID int    `json:"product_id" example:"1"`
+	Name      string `json:"name" example:"Laptop"`
+	Quantity  int    `json:"quantity" example:"17"`
+	Revenue   Money  `json:"revenue" swaggertype:"number" example:"849983"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Типы скидки купона
+const (
+	DiscountTypePercentage = "percentage"
+	DiscountTypeFixed      = "fixed"
+)
+
+// Coupon промокод на скидку
+type Coupon struct {
+	ID             int        `json:"id" example:"1" readonly:"true"`
+	Code           string     `json:"code" example:"WELCOME10"`
+	DiscountType   string     `json:"discount_type" example:"percentage" enums:"percentage,fixed"`
+	DiscountValue  Money      `json:"discount_value" swaggertype:"number" example:"10"`  // процент для percentage, сумма в базовой валюте для fixed
+	MinOrderValue  Money      `json:"min_order_value" swaggertype:"number" example:"50"` // в базовой валюте
+	ValidFrom      *time.Time `json:"valid_from,omitempty"`
+	ValidTo        *time.Time `json:"valid_to,omitempty"`
+	MaxUses        int        `json:"max_uses" example:"100"`        // 0 - без ограничений
+	MaxUsesPerUser int        `json:"max_uses_per_user" example:"1"` // 0 - без ограничений
+	UsedCount      int        `json:"used_count" example:"0" readonly:"true"`
+	Disabled       bool       `json:"disabled" example:"false"`
+	CreatedAt      time.Time  `json:"created_at" readonly:"true"`
+	UpdatedAt      time.Time  `json:"updated_at" readonly:"true"`
+}
+
+// CheckApplicable проверяет, что купон можно применить к заказу на сумму subtotal в базовой валюте в момент now.
+// userRedemptions - сколько раз купон уже применял пользователь, оформляющий заказ.
+func (c *Coupon) CheckApplicable(now time.Time, subtotal Money, userRedemptions int) error {
+	switch {
+	case c.Disabled:
+		return fmt.Errorf("%w: coupon %s is disabled", ErrCouponNotApplicable, c.Code)
+	case c.ValidFrom != nil && now.Before(*c.ValidFrom):
+		return fmt.Errorf("%w: coupon %s is not active yet", ErrCouponNotApplicable, c.Code)
+	case c.ValidTo != nil && now.After(*c.ValidTo):
+		return fmt.Errorf("%w: coupon %s has expired", ErrCouponNotApplicable, c.Code)
+	case c.MaxUses > 0 && c.UsedCount >= c.MaxUses:
+		return fmt.Errorf("%w: coupon %s has been used up", ErrCouponNotApplicable, c.Code)
+	case c.MaxUsesPerUser > 0 && userRedemptions >= c.MaxUsesPerUser:
+		return fmt.Errorf("%w: coupon %s has already been used the maximum number of times", ErrCouponNotApplicable, c.Code)
+	case subtotal < c.MinOrderValue:
+		return fmt.Errorf("%w: order total must be at least %s to use coupon %s", ErrCouponNotApplicable, c.MinOrderValue, c.Code)
+	}
+	return nil
+}
+
+// Discount рассчитывает скидку в валюте заказа для заказа на сумму subtotal. exchangeRate - курс валюты заказа
+// к базовой валюте, по нему пересчитывается фиксированная скидка. Скидка не превышает сумму заказа.
+func (c *Coupon) Discount(subtotal Money, exchangeRate float64) Money {
+	var discount Money
+	switch c.DiscountType {
+	case DiscountTypePercentage:
+		// DiscountValue хранит процент с двумя знаками после запятой: 10% = 10.00
+		discount = subtotal.MulDiv(c.DiscountValue, 100*MoneyScale)
+	case DiscountTypeFixed:
+		discount = c.DiscountValue
+		if exchangeRate > 0 {
+			discount = discount.MulRat(new(big.Rat).Inv(DecimalRate(exchangeRate)))
+		}
+	}
+
+	if discount > subtotal {
+		return subtotal
+	}
+	return discount
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Поддерживаемые валюты, коды ISO 4217
+const (
+	CurrencyRUB = "RUB"
+	CurrencyEUR = "EUR"
+	CurrencyUSD = "USD"
+)
+
+// IsSupportedCurrency проверяет, что код валюты входит в список поддерживаемых
+func IsSupportedCurrency(currency string) bool {
+	switch currency {
+	case CurrencyRUB, CurrencyEUR, CurrencyUSD:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExchangeRate курс обмена: одна единица FromCurrency стоит Rate единиц ToCurrency
+type ExchangeRate struct {
+	FromCurrency string    `json:"from_currency" example:"EUR"`
+	ToCurrency   string    `json:"to_currency" example:"RUB"`
+	Rate         float64   `json:"rate" example:"98.5"`
+	UpdatedAt    time.Time `json:"updated_at" readonly:"true"`
+}
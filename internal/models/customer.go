@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Customer клиент, на которого оформляются заказы
+type Customer struct {
+	ID        int       `json:"id" example:"1" readonly:"true"`
+	Name      string    `json:"name" example:"John Doe"`
+	Email     string    `json:"email,omitempty" example:"john.doe@example.com"`
+	Phone     string    `json:"phone,omitempty" example:"+7 900 123-45-67"`
+	Addresses []Address `json:"addresses"`
+	CreatedAt time.Time `json:"created_at" readonly:"true"`
+	UpdatedAt time.Time `json:"updated_at" readonly:"true"`
+}
+
+// Address адрес доставки клиента
+type Address struct {
+	Line1      string `json:"line1" example:"Tverskaya st. 1"`
+	Line2      string `json:"line2,omitempty" example:"apt. 12"`
+	City       string `json:"city" example:"Moscow"`
+	PostalCode string `json:"postal_code,omitempty" example:"125009"`
+	Country    string `json:"country" example:"RU"`
+}
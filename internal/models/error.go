@@ -1,5 +1,34 @@
 package models
 
+import "errors"
+
+var (
+	ErrInvalidOrderData        = errors.New("invalid order data")
+	ErrOrderNotFound           = errors.New("order not found")
+	ErrInvalidStatusTransition = errors.New("invalid order status transition")
+	ErrOutOfStock              = errors.New("product is out of stock")
+	ErrInvalidFilter           = errors.New("invalid filter parameters")
+	ErrVersionConflict         = errors.New("order has been modified by another request")
+	ErrOrderNoteForbidden      = errors.New("only administrators can add internal order notes")
+	ErrInvalidProductData      = errors.New("invalid product data")
+	ErrProductNotFound         = errors.New("product not found")
+	ErrInvalidIdempotencyKey   = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused    = errors.New("idempotency key has already been used with a different request")
+	ErrIdempotencyKeyInUse     = errors.New("request with this idempotency key is still being processed")
+	ErrInvalidCouponData       = errors.New("invalid coupon data")
+	ErrCouponNotFound          = errors.New("coupon not found")
+	ErrCouponCodeTaken         = errors.New("coupon with this code already exists")
+	ErrCouponNotApplicable     = errors.New("coupon cannot be applied to the order")
+	ErrInvalidExchangeRate     = errors.New("invalid exchange rate data")
+	ErrExchangeRateNotFound    = errors.New("exchange rate not found")
+	ErrInvalidCustomerData     = errors.New("invalid customer data")
+	ErrCustomerNotFound        = errors.New("customer not found")
+	ErrCustomerEmailTaken      = errors.New("customer with this email already exists")
+	ErrInvalidWebhookData      = errors.New("invalid webhook data")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
 // ErrorResponse структура для ошибки
 type ErrorResponse struct {
 	Message string `json:"message"`
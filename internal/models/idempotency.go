@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyRecord сохраненный результат запроса с заголовком Idempotency-Key
+type IdempotencyRecord struct {
+	UserID          int
+	Key             string
+	RequestHash     string
+	ResponseStatus  int               // 0, пока исходный запрос еще обрабатывается
+	ResponseHeaders map[string]string // заголовки ответа, которые отдаются повторно вместе с телом
+	ResponseBody    []byte
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MoneyScale количество минимальных единиц в одной денежной единице
+const MoneyScale = 100
+
+// RateScale количество знаков после запятой в курсах валют, как в колонке DECIMAL(18, 8)
+const RateScale = 8
+
+// Money денежная сумма в минимальных единицах валюты (центах). Колонки DECIMAL(10, 2) читаются и пишутся
+// без преобразования во float64, поэтому суммы не накапливают ошибку округления.
+// В JSON сумма записывается числом с двумя знаками после запятой, а читается как из числа, так и из строки.
+type Money int64
+
+// decimalPattern десятичная запись числа, как в JSON: без дробей вида "1/3" и шестнадцатеричных префиксов,
+// которые дополнительно принимает big.Rat
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+// ParseMoney разбирает десятичную запись суммы, например "25.45", "-3" или "1e2".
+// Знаки после второго округляются до цента, половина - от нуля.
+func ParseMoney(s string) (Money, error) {
+	trimmed := strings.TrimSpace(s)
+	if !decimalPattern.MatchString(trimmed) {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+
+	value, ok := new(big.Rat).SetString(trimmed)
+	if !ok {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+
+	return roundRat(value.Mul(value, big.NewRat(MoneyScale, 1)))
+}
+
+// MoneyFromFloat переводит сумму из float64 с округлением до цента
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * MoneyScale))
+}
+
+// Mul возвращает стоимость quantity единиц по цене m
+func (m Money) Mul(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// MulRate умножает сумму на ставку (налога, курса) с округлением до цента
+func (m Money) MulRate(rate float64) Money {
+	return m.MulRat(DecimalRate(rate))
+}
+
+// MulRat умножает сумму на рациональное число с округлением до цента
+func (m Money) MulRat(rate *big.Rat) Money {
+	return MoneyFromRat(new(big.Rat).Mul(new(big.Rat).SetInt64(int64(m)), rate))
+}
+
+// MoneyFromRat округляет до цента сумму, заданную точной дробью в центах
+func MoneyFromRat(cents *big.Rat) Money {
+	result, _ := roundRat(cents)
+	return result
+}
+
+// DecimalRate переводит ставку или курс из float64 в десятичную дробь, которая хранится в базе данных:
+// 0.07 становится ровно 7/100, а не ближайшей к нему двоичной дробью. Расчеты со ставками ведутся
+// в big.Rat, и результат округляется один раз.
+func DecimalRate(rate float64) *big.Rat {
+	value, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat)
+	}
+	return value
+}
+
+// RoundRate округляет курс до RateScale знаков после запятой, с которыми он хранится в заказе
+func RoundRate(rate *big.Rat) float64 {
+	value, _ := strconv.ParseFloat(rate.FloatString(RateScale), 64)
+	return value
+}
+
+// MulDiv возвращает m * num / den с округлением до цента без переполнения промежуточного результата
+func (m Money) MulDiv(num, den Money) Money {
+	if den == 0 {
+		return 0
+	}
+
+	value := new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(num))),
+		big.NewInt(int64(den)),
+	)
+	result, _ := roundRat(value)
+	return result
+}
+
+// Float64 возвращает сумму в денежных единицах; используется только для отображения
+func (m Money) Float64() float64 {
+	return float64(m) / MoneyScale
+}
+
+// String возвращает сумму с двумя знаками после запятой, например "25.40"
+func (m Money) String() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/MoneyScale, value%MoneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON принимает сумму числом (25.45) или строкой ("25.45"), как принимал API до перехода на Money
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if raw == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(raw, `"`) {
+		unquoted, err := strconv.Unquote(raw)
+		if err != nil {
+			return fmt.Errorf("invalid money amount %s", raw)
+		}
+		raw = unquoted
+	}
+
+	value, err := ParseMoney(raw)
+	if err != nil {
+		return err
+	}
+	*m = value
+	return nil
+}
+
+// Scan читает DECIMAL из базы данных. lib/pq возвращает DECIMAL в виде текста.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		value, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = value
+	case string:
+		value, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = value
+	case int64:
+		*m = Money(v * MoneyScale)
+	case float64:
+		*m = MoneyFromFloat(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	return nil
+}
+
+// Value записывает сумму в базу данных десятичной строкой, чтобы PostgreSQL не получал float
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// roundRat округляет рациональное число до целого, половина - от нуля
+func roundRat(value *big.Rat) (Money, error) {
+	num := new(big.Int).Abs(value.Num())
+	den := value.Denom()
+
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+
+	if !quotient.IsInt64() {
+		return 0, fmt.Errorf("money amount %s is out of range", value.FloatString(2))
+	}
+	return Money(quotient.Int64()), nil
+}
@@ -1,17 +1,148 @@
 package models
 
-import "time"
+import (
+	"time"
+)
+
+// Статусы заказа
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+)
+
+// Коды причин отмены заказа
+const (
+	CancellationReasonCustomerRequest = "customer_request"
+	CancellationReasonPaymentFailed   = "payment_failed"
+	CancellationReasonOutOfStock      = "out_of_stock"
+	CancellationReasonDuplicate       = "duplicate"
+	CancellationReasonFraudSuspected  = "fraud_suspected"
+	CancellationReasonOther           = "other"
+	// CancellationReasonExpired устанавливается системой при автоматической отмене неоплаченного заказа
+	CancellationReasonExpired = "expired"
+)
 
 // Order модель для заказа
 // @Description Order struct
-// @example {"customer_name": "John Doe", "status": "pending", "total_price": 100.5, "product_id": 1}
+// @example {"customer_id": 1, "items": [{"product_id": 1, "quantity": 2}]}
 type Order struct {
-	ID           int       `swaggerignore:"true" ,json:"id"`
-	CustomerName string    `json:"customer_name" example:"John Doe"`
-	Status       string    `json:"status" example:"pending"`
-	TotalPrice   float64   `json:"total_price" example:"100.5"`
-	ProductID    int       `json:"product_id" example:"1"`
-	CreatedAt    time.Time `swaggerignore:"true" ,json:"created_at"`
-	UpdatedAt    time.Time `swaggerignore:"true" ,json:"updated_at"`
-	IsDeleted    bool      `swaggerignore:"true" ,json:"is_deleted"`
+	ID             int                `swaggerignore:"true" ,json:"id"`
+	CustomerID     int                `json:"customer_id,omitempty" example:"1"` // клиент заказа; если не указан, клиент находится или создается по customer_name
+	CustomerName   string             `json:"customer_name" example:"John Doe"`
+	Status         string             `json:"status" example:"pending"`
+	Currency       string             `json:"currency" example:"EUR"`                                                  // валюта заказа, по умолчанию базовая валюта
+	Region         string             `json:"region,omitempty" example:"DE"`                                           // регион доставки, определяет налоговые ставки
+	Subtotal       Money              `json:"subtotal" swaggertype:"number" example:"90.45" readonly:"true"`           // стоимость позиций без налога и скидки
+	TaxAmount      Money              `json:"tax_amount" swaggertype:"number" example:"17.19" readonly:"true"`         // налог с учетом скидки
+	TotalPrice     Money              `json:"total_price" swaggertype:"number" example:"100.5" readonly:"true"`        // итоговая сумма: subtotal - discount_amount + tax_amount
+	ExchangeRate   float64            `json:"exchange_rate" example:"98.5" readonly:"true"`                            // курс валюты заказа к базовой валюте на момент создания
+	BaseTotalPrice Money              `json:"base_total_price" swaggertype:"number" example:"9899.25" readonly:"true"` // итоговая сумма в базовой валюте для отчетов
+	Items          []OrderItem        `json:"items"`
+	CreatedAt      time.Time          `swaggerignore:"true" ,json:"created_at"`
+	UpdatedAt      time.Time          `swaggerignore:"true" ,json:"updated_at"`
+	IsDeleted      bool               `swaggerignore:"true" ,json:"is_deleted"`
+	Version        int                `json:"version" example:"1" readonly:"true"`                                  // увеличивается при каждом изменении заказа
+	UserID         int                `json:"user_id" example:"1" readonly:"true"`                                  // пользователь, создавший заказ
+	CouponCode     string             `json:"coupon_code,omitempty" example:"WELCOME10"`                            // промокод, применяемый при создании заказа
+	DiscountAmount Money              `json:"discount_amount" swaggertype:"number" example:"10.05" readonly:"true"` // скидка по промокоду, уже вычтенная из total_price
+	Cancellation   *OrderCancellation `json:"cancellation,omitempty" readonly:"true"`                               // заполняется для заказов, отмененных через POST /orders/{id}/cancel
+}
+
+// ApplyDiscount вычитает скидку из подытога заказа и пересчитывает налог и итоговую сумму.
+// Скидка распределяется по позициям пропорционально их стоимости, поэтому налог уменьшается в той же пропорции.
+func (o *Order) ApplyDiscount(discount Money) {
+	o.TaxAmount = o.TaxAmount.MulDiv(o.Subtotal-discount, o.Subtotal)
+	o.DiscountAmount = discount
+	o.SetTotal(o.Subtotal - discount + o.TaxAmount)
+}
+
+// SetTotal записывает итоговую сумму заказа и ее значение в базовой валюте по курсу ExchangeRate
+func (o *Order) SetTotal(total Money) {
+	o.TotalPrice = total
+	o.BaseTotalPrice = total.MulRate(o.ExchangeRate)
+}
+
+// OrderCancellation причина отмены заказа
+type OrderCancellation struct {
+	OrderID     int       `json:"order_id" example:"1"`
+	ReasonCode  string    `json:"reason_code" example:"customer_request"`
+	Note        string    `json:"note,omitempty" example:"Customer changed their mind"`
+	CancelledBy int       `json:"cancelled_by" example:"1"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
+
+// OrderItem represents a single line of an order
+type OrderItem struct {
+	ID        int   `json:"id" swaggerignore:"true"`
+	OrderID   int   `json:"order_id" swaggerignore:"true"`
+	ProductID int   `json:"product_id" example:"1"`
+	Quantity  int   `json:"quantity" example:"2"`
+	UnitPrice Money `json:"unit_price" swaggertype:"number" example:"50.25" readonly:"true"` // цена продукта на момент создания заказа
+}
+
+// OrderStatusChange запись истории изменения статуса заказа
+type OrderStatusChange struct {
+	ID        int       `json:"id"`
+	OrderID   int       `json:"order_id"`
+	OldStatus string    `json:"old_status" example:"pending"`
+	NewStatus string    `json:"new_status" example:"confirmed"`
+	ChangedBy int       `json:"changed_by" example:"1"`
+	Reason    string    `json:"reason,omitempty" example:"Payment received"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderNote заметка к заказу. Внутренние заметки видны только администраторам,
+// остальные - также владельцу заказа.
+type OrderNote struct {
+	ID         int       `json:"id"`
+	OrderID    int       `json:"order_id"`
+	AuthorID   int       `json:"author_id" example:"1"`
+	Text       string    `json:"text" example:"Customer asked to deliver after 6 pm"`
+	IsInternal bool      `json:"is_internal" example:"true"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OrderFilter параметры выборки списка заказов. Нулевые значения означают отсутствие фильтра.
+type OrderFilter struct {
+	Statuses     []string
+	MinPrice     Money // границы суммы заказа в базовой валюте
+	MaxPrice     Money
+	CreatedFrom  time.Time
+	CreatedTo    time.Time
+	UpdatedSince time.Time
+	ProductID    int
+	CustomerID   int
+	CustomerName string // поиск по части имени без учета регистра
+	UserID       int    // только заказы указанного пользователя
+	Deleted      bool   // выбрать "мягко" удаленные заказы вместо активных
+	Sort         string // created_at, total_price (в базовой валюте) или status; префикс "-" задает порядок по убыванию
+	Limit        int
+	Cursor       string
+}
+
+// OrderPage страница списка заказов
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor,omitempty" example:"eyJzIjoiLWNyZWF0ZWRfYXQiLCJ2IjoiMjAyNS0wMS0xMFQxMTozNjowM1oiLCJpZCI6NDJ9"`
+	TotalCount int     `json:"total_count" example:"120"`
+}
+
+// OrderStatusUpdate элемент пакетной смены статусов заказов
+type OrderStatusUpdate struct {
+	OrderID int    `json:"order_id" example:"1"`
+	Status  string `json:"status" example:"shipped"`
+	Reason  string `json:"reason,omitempty" example:"Handed to courier"`
+}
+
+// BulkOrderResult результат обработки одного элемента пакетного запроса
+type BulkOrderResult struct {
+	Index   int    `json:"index" example:"0"` // позиция элемента в запросе
+	OrderID int    `json:"order_id,omitempty" example:"1"`
+	Order   *Order `json:"order,omitempty"`
+	Code    int    `json:"code" example:"201"` // HTTP статус, который вернул бы одиночный запрос
+	Error   string `json:"error,omitempty"`
+	Err     error  `json:"-"`
 }
@@ -2,8 +2,36 @@ package models
 
 // Product represents a single product within an order
 type Product struct {
-	ID       int     `swaggerignore:"true" ,json:"id"`
-	Name     string  `json:"name"`
-	Price    float64 `json:"price"`
-	Quantity int     `json:"quantity"`
+	ID       int    `swaggerignore:"true" ,json:"id"`
+	Name     string `json:"name"`
+	Price    Money  `json:"price" swaggertype:"number"`
+	Quantity int    `json:"quantity"`
+	Category string `json:"category,omitempty"`     // определяет налоговую ставку
+	Currency string `json:"currency" example:"RUB"` // валюта цены, по умолчанию базовая валюта
+}
+
+// Статусы строк импорта каталога продуктов
+const (
+	ProductImportCreated = "created"
+	ProductImportUpdated = "updated"
+	ProductImportFailed  = "failed"
+)
+
+// ProductImportRow результат импорта одной строки CSV-файла
+type ProductImportRow struct {
+	Row       int     `json:"row" example:"2"` // номер строки в файле, заголовок - строка 1
+	ProductID int     `json:"product_id,omitempty" example:"1"`
+	Status    string  `json:"status" example:"created"`
+	Error     string  `json:"error,omitempty"`
+	Product   Product `json:"-"`
+	// HasCategory в файле есть колонка category; без нее категория существующего продукта не меняется
+	HasCategory bool `json:"-"`
+}
+
+// ProductImportReport отчет об импорте каталога продуктов
+type ProductImportReport struct {
+	Rows    []ProductImportRow `json:"rows"`
+	Created int                `json:"created" example:"10"`
+	Updated int                `json:"updated" example:"2"`
+	Failed  int                `json:"failed" example:"1"`
 }
@@ -0,0 +1,11 @@
+package models
+
+// TaxRule налоговая ставка для региона и категории продуктов. Пустые Region и Category
+// означают, что правило подходит для любого региона или категории.
+type TaxRule struct {
+	ID        int     `json:"id" example:"1"`
+	Region    string  `json:"region" example:"DE"`
+	Category  string  `json:"category" example:"books"`
+	Rate      float64 `json:"rate" example:"0.07"`
+	Inclusive bool    `json:"inclusive" example:"true"` // цены каталога уже включают налог
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы доставки события на вебхук
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook подписка партнера на события заказов
+type Webhook struct {
+	ID         int       `json:"id" example:"1" readonly:"true"`
+	URL        string    `json:"url" example:"https://partner.example.com/hooks/orders"`
+	Secret     string    `json:"-"` // ключ подписи X-Webhook-Signature, возвращается только при создании подписки
+	EventTypes []string  `json:"event_types" example:"order_status_changed,order_cancelled"`
+	IsActive   bool      `json:"is_active" example:"true"`
+	CreatedAt  time.Time `json:"created_at" readonly:"true"`
+	UpdatedAt  time.Time `json:"updated_at" readonly:"true"`
+}
+
+// WebhookDelivery отправка события на вебхук со всеми попытками
+type WebhookDelivery struct {
+	ID             int             `json:"id" example:"1"`
+	WebhookID      int             `json:"webhook_id" example:"1"`
+	EventType      string          `json:"event_type" example:"order_status_changed"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"` // тело запроса байт в байт, как оно было подписано
+	Status         string          `json:"status" example:"succeeded"`
+	Attempts       int             `json:"attempts" example:"1"`
+	ResponseStatus int             `json:"response_status,omitempty" example:"200"` // HTTP статус ответа на последнюю попытку
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// GetRevenue возвращает количество и сумму заказов в базовой валюте по интервалам filter.Interval.
+// Интервалы без заказов не возвращаются.
+func (r *AnalyticsRepository) GetRevenue(filter models.AnalyticsFilter) ([]models.RevenuePoint, error) {
+	whereClauses, args := analyticsFilterClauses(filter, "orders", []interface{}{filter.Interval})
+	whereClauses = append(whereClauses, fmt.Sprintf("orders.status <> '%s'", models.OrderStatusCancelled))
+
+	query := fmt.Sprintf(`
+		SELECT date_trunc($1, created_at) AS period, COUNT(*), COALESCE(SUM(base_total_price), 0)
+		FROM orders
+		WHERE %s
+		GROUP BY period
+		ORDER BY period
+	`, strings.Join(whereClauses, " AND "))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get revenue: %v", err)
+	}
+	defer rows.Close()
+
+	points := []models.RevenuePoint{}
+	for rows.Next() {
+		var point models.RevenuePoint
+		if err := rows.Scan(&point.PeriodStart, &point.Orders, &point.Revenue); err != nil {
+			return nil, fmt.Errorf("could not scan revenue: %v", err)
+		}
+		if point.Orders > 0 {
+			point.AverageOrderValue = point.Revenue.MulDiv(1, models.Money(point.Orders))
+		}
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
+
+// GetOrdersByStatus возвращает количество и сумму заказов в базовой валюте по статусам
+func (r *AnalyticsRepository) GetOrdersByStatus(filter models.AnalyticsFilter) ([]models.OrderStatusStats, error) {
+	whereClauses, args := analyticsFilterClauses(filter, "orders", nil)
+
+	query := fmt.Sprintf(`
+		SELECT status, COUNT(*), COALESCE(SUM(base_total_price), 0)
+		FROM orders
+		WHERE %s
+		GROUP BY status
+		ORDER BY status
+	`, strings.Join(whereClauses, " AND "))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get orders by status: %v", err)
+	}
+	defer rows.Close()
+
+	stats := []models.OrderStatusStats{}
+	for rows.Next() {
+		var stat models.OrderStatusStats
+		if err := rows.Scan(&stat.Status, &stat.Orders, &stat.Revenue); err != nil {
+			return nil, fmt.Errorf("could not scan orders by status: %v", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetTopProducts возвращает filter.Limit продуктов с наибольшей выручкой в базовой валюте.
+// Итоговая сумма заказа в базовой валюте (со скидкой и налогом) распределяется между позициями пропорционально
+// их стоимости, поэтому выручка продуктов сходится с остальными отчетами. Отмененные и удаленные заказы не учитываются.
+func (r *AnalyticsRepository) GetTopProducts(filter models.AnalyticsFilter) ([]models.ProductSales, error) {
+	whereClauses, args := analyticsFilterClauses(filter, "o", nil)
+	whereClauses = append(whereClauses, fmt.Sprintf("o.status <> '%s'", models.OrderStatusCancelled))
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(`
+		SELECT lines.product_id, COALESCE(p.name, ''), SUM(lines.quantity),
+		       COALESCE(ROUND(SUM(lines.revenue), 2), 0) AS revenue
+		FROM (
+			SELECT oi.product_id, oi.quantity,
+			       o.base_total_price * oi.quantity * oi.unit_price
+			           / NULLIF(SUM(oi.quantity * oi.unit_price) OVER (PARTITION BY o.id), 0) AS revenue
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE %s
+		) lines
+		LEFT JOIN products p ON p.id = lines.product_id
+		GROUP BY lines.product_id, p.name
+		ORDER BY revenue DESC, lines.product_id
+		LIMIT $%d
+	`, strings.Join(whereClauses, " AND "), len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get top products: %v", err)
+	}
+	defer rows.Close()
+
+	products := []models.ProductSales{}
+	for rows.Next() {
+		var product models.ProductSales
+		if err := rows.Scan(&product.ProductID, &product.Name, &product.Quantity, &product.Revenue); err != nil {
+			return nil, fmt.Errorf("could not scan top products: %v", err)
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// analyticsFilterClauses строит условия WHERE по периоду создания заказов таблицы с псевдонимом table.
+// args - аргументы, уже используемые запросом перед условиями.
+func analyticsFilterClauses(filter models.AnalyticsFilter, table string, args []interface{}) ([]string, []interface{}) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	whereClauses := []string{table + ".is_deleted = false"}
+
+	if !filter.From.IsZero() {
+		whereClauses = append(whereClauses, fmt.Sprintf("%s.created_at >= $%d", table, len(args)+1))
+		args = append(args, filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		whereClauses = append(whereClauses, fmt.Sprintf("%s.created_at <= $%d", table, len(args)+1))
+		args = append(args, filter.To)
+	}
+
+	return whereClauses, args
+}
@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+// couponColumns колонки купона в порядке, в котором их читает scanCoupon
+const couponColumns = "id, code, discount_type, discount_value, min_order_value, valid_from, valid_to, max_uses, max_uses_per_user, used_count, disabled, created_at, updated_at"
+
+// uniqueViolation код ошибки PostgreSQL при нарушении ограничения уникальности
+const uniqueViolation = "23505"
+
+type CouponRepository struct {
+	db *sql.DB
+}
+
+func NewCouponRepository(db *sql.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+func (r *CouponRepository) CreateCoupon(coupon *models.Coupon) error {
+	query := `
+		INSERT INTO coupons (code, discount_type, discount_value, min_order_value, valid_from, valid_to, max_uses, max_uses_per_user, disabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, used_count, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query,
+		coupon.Code, coupon.DiscountType, coupon.DiscountValue, coupon.MinOrderValue,
+		nullTime(coupon.ValidFrom), nullTime(coupon.ValidTo), coupon.MaxUses, coupon.MaxUsesPerUser, coupon.Disabled,
+	).Scan(&coupon.ID, &coupon.UsedCount, &coupon.CreatedAt, &coupon.UpdatedAt)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s", models.ErrCouponCodeTaken, coupon.Code)
+	} else if err != nil {
+		return fmt.Errorf("could not create coupon: %v", err)
+	}
+	return nil
+}
+
+// UpdateCoupon обновляет условия купона. Счетчик использований не меняется.
+func (r *CouponRepository) UpdateCoupon(coupon *models.Coupon) error {
+	query := `
+		UPDATE coupons
+		SET code = $1, discount_type = $2, discount_value = $3, min_order_value = $4, valid_from = $5, valid_to = $6,
+		    max_uses = $7, max_uses_per_user = $8, disabled = $9, updated_at = NOW()
+		WHERE id = $10
+		RETURNING used_count, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query,
+		coupon.Code, coupon.DiscountType, coupon.DiscountValue, coupon.MinOrderValue,
+		nullTime(coupon.ValidFrom), nullTime(coupon.ValidTo), coupon.MaxUses, coupon.MaxUsesPerUser, coupon.Disabled, coupon.ID,
+	).Scan(&coupon.UsedCount, &coupon.CreatedAt, &coupon.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w with id: %d", models.ErrCouponNotFound, coupon.ID)
+	} else if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s", models.ErrCouponCodeTaken, coupon.Code)
+	} else if err != nil {
+		return fmt.Errorf("could not update coupon: %v", err)
+	}
+	return nil
+}
+
+func (r *CouponRepository) DeleteCoupon(couponID int) error {
+	result, err := r.db.Exec("DELETE FROM coupons WHERE id = $1", couponID)
+	if err != nil {
+		return fmt.Errorf("could not delete coupon: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not delete coupon: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with id: %d", models.ErrCouponNotFound, couponID)
+	}
+	return nil
+}
+
+func (r *CouponRepository) GetCouponByID(couponID int) (*models.Coupon, error) {
+	query := fmt.Sprintf("SELECT %s FROM coupons WHERE id = $1", couponColumns)
+
+	var coupon models.Coupon
+	err := scanCoupon(r.db.QueryRow(query, couponID), &coupon)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w with id: %d", models.ErrCouponNotFound, couponID)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get coupon by id: %v", err)
+	}
+	return &coupon, nil
+}
+
+func (r *CouponRepository) GetCouponByCode(code string) (*models.Coupon, error) {
+	query := fmt.Sprintf("SELECT %s FROM coupons WHERE code = $1", couponColumns)
+
+	var coupon models.Coupon
+	err := scanCoupon(r.db.QueryRow(query, code), &coupon)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w with code: %s", models.ErrCouponNotFound, code)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get coupon by code: %v", err)
+	}
+	return &coupon, nil
+}
+
+func (r *CouponRepository) GetAllCoupons() ([]models.Coupon, error) {
+	query := fmt.Sprintf("SELECT %s FROM coupons ORDER BY id", couponColumns)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("could not get coupons: %v", err)
+	}
+	defer rows.Close()
+
+	coupons := []models.Coupon{}
+	for rows.Next() {
+		var coupon models.Coupon
+		if err := scanCoupon(rows, &coupon); err != nil {
+			return nil, fmt.Errorf("could not scan coupon: %v", err)
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	return coupons, rows.Err()
+}
+
+// redeemCoupon блокирует купон заказа до конца транзакции, проверяет условия его применения
+// и увеличивает счетчик использований. Скидка уже рассчитана сервисом и записана в order.DiscountAmount.
+// Возвращает ID купона для записи в историю применений.
+func redeemCoupon(tx *sql.Tx, order *models.Order) (int, error) {
+	query := fmt.Sprintf("SELECT %s FROM coupons WHERE code = $1 FOR UPDATE", couponColumns)
+
+	var coupon models.Coupon
+	err := scanCoupon(tx.QueryRow(query, order.CouponCode), &coupon)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: unknown coupon %s", models.ErrCouponNotApplicable, order.CouponCode)
+	} else if err != nil {
+		return 0, fmt.Errorf("could not lock coupon: %v", err)
+	}
+
+	var userRedemptions int
+	if coupon.MaxUsesPerUser > 0 && order.UserID > 0 {
+		err = tx.QueryRow(
+			"SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1 AND user_id = $2",
+			coupon.ID, order.UserID,
+		).Scan(&userRedemptions)
+		if err != nil {
+			return 0, fmt.Errorf("could not count coupon redemptions: %v", err)
+		}
+	}
+
+	// Условия купона заданы в базовой валюте
+	err = coupon.CheckApplicable(time.Now(), order.Subtotal.MulRate(order.ExchangeRate), userRedemptions)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("UPDATE coupons SET used_count = used_count + 1 WHERE id = $1", coupon.ID)
+	if err != nil {
+		return 0, fmt.Errorf("could not update coupon usage: %v", err)
+	}
+	return coupon.ID, nil
+}
+
+// insertCouponRedemption записывает применение купона к созданному заказу
+func insertCouponRedemption(tx *sql.Tx, couponID int, order *models.Order) error {
+	query := `
+		INSERT INTO coupon_redemptions (coupon_id, order_id, user_id, discount_amount)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	userID := sql.NullInt64{Int64: int64(order.UserID), Valid: order.UserID > 0}
+	_, err := tx.Exec(query, couponID, order.ID, userID, order.DiscountAmount)
+	if err != nil {
+		return fmt.Errorf("could not record coupon redemption: %v", err)
+	}
+	return nil
+}
+
+// scanCoupon читает колонки couponColumns в coupon
+func scanCoupon(row rowScanner, coupon *models.Coupon) error {
+	var validFrom, validTo sql.NullTime
+	err := row.Scan(
+		&coupon.ID, &coupon.Code, &coupon.DiscountType, &coupon.DiscountValue, &coupon.MinOrderValue,
+		&validFrom, &validTo, &coupon.MaxUses, &coupon.MaxUsesPerUser, &coupon.UsedCount, &coupon.Disabled,
+		&coupon.CreatedAt, &coupon.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if validFrom.Valid {
+		coupon.ValidFrom = &validFrom.Time
+	}
+	if validTo.Valid {
+		coupon.ValidTo = &validTo.Time
+	}
+	return nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// customerColumns колонки клиента в порядке, в котором их читает scanCustomer
+const customerColumns = "id, name, email, phone, addresses, created_at, updated_at"
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+func (r *CustomerRepository) CreateCustomer(customer *models.Customer) error {
+	addresses, err := json.Marshal(customer.Addresses)
+	if err != nil {
+		return fmt.Errorf("could not encode customer addresses: %v", err)
+	}
+
+	query := `
+		INSERT INTO customers (name, email, phone, addresses)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	email := sql.NullString{String: customer.Email, Valid: customer.Email != ""}
+	phone := sql.NullString{String: customer.Phone, Valid: customer.Phone != ""}
+	err = r.db.QueryRow(query, customer.Name, email, phone, addresses).
+		Scan(&customer.ID, &customer.CreatedAt, &customer.UpdatedAt)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s", models.ErrCustomerEmailTaken, customer.Email)
+	} else if err != nil {
+		return fmt.Errorf("could not create customer: %v", err)
+	}
+	return nil
+}
+
+func (r *CustomerRepository) UpdateCustomer(customer *models.Customer) error {
+	addresses, err := json.Marshal(customer.Addresses)
+	if err != nil {
+		return fmt.Errorf("could not encode customer addresses: %v", err)
+	}
+
+	query := `
+		UPDATE customers
+		SET name = $1, email = $2, phone = $3, addresses = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING created_at, updated_at
+	`
+
+	email := sql.NullString{String: customer.Email, Valid: customer.Email != ""}
+	phone := sql.NullString{String: customer.Phone, Valid: customer.Phone != ""}
+	err = r.db.QueryRow(query, customer.Name, email, phone, addresses, customer.ID).
+		Scan(&customer.CreatedAt, &customer.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w with id: %d", models.ErrCustomerNotFound, customer.ID)
+	} else if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s", models.ErrCustomerEmailTaken, customer.Email)
+	} else if err != nil {
+		return fmt.Errorf("could not update customer: %v", err)
+	}
+	return nil
+}
+
+// DeleteCustomer удаляет клиента. Его заказы сохраняются без ссылки на клиента, с именем на момент оформления.
+func (r *CustomerRepository) DeleteCustomer(customerID int) error {
+	result, err := r.db.Exec("DELETE FROM customers WHERE id = $1", customerID)
+	if err != nil {
+		return fmt.Errorf("could not delete customer: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not delete customer: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with id: %d", models.ErrCustomerNotFound, customerID)
+	}
+	return nil
+}
+
+func (r *CustomerRepository) GetCustomerByID(customerID int) (*models.Customer, error) {
+	query := fmt.Sprintf("SELECT %s FROM customers WHERE id = $1", customerColumns)
+
+	var customer models.Customer
+	err := scanCustomer(r.db.QueryRow(query, customerID), &customer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w with id: %d", models.ErrCustomerNotFound, customerID)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get customer by id: %v", err)
+	}
+	return &customer, nil
+}
+
+func (r *CustomerRepository) GetAllCustomers() ([]models.Customer, error) {
+	query := fmt.Sprintf("SELECT %s FROM customers ORDER BY id", customerColumns)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("could not get customers: %v", err)
+	}
+	defer rows.Close()
+
+	customers := []models.Customer{}
+	for rows.Next() {
+		var customer models.Customer
+		if err := scanCustomer(rows, &customer); err != nil {
+			return nil, fmt.Errorf("could not scan customer: %v", err)
+		}
+		customers = append(customers, customer)
+	}
+
+	return customers, rows.Err()
+}
+
+// FindOrCreateCustomerByName возвращает клиента с именем name без учета регистра, а если такого нет, создает его.
+// Поиск и создание выполняются под advisory lock по имени, чтобы параллельные заказы не создали двух одинаковых клиентов.
+func (r *CustomerRepository) FindOrCreateCustomerByName(name string) (*models.Customer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`SELECT pg_advisory_xact_lock(hashtext(LOWER($1)))`, name)
+	if err != nil {
+		return nil, fmt.Errorf("could not lock customer name: %v", err)
+	}
+
+	var customer models.Customer
+	query := fmt.Sprintf("SELECT %s FROM customers WHERE LOWER(name) = LOWER($1) ORDER BY id LIMIT 1", customerColumns)
+	err = scanCustomer(tx.QueryRow(query, name), &customer)
+	if errors.Is(err, sql.ErrNoRows) {
+		query = fmt.Sprintf("INSERT INTO customers (name) VALUES ($1) RETURNING %s", customerColumns)
+		err = scanCustomer(tx.QueryRow(query, name), &customer)
+		if err != nil {
+			return nil, fmt.Errorf("could not create customer: %v", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get customer by name: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %v", err)
+	}
+	return &customer, nil
+}
+
+// scanCustomer читает колонки customerColumns в customer
+func scanCustomer(row rowScanner, customer *models.Customer) error {
+	var (
+		email, phone sql.NullString
+		addresses    []byte
+	)
+	err := row.Scan(&customer.ID, &customer.Name, &email, &phone, &addresses, &customer.CreatedAt, &customer.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	customer.Email = email.String
+	customer.Phone = phone.String
+	customer.Addresses = []models.Address{}
+	if len(addresses) > 0 {
+		if err := json.Unmarshal(addresses, &customer.Addresses); err != nil {
+			return fmt.Errorf("invalid customer addresses: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type ExchangeRateRepository struct {
+	db *sql.DB
+}
+
+func NewExchangeRateRepository(db *sql.DB) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db: db}
+}
+
+// SaveExchangeRates создает или обновляет курсы одной транзакцией: файл курсов загружается целиком или не загружается вовсе
+func (r *ExchangeRateRepository) SaveExchangeRates(rates []models.ExchangeRate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO exchange_rates (from_currency, to_currency, rate, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (from_currency, to_currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+
+	for i := range rates {
+		rate := &rates[i]
+		err = tx.QueryRow(query, rate.FromCurrency, rate.ToCurrency, rate.Rate).Scan(&rate.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("could not save exchange rate %s/%s: %v", rate.FromCurrency, rate.ToCurrency, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit exchange rates: %v", err)
+	}
+	return nil
+}
+
+// AssignBaseCurrency записывает базовую валюту в продукты и заказы, у которых валюта не заполнена:
+// это продукты и заказы, созданные до появления валют. Суммы таких заказов уже указаны в базовой валюте.
+// Возвращает количество обновленных продуктов и заказов.
+func (r *ExchangeRateRepository) AssignBaseCurrency(baseCurrency string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	products, err := tx.Exec(`UPDATE products SET currency = $1 WHERE currency IS NULL`, baseCurrency)
+	if err != nil {
+		return 0, fmt.Errorf("could not assign base currency to products: %v", err)
+	}
+	orders, err := tx.Exec(`
+		UPDATE orders
+		SET currency = $1, exchange_rate = 1, base_total_price = total_price
+		WHERE currency IS NULL
+	`, baseCurrency)
+	if err != nil {
+		return 0, fmt.Errorf("could not assign base currency to orders: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not commit base currency: %v", err)
+	}
+
+	productsUpdated, err := products.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not check rows affected: %v", err)
+	}
+	ordersUpdated, err := orders.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not check rows affected: %v", err)
+	}
+	return int(productsUpdated + ordersUpdated), nil
+}
+
+func (r *ExchangeRateRepository) DeleteExchangeRate(fromCurrency, toCurrency string) error {
+	result, err := r.db.Exec("DELETE FROM exchange_rates WHERE from_currency = $1 AND to_currency = $2", fromCurrency, toCurrency)
+	if err != nil {
+		return fmt.Errorf("could not delete exchange rate: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not check rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s/%s", models.ErrExchangeRateNotFound, fromCurrency, toCurrency)
+	}
+	return nil
+}
+
+func (r *ExchangeRateRepository) GetExchangeRate(fromCurrency, toCurrency string) (*models.ExchangeRate, error) {
+	query := `
+		SELECT from_currency, to_currency, rate, updated_at
+		FROM exchange_rates
+		WHERE from_currency = $1 AND to_currency = $2
+	`
+
+	var rate models.ExchangeRate
+	err := r.db.QueryRow(query, fromCurrency, toCurrency).Scan(&rate.FromCurrency, &rate.ToCurrency, &rate.Rate, &rate.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s/%s", models.ErrExchangeRateNotFound, fromCurrency, toCurrency)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get exchange rate: %v", err)
+	}
+	return &rate, nil
+}
+
+func (r *ExchangeRateRepository) GetAllExchangeRates() ([]models.ExchangeRate, error) {
+	query := `
+		SELECT from_currency, to_currency, rate, updated_at
+		FROM exchange_rates
+		ORDER BY from_currency, to_currency
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("could not get exchange rates: %v", err)
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var rate models.ExchangeRate
+		if err := rows.Scan(&rate.FromCurrency, &rate.ToCurrency, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan exchange rate: %v", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
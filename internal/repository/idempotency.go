@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// ReserveIdempotencyKey закрепляет ключ за новым запросом. Просроченный ключ перезаписывается.
+// Если ключ уже занят действующей записью, она возвращается вызывающему.
+func (r *IdempotencyRepository) ReserveIdempotencyKey(record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    response_status = NULL,
+		    response_headers = NULL,
+		    response_body = NULL,
+		    created_at = EXCLUDED.created_at,
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		RETURNING user_id
+	`
+
+	var userID int
+	err := r.db.QueryRow(query, record.UserID, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt).Scan(&userID)
+	if err == nil {
+		return nil, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("could not reserve idempotency key: %v", err)
+	}
+
+	return r.getIdempotencyKey(record.UserID, record.Key)
+}
+
+// SaveIdempotencyResponse сохраняет ответ на запрос, для которого был зарезервирован ключ
+func (r *IdempotencyRepository) SaveIdempotencyResponse(userID int, key string, status int, headers map[string]string, body []byte) error {
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("could not encode idempotent response headers: %v", err)
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET response_status = $1, response_headers = $2, response_body = $3
+		WHERE user_id = $4 AND idempotency_key = $5
+	`
+
+	_, err = r.db.Exec(query, status, encodedHeaders, body, userID, key)
+	if err != nil {
+		return fmt.Errorf("could not save idempotent response: %v", err)
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey освобождает ключ, чтобы запрос можно было повторить
+func (r *IdempotencyRepository) DeleteIdempotencyKey(userID int, key string) error {
+	_, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`, userID, key)
+	if err != nil {
+		return fmt.Errorf("could not delete idempotency key: %v", err)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys удаляет ключи, срок действия которых истек к моменту now.
+// Возвращает количество удаленных ключей.
+func (r *IdempotencyRepository) DeleteExpiredIdempotencyKeys(now time.Time) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("could not delete expired idempotency keys: %v", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not check rows affected: %v", err)
+	}
+	return int(deleted), nil
+}
+
+func (r *IdempotencyRepository) getIdempotencyKey(userID int, key string) (*models.IdempotencyRecord, error) {
+	query := `
+		SELECT request_hash, response_status, response_headers, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	record := models.IdempotencyRecord{UserID: userID, Key: key}
+	var (
+		status  sql.NullInt64
+		headers []byte
+	)
+	err := r.db.QueryRow(query, userID, key).Scan(&record.RequestHash, &status, &headers, &record.ResponseBody, &record.CreatedAt, &record.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Запись удалили между попыткой резервирования и чтением: исходный запрос завершился ошибкой
+		return nil, fmt.Errorf("%w: key %q was released concurrently", models.ErrIdempotencyKeyInUse, key)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get idempotency key: %v", err)
+	}
+	record.ResponseStatus = int(status.Int64)
+	// Пока запрос обрабатывается, заголовки ответа не заполнены
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &record.ResponseHeaders); err != nil {
+			return nil, fmt.Errorf("invalid idempotent response headers: %v", err)
+		}
+	}
+
+	return &record, nil
+}
@@ -3,10 +3,23 @@ package repository
 import (
 	"TestTask/internal/models"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/lib/pq"
+	"sort"
 	"strings"
+	"time"
 )
 
+// autoCancelLockKey ключ advisory lock, под которым отменяются просроченные заказы.
+// Пока одна реплика держит блокировку, остальные пропускают очередной запуск.
+const autoCancelLockKey int64 = 20250130
+
+// orderColumns колонки заказа в порядке, в котором их читает scanOrder
+const orderColumns = "id, customer_id, customer_name, status, total_price, created_at, updated_at, is_deleted, version, user_id, coupon_code, discount_amount, region, subtotal, tax_amount, currency, exchange_rate, base_total_price"
+
 type OrderRepository struct {
 	db *sql.DB
 }
@@ -16,129 +29,993 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 }
 
 func (r *OrderRepository) CreateOrder(order *models.Order) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = reserveStock(tx, order.Items)
+	if err != nil {
+		return err
+	}
+
+	var couponID int
+	if order.CouponCode != "" {
+		couponID, err = redeemCoupon(tx, order)
+		if err != nil {
+			return err
+		}
+	}
+
 	query := `
-		INSERT INTO orders (customer_name, status, total_price, product_id)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO orders (customer_name, status, total_price, user_id, coupon_code, discount_amount, region, subtotal, tax_amount,
+		                    currency, exchange_rate, base_total_price, customer_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at, version
 	`
 
-	_, err := r.db.Exec(query, order.CustomerName, order.Status, order.TotalPrice, order.ProductID)
+	userID := sql.NullInt64{Int64: int64(order.UserID), Valid: order.UserID > 0}
+	couponCode := sql.NullString{String: order.CouponCode, Valid: order.CouponCode != ""}
+	customerID := sql.NullInt64{Int64: int64(order.CustomerID), Valid: order.CustomerID > 0}
+	err = tx.QueryRow(query, order.CustomerName, order.Status, order.TotalPrice, userID, couponCode, order.DiscountAmount,
+		order.Region, order.Subtotal, order.TaxAmount, order.Currency, order.ExchangeRate, order.BaseTotalPrice, customerID).
+		Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt, &order.Version)
 	if err != nil {
 		return fmt.Errorf("could not create order: %v", err)
 	}
+
+	if couponID > 0 {
+		err = insertCouponRedemption(tx, couponID, order)
+		if err != nil {
+			return err
+		}
+	}
+
+	itemQuery := `
+		INSERT INTO order_items (order_id, product_id, quantity, unit_price)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.OrderID = order.ID
+
+		err = tx.QueryRow(itemQuery, item.OrderID, item.ProductID, item.Quantity, item.UnitPrice).Scan(&item.ID)
+		if err != nil {
+			return fmt.Errorf("could not create order item: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit order: %v", err)
+	}
 	return nil
 }
 
-func (r *OrderRepository) UpdateOrder(order *models.Order) error {
+// UpdateOrder обновляет заказ, если его версия совпадает с order.Version, и записывает в order новую версию.
+// oldStatus - статус, для которого проверен переход в order.Status; если после блокировки строки статус заказа
+// другой (его изменил параллельный запрос, например при If-Match: *), возвращается ErrInvalidStatusTransition.
+// Если передан change, в той же транзакции записывается история смены статуса.
+func (r *OrderRepository) UpdateOrder(order *models.Order, oldStatus string, change *models.OrderStatusChange) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	currentStatus, err := lockOrderStatus(tx, order.ID)
+	if err != nil {
+		return err
+	}
+	if currentStatus != oldStatus {
+		return fmt.Errorf("%w: order %d is no longer in status %s", models.ErrInvalidStatusTransition, order.ID, oldStatus)
+	}
+
+	// Нулевая версия означает, что клиент не проверяет версию заказа (If-Match: *)
 	query := `
 	UPDATE orders
-        SET customer_name = $1, status = $2, total_price = $3, updated_at = $4
-        WHERE id = $5 AND is_deleted = false
+        SET customer_name = $1, status = $2, total_price = $3, updated_at = $4, version = version + 1, customer_id = $7
+        WHERE id = $5 AND is_deleted = false AND ($6 = 0 OR version = $6)
+        RETURNING version
 	`
 
-	result, err := r.db.Exec(query, order.CustomerName, order.Status, order.TotalPrice, order.UpdatedAt, order.ID)
-	if err != nil {
+	customerID := sql.NullInt64{Int64: int64(order.CustomerID), Valid: order.CustomerID > 0}
+	err = tx.QueryRow(query, order.CustomerName, order.Status, order.TotalPrice, order.UpdatedAt, order.ID, order.Version, customerID).
+		Scan(&order.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: order %d is no longer at version %d", models.ErrVersionConflict, order.ID, order.Version)
+	} else if err != nil {
 		return fmt.Errorf("could not update order: %v", err)
 	}
 
-	affectedRows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("could not get affected rows: %v", err)
+	if currentStatus != models.OrderStatusCancelled && order.Status == models.OrderStatusCancelled {
+		err = releaseStock(tx, order.ID)
+		if err != nil {
+			return err
+		}
 	}
 
-	if affectedRows == 0 {
-		return fmt.Errorf("no order found with the given id")
+	if change != nil {
+		err = insertStatusChange(tx, change)
+		if err != nil {
+			return err
+		}
 	}
 
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit order update: %v", err)
+	}
 	return nil
 }
 
+// UpdateOrderStatus меняет статус заказа, только если он все еще находится в статусе change.OldStatus,
+// и записывает изменение в историю. При отмене заказа зарезервированный товар возвращается на склад.
+// Возвращает новую версию заказа.
+func (r *OrderRepository) UpdateOrderStatus(change *models.OrderStatusChange) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	version, err := updateOrderStatus(tx, change)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not commit order status: %v", err)
+	}
+	return version, nil
+}
+
+// CancelOrder отменяет заказ так же, как UpdateOrderStatus, и сохраняет причину отмены.
+// Возвращает новую версию заказа.
+func (r *OrderRepository) CancelOrder(change *models.OrderStatusChange, cancellation *models.OrderCancellation) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	version, err := updateOrderStatus(tx, change)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO order_cancellations (order_id, reason_code, note, cancelled_by, cancelled_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (order_id) DO UPDATE
+		SET reason_code = EXCLUDED.reason_code, note = EXCLUDED.note,
+		    cancelled_by = EXCLUDED.cancelled_by, cancelled_at = EXCLUDED.cancelled_at
+	`
+
+	note := sql.NullString{String: cancellation.Note, Valid: cancellation.Note != ""}
+	cancelledBy := sql.NullInt64{Int64: int64(cancellation.CancelledBy), Valid: cancellation.CancelledBy > 0}
+
+	_, err = tx.Exec(query, cancellation.OrderID, cancellation.ReasonCode, note, cancelledBy, cancellation.CancelledAt)
+	if err != nil {
+		return 0, fmt.Errorf("could not record order cancellation: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not commit order cancellation: %v", err)
+	}
+	return version, nil
+}
+
+// updateOrderStatus меняет статус заказа внутри транзакции, возвращает товар на склад при отмене
+// и записывает изменение в историю
+func updateOrderStatus(tx *sql.Tx, change *models.OrderStatusChange) (int, error) {
+	query := `
+		UPDATE orders
+		SET status = $1, updated_at = $2, version = version + 1
+		WHERE id = $3 AND status = $4 AND is_deleted = false
+		RETURNING version
+	`
+
+	var version int
+	err := tx.QueryRow(query, change.NewStatus, change.CreatedAt, change.OrderID, change.OldStatus).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: order %d is no longer in status %s", models.ErrInvalidStatusTransition, change.OrderID, change.OldStatus)
+	} else if err != nil {
+		return 0, fmt.Errorf("could not update order status: %v", err)
+	}
+
+	if change.NewStatus == models.OrderStatusCancelled {
+		err = releaseStock(tx, change.OrderID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = insertStatusChange(tx, change)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// DeleteOrder помечает заказ удаленным и возвращает на склад товар, который еще не был отгружен
 func (r *OrderRepository) DeleteOrder(orderID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	currentStatus, err := lockOrderStatus(tx, orderID)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE orders
-		SET is_deleted = true
+		SET is_deleted = true, deleted_at = NOW(), version = version + 1
 		WHERE id = $1
 	`
-	result, err := r.db.Exec(query, orderID)
+	_, err = tx.Exec(query, orderID)
 	if err != nil {
 		return fmt.Errorf("could not delete order: %v", err)
 	}
 
-	affectedRows, err := result.RowsAffected()
+	if currentStatus == models.OrderStatusPending || currentStatus == models.OrderStatusConfirmed {
+		err = releaseStock(tx, orderID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit order deletion: %v", err)
+	}
+	return nil
+}
+
+// RestoreOrder снимает с заказа пометку об удалении. Для заказов, которые еще не были
+// отгружены, товар повторно резервируется на складе.
+func (r *OrderRepository) RestoreOrder(orderID int) error {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("could not get affected rows: %v", err)
+		return fmt.Errorf("could not begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	if affectedRows == 0 {
-		return fmt.Errorf("no order found with the given id")
+	var status string
+	err = tx.QueryRow(`SELECT status FROM orders WHERE id = $1 AND is_deleted = true FOR UPDATE`, orderID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: no deleted order with id %d", models.ErrOrderNotFound, orderID)
+	} else if err != nil {
+		return fmt.Errorf("could not lock order: %v", err)
 	}
 
+	if status == models.OrderStatusPending || status == models.OrderStatusConfirmed {
+		items, err := r.getOrderItems([]int{orderID})
+		if err != nil {
+			return err
+		}
+		if err = reserveStock(tx, items[orderID]); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE orders
+		SET is_deleted = false, deleted_at = NULL, version = version + 1
+		WHERE id = $1
+	`
+	_, err = tx.Exec(query, orderID)
+	if err != nil {
+		return fmt.Errorf("could not restore order: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit order restore: %v", err)
+	}
 	return nil
 }
 
-func (r *OrderRepository) GetOrderByID(orderID int) (*models.Order, error) {
+// PurgeDeletedOrders окончательно удаляет заказы, помеченные удаленными раньше before.
+// Позиции и история статусов удаляются каскадно.
+func (r *OrderRepository) PurgeDeletedOrders(before time.Time) (int, error) {
 	query := `
-		SELECT id, customer_name, status, total_price, product_id, created_at, updated_at, is_deleted
-        FROM orders
-        WHERE id = $1 AND is_deleted = false
+		DELETE FROM orders
+		WHERE is_deleted = true AND deleted_at < $1
 	`
-	var order models.Order
-	err := r.db.QueryRow(query, orderID).Scan(&order.ID, &order.CustomerName, &order.Status, &order.TotalPrice, &order.ProductID, &order.CreatedAt, &order.UpdatedAt, &order.IsDeleted)
 
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, fmt.Errorf("could not purge deleted orders: %v", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not purge deleted orders: %v", err)
+	}
+	return int(purged), nil
+}
+
+// CancelStalePendingOrders отменяет не более limit заказов, которые находятся в статусе pending
+// с момента раньше createdBefore: возвращает товар на склад, записывает историю статусов и причину отмены.
+// Возвращает ID отмененных заказов. Если обработку уже выполняет другая реплика, возвращается пустой список.
+func (r *OrderRepository) CancelStalePendingOrders(createdBefore, cancelledAt time.Time, limit int) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	err = tx.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, autoCancelLockKey).Scan(&locked)
+	if err != nil {
+		return nil, fmt.Errorf("could not acquire auto-cancel lock: %v", err)
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE orders
+		SET status = $1, updated_at = $2, version = version + 1
+		WHERE id IN (
+			SELECT id
+			FROM orders
+			WHERE status = $3 AND is_deleted = false AND created_at < $4
+			ORDER BY created_at, id
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`
+
+	rows, err := tx.Query(query, models.OrderStatusCancelled, cancelledAt, models.OrderStatusPending, createdBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not cancel stale orders: %v", err)
+	}
+
+	var orderIDs []int
+	for rows.Next() {
+		var orderID int
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not scan cancelled order: %v", err)
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not cancel stale orders: %v", err)
+	}
+
+	for _, orderID := range orderIDs {
+		err = releaseStock(tx, orderID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = insertStatusChange(tx, &models.OrderStatusChange{
+			OrderID:   orderID,
+			OldStatus: models.OrderStatusPending,
+			NewStatus: models.OrderStatusCancelled,
+			Reason:    models.CancellationReasonExpired,
+			CreatedAt: cancelledAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(orderIDs) > 0 {
+		query = `
+			INSERT INTO order_cancellations (order_id, reason_code, cancelled_at)
+			SELECT unnest($1::BIGINT[]), $2, $3
+		`
+		_, err = tx.Exec(query, pq.Array(orderIDs), models.CancellationReasonExpired, cancelledAt)
+		if err != nil {
+			return nil, fmt.Errorf("could not record order cancellations: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit stale orders cancellation: %v", err)
+	}
+	return orderIDs, nil
+}
+
+func (r *OrderRepository) GetOrderByID(orderID int) (*models.Order, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM orders
+		WHERE id = $1 AND is_deleted = false
+	`, orderColumns)
+
+	var order models.Order
+	err := scanOrder(r.db.QueryRow(query, orderID), &order)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("no order found with id: %d", orderID)
+			return nil, fmt.Errorf("%w with id: %d", models.ErrOrderNotFound, orderID)
 		}
 		return nil, fmt.Errorf("could not get order by id: %v", err)
 	}
+
+	items, err := r.getOrderItems([]int{order.ID})
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items[order.ID]
+
+	if order.Status == models.OrderStatusCancelled {
+		order.Cancellation, err = r.getOrderCancellation(order.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &order, nil
 }
 
-func (r *OrderRepository) GetOrdersByFilters(status string, minPrice, maxPrice float64) ([]models.Order, error) {
+// getOrderCancellation возвращает причину отмены заказа или nil, если заказ был отменен без нее
+func (r *OrderRepository) getOrderCancellation(orderID int) (*models.OrderCancellation, error) {
 	query := `
-		SELECT id, customer_name, status, total_price, product_id, created_at, updated_at, is_deleted
-		FROM orders
-		WHERE is_deleted = false
+		SELECT order_id, reason_code, note, cancelled_by, cancelled_at
+		FROM order_cancellations
+		WHERE order_id = $1
 	`
 
-	args := []interface{}{}
-	whereClauses := []string{}
+	var (
+		cancellation models.OrderCancellation
+		note         sql.NullString
+		cancelledBy  sql.NullInt64
+	)
+	err := r.db.QueryRow(query, orderID).Scan(
+		&cancellation.OrderID, &cancellation.ReasonCode, &note, &cancelledBy, &cancellation.CancelledAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get order cancellation: %v", err)
+	}
+	cancellation.Note = note.String
+	cancellation.CancelledBy = int(cancelledBy.Int64)
 
-	if status != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("status = $%d", len(args)+1))
-		args = append(args, status)
+	return &cancellation, nil
+}
+
+// GetOrderStatusHistory возвращает историю смены статусов заказа в хронологическом порядке
+func (r *OrderRepository) GetOrderStatusHistory(orderID int) ([]models.OrderStatusChange, error) {
+	query := `
+		SELECT id, order_id, old_status, new_status, changed_by, reason, created_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get order status history: %w", err)
 	}
+	defer rows.Close()
 
-	if minPrice > 0 {
-		whereClauses = append(whereClauses, fmt.Sprintf("total_price >= $%d", len(args)+1))
-		args = append(args, minPrice)
+	history := []models.OrderStatusChange{}
+	for rows.Next() {
+		var (
+			change    models.OrderStatusChange
+			changedBy sql.NullInt64
+			reason    sql.NullString
+		)
+		if err := rows.Scan(
+			&change.ID, &change.OrderID, &change.OldStatus, &change.NewStatus, &changedBy, &reason, &change.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan order status change: %w", err)
+		}
+		change.ChangedBy = int(changedBy.Int64)
+		change.Reason = reason.String
+		history = append(history, change)
 	}
 
-	if maxPrice > 0 {
-		whereClauses = append(whereClauses, fmt.Sprintf("total_price <= $%d", len(args)+1))
-		args = append(args, maxPrice)
+	return history, rows.Err()
+}
+
+// CreateOrderNote сохраняет заметку к заказу и заполняет ее идентификатор и время создания
+func (r *OrderRepository) CreateOrderNote(note *models.OrderNote) error {
+	query := `
+		INSERT INTO order_notes (order_id, author_id, text, is_internal)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	authorID := sql.NullInt64{Int64: int64(note.AuthorID), Valid: note.AuthorID > 0}
+	err := r.db.QueryRow(query, note.OrderID, authorID, note.Text, note.IsInternal).Scan(&note.ID, &note.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("could not create order note: %w", err)
 	}
+	return nil
+}
 
-	if len(whereClauses) > 0 {
-		query += " AND " + strings.Join(whereClauses, " AND ")
+// GetOrderNotes возвращает заметки к заказу в хронологическом порядке. Если includeInternal = false,
+// внутренние заметки не возвращаются.
+func (r *OrderRepository) GetOrderNotes(orderID int, includeInternal bool) ([]models.OrderNote, error) {
+	query := `
+		SELECT id, order_id, author_id, text, is_internal, created_at
+		FROM order_notes
+		WHERE order_id = $1 AND ($2 OR NOT is_internal)
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(query, orderID, includeInternal)
+	if err != nil {
+		return nil, fmt.Errorf("could not get order notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []models.OrderNote{}
+	for rows.Next() {
+		var (
+			note     models.OrderNote
+			authorID sql.NullInt64
+		)
+		if err := rows.Scan(&note.ID, &note.OrderID, &authorID, &note.Text, &note.IsInternal, &note.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan order note: %w", err)
+		}
+		note.AuthorID = int(authorID.Int64)
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
+
+// orderSortColumns сопоставляет поле сортировки с колонкой таблицы orders.
+// Заказы в разных валютах сравниваются по сумме в базовой валюте.
+var orderSortColumns = map[string]string{
+	"created_at":  "created_at",
+	"total_price": "base_total_price",
+	"status":      "status",
+}
+
+// likeEscaper экранирует спецсимволы шаблона LIKE в пользовательском вводе
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// orderCursor позиция последнего заказа страницы для keyset-пагинации
+type orderCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// GetOrdersByFilters возвращает страницу заказов, отсортированную по filter.Sort.
+// Знак "-" перед полем сортировки означает порядок по убыванию.
+func (r *OrderRepository) GetOrdersByFilters(filter models.OrderFilter) (*models.OrderPage, error) {
+	sortField := strings.TrimPrefix(filter.Sort, "-")
+	descending := strings.HasPrefix(filter.Sort, "-")
+
+	sortColumn, ok := orderSortColumns[sortField]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported sort %q", models.ErrInvalidFilter, filter.Sort)
+	}
+	if filter.Limit <= 0 {
+		return nil, fmt.Errorf("%w: limit must be positive", models.ErrInvalidFilter)
 	}
 
+	whereClauses, args := orderFilterClauses(filter)
+
+	var (
+		cursorValue interface{}
+		cursorID    int
+		err         error
+	)
+	if filter.Cursor != "" {
+		cursorValue, cursorID, err = decodeOrderCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	page := &models.OrderPage{}
+
+	countQuery := "SELECT COUNT(*) FROM orders WHERE " + strings.Join(whereClauses, " AND ")
+	err = r.db.QueryRow(countQuery, args...).Scan(&page.TotalCount)
+	if err != nil {
+		return nil, fmt.Errorf("could not count orders: %w", err)
+	}
+
+	if filter.Cursor != "" {
+		operator := ">"
+		if descending {
+			operator = "<"
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf("(%s, id) %s ($%d, $%d)", sortColumn, operator, len(args)+1, len(args)+2))
+		args = append(args, cursorValue, cursorID)
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM orders
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT $%d
+	`, orderColumns, strings.Join(whereClauses, " AND "), sortColumn, direction, direction, len(args)+1)
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	args = append(args, filter.Limit+1)
+
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get orders: %w", err)
 	}
 	defer rows.Close()
 
-	var orders []models.Order
+	orders := make([]models.Order, 0, filter.Limit+1)
 	for rows.Next() {
 		var order models.Order
-		if err := rows.Scan(
-			&order.ID, &order.CustomerName, &order.Status, &order.TotalPrice,
-			&order.ProductID, &order.CreatedAt, &order.UpdatedAt, &order.IsDeleted,
-		); err != nil {
+		if err := scanOrder(rows, &order); err != nil {
 			return nil, fmt.Errorf("could not scan order: %w", err)
 		}
 		orders = append(orders, order)
 	}
 
-	return orders, nil
+	if len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+		page.NextCursor = encodeOrderCursor(filter.Sort, sortField, orders[len(orders)-1])
+	}
+	page.Orders = orders
+
+	if len(orders) == 0 {
+		return page, nil
+	}
+
+	orderIDs := make([]int, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+	}
+
+	items, err := r.getOrderItems(orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		orders[i].Items = items[orders[i].ID]
+	}
+
+	return page, nil
+}
+
+// StreamOrdersByFilters передает в fn по одному все заказы, подходящие под фильтр, в порядке filter.Sort.
+// Заказы читаются из курсора базы данных построчно, без загрузки всей выборки в память.
+// Лимит и курсор фильтра не учитываются.
+func (r *OrderRepository) StreamOrdersByFilters(filter models.OrderFilter, fn func(order *models.Order) error) error {
+	sortColumn, ok := orderSortColumns[strings.TrimPrefix(filter.Sort, "-")]
+	if !ok {
+		return fmt.Errorf("%w: unsupported sort %q", models.ErrInvalidFilter, filter.Sort)
+	}
+
+	direction := "ASC"
+	if strings.HasPrefix(filter.Sort, "-") {
+		direction = "DESC"
+	}
+
+	whereClauses, args := orderFilterClauses(filter)
+
+	// Позиции собираются в массивы в том же запросе, чтобы не делать отдельный запрос на каждый заказ
+	query := fmt.Sprintf(`
+		SELECT %s,
+		       oi.ids, oi.product_ids, oi.quantities, oi.unit_prices
+		FROM orders
+		LEFT JOIN LATERAL (
+			SELECT array_agg(id ORDER BY id) AS ids,
+			       array_agg(product_id ORDER BY id) AS product_ids,
+			       array_agg(quantity ORDER BY id) AS quantities,
+			       array_agg(unit_price ORDER BY id) AS unit_prices
+			FROM order_items
+			WHERE order_id = orders.id
+		) oi ON true
+		WHERE %s
+		ORDER BY %s %s, id %s
+	`, orderColumns, strings.Join(whereClauses, " AND "), sortColumn, direction, direction)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("could not export orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			order                           models.Order
+			itemIDs, productIDs, quantities pq.Int64Array
+			unitPrices                      []models.Money
+		)
+		if err := scanOrder(rows, &order, &itemIDs, &productIDs, &quantities, pq.Array(&unitPrices)); err != nil {
+			return fmt.Errorf("could not scan order: %w", err)
+		}
+
+		for i := range itemIDs {
+			order.Items = append(order.Items, models.OrderItem{
+				ID:        int(itemIDs[i]),
+				OrderID:   order.ID,
+				ProductID: int(productIDs[i]),
+				Quantity:  int(quantities[i]),
+				UnitPrice: unitPrices[i],
+			})
+		}
+
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// rowScanner общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOrder читает колонки orderColumns в order, extra - колонки, выбранные после них
+func scanOrder(row rowScanner, order *models.Order, extra ...interface{}) error {
+	var (
+		customerID sql.NullInt64
+		userID     sql.NullInt64
+		couponCode sql.NullString
+	)
+	dest := []interface{}{
+		&order.ID, &customerID, &order.CustomerName, &order.Status, &order.TotalPrice,
+		&order.CreatedAt, &order.UpdatedAt, &order.IsDeleted, &order.Version, &userID,
+		&couponCode, &order.DiscountAmount, &order.Region, &order.Subtotal, &order.TaxAmount,
+		&order.Currency, &order.ExchangeRate, &order.BaseTotalPrice,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return err
+	}
+
+	order.CustomerID = int(customerID.Int64)
+	order.UserID = int(userID.Int64)
+	order.CouponCode = couponCode.String
+	return nil
+}
+
+// orderFilterClauses строит условия WHERE и их аргументы для фильтра заказов
+func orderFilterClauses(filter models.OrderFilter) ([]string, []interface{}) {
+	args := []interface{}{}
+	whereClauses := []string{"is_deleted = false"}
+	if filter.Deleted {
+		whereClauses[0] = "is_deleted = true"
+	}
+
+	if len(filter.Statuses) > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("status = ANY($%d)", len(args)+1))
+		args = append(args, pq.Array(filter.Statuses))
+	}
+
+	if filter.MinPrice > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("base_total_price >= $%d", len(args)+1))
+		args = append(args, filter.MinPrice)
+	}
+
+	if filter.MaxPrice > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("base_total_price <= $%d", len(args)+1))
+		args = append(args, filter.MaxPrice)
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		whereClauses = append(whereClauses, fmt.Sprintf("created_at >= $%d", len(args)+1))
+		args = append(args, filter.CreatedFrom)
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		whereClauses = append(whereClauses, fmt.Sprintf("created_at <= $%d", len(args)+1))
+		args = append(args, filter.CreatedTo)
+	}
+
+	if !filter.UpdatedSince.IsZero() {
+		whereClauses = append(whereClauses, fmt.Sprintf("updated_at >= $%d", len(args)+1))
+		args = append(args, filter.UpdatedSince)
+	}
+
+	if filter.ProductID > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = orders.id AND oi.product_id = $%d)", len(args)+1,
+		))
+		args = append(args, filter.ProductID)
+	}
+
+	if filter.CustomerID > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("customer_id = $%d", len(args)+1))
+		args = append(args, filter.CustomerID)
+	}
+
+	if filter.CustomerName != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("customer_name ILIKE $%d", len(args)+1))
+		args = append(args, "%"+likeEscaper.Replace(filter.CustomerName)+"%")
+	}
+
+	if filter.UserID > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("user_id = $%d", len(args)+1))
+		args = append(args, filter.UserID)
+	}
+
+	return whereClauses, args
+}
+
+// encodeOrderCursor кодирует позицию заказа в непрозрачный для клиента курсор
+func encodeOrderCursor(sort, sortField string, order models.Order) string {
+	cursor := orderCursor{Sort: sort, ID: order.ID}
+
+	switch sortField {
+	case "created_at":
+		cursor.Value = order.CreatedAt.Format(time.RFC3339Nano)
+	case "total_price":
+		cursor.Value = order.BaseTotalPrice.String()
+	case "status":
+		cursor.Value = order.Status
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeOrderCursor разбирает курсор и возвращает значение поля сортировки и ID последнего заказа
+func decodeOrderCursor(encoded, sort string) (interface{}, int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: malformed cursor", models.ErrInvalidFilter)
+	}
+
+	var cursor orderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, 0, fmt.Errorf("%w: malformed cursor", models.ErrInvalidFilter)
+	}
+
+	if cursor.Sort != sort {
+		return nil, 0, fmt.Errorf("%w: cursor was issued for sort %q", models.ErrInvalidFilter, cursor.Sort)
+	}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case "created_at":
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: malformed cursor", models.ErrInvalidFilter)
+		}
+		return createdAt, cursor.ID, nil
+	case "total_price":
+		totalPrice, err := models.ParseMoney(cursor.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: malformed cursor", models.ErrInvalidFilter)
+		}
+		return totalPrice, cursor.ID, nil
+	default:
+		return cursor.Value, cursor.ID, nil
+	}
+}
+
+// getOrderItems возвращает позиции заказов, сгруппированные по ID заказа
+func (r *OrderRepository) getOrderItems(orderIDs []int) (map[int][]models.OrderItem, error) {
+	query := `
+		SELECT id, order_id, product_id, quantity, unit_price
+		FROM order_items
+		WHERE order_id = ANY($1)
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(query, pq.Array(orderIDs))
+	if err != nil {
+		return nil, fmt.Errorf("could not get order items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make(map[int][]models.OrderItem, len(orderIDs))
+	for rows.Next() {
+		var item models.OrderItem
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.UnitPrice); err != nil {
+			return nil, fmt.Errorf("could not scan order item: %w", err)
+		}
+		items[item.OrderID] = append(items[item.OrderID], item)
+	}
+
+	return items, rows.Err()
+}
+
+// lockOrderStatus блокирует строку заказа до конца транзакции и возвращает его текущий статус
+func lockOrderStatus(tx *sql.Tx, orderID int) (string, error) {
+	query := `
+		SELECT status
+		FROM orders
+		WHERE id = $1 AND is_deleted = false
+		FOR UPDATE
+	`
+
+	var status string
+	err := tx.QueryRow(query, orderID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w with the given id", models.ErrOrderNotFound)
+	} else if err != nil {
+		return "", fmt.Errorf("could not lock order: %v", err)
+	}
+
+	return status, nil
+}
+
+// reserveStock блокирует строки продуктов и списывает со склада количество, необходимое для позиций заказа
+func reserveStock(tx *sql.Tx, items []models.OrderItem) error {
+	quantities := make(map[int]int, len(items))
+	productIDs := make([]int, 0, len(items))
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	// Блокируем продукты в одном и том же порядке, чтобы параллельные заказы не попадали в deadlock
+	sort.Ints(productIDs)
+
+	for _, productID := range productIDs {
+		var available int
+		err := tx.QueryRow(`SELECT quantity FROM products WHERE id = $1 FOR UPDATE`, productID).Scan(&available)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: no product found with id %d", models.ErrInvalidOrderData, productID)
+		} else if err != nil {
+			return fmt.Errorf("could not lock product: %v", err)
+		}
+
+		requested := quantities[productID]
+		if available < requested {
+			return fmt.Errorf("%w: product %d has %d in stock, %d requested", models.ErrOutOfStock, productID, available, requested)
+		}
+
+		_, err = tx.Exec(`UPDATE products SET quantity = quantity - $1 WHERE id = $2`, requested, productID)
+		if err != nil {
+			return fmt.Errorf("could not reserve product stock: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// releaseStock возвращает на склад товар из позиций заказа
+func releaseStock(tx *sql.Tx, orderID int) error {
+	query := `
+		UPDATE products p
+		SET quantity = p.quantity + oi.quantity
+		FROM (
+			SELECT product_id, SUM(quantity) AS quantity
+			FROM order_items
+			WHERE order_id = $1
+			GROUP BY product_id
+		) oi
+		WHERE p.id = oi.product_id
+	`
+
+	_, err := tx.Exec(query, orderID)
+	if err != nil {
+		return fmt.Errorf("could not release product stock: %v", err)
+	}
+	return nil
+}
+
+// insertStatusChange записывает изменение статуса заказа в историю
+func insertStatusChange(tx *sql.Tx, change *models.OrderStatusChange) error {
+	query := `
+		INSERT INTO order_status_history (order_id, old_status, new_status, changed_by, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	changedBy := sql.NullInt64{Int64: int64(change.ChangedBy), Valid: change.ChangedBy > 0}
+	reason := sql.NullString{String: change.Reason, Valid: change.Reason != ""}
+
+	err := tx.QueryRow(query, change.OrderID, change.OldStatus, change.NewStatus, changedBy, reason, change.CreatedAt).Scan(&change.ID)
+	if err != nil {
+		return fmt.Errorf("could not record order status change: %v", err)
+	}
+	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/lib/pq"
 )
 
 type ProductRepository struct {
@@ -17,9 +18,9 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 
 func (r *ProductRepository) CreateProduct(product *models.Product) error {
 	query := `
-		INSERT INTO products (name, price, quantity) VALUES ($1, $2, $3)
+		INSERT INTO products (name, price, quantity, category, currency) VALUES ($1, $2, $3, $4, $5)
 	`
-	_, err := r.db.Exec(query, product.Name, product.Price, product.Quantity)
+	_, err := r.db.Exec(query, product.Name, product.Price, product.Quantity, product.Category, product.Currency)
 	if err != nil {
 		return fmt.Errorf("could not create product: %v", err)
 	}
@@ -28,14 +29,14 @@ func (r *ProductRepository) CreateProduct(product *models.Product) error {
 
 func (r *ProductRepository) GetProductByID(productID int) (*models.Product, error) {
 	query := `
-		SELECT id, name, price, quantity 
+		SELECT id, name, price, quantity, category, currency
 		FROM products
 		WHERE id = $1
 	`
 	row := r.db.QueryRow(query, productID)
 
 	var product models.Product
-	err := row.Scan(&product.ID, &product.Name, &product.Price, &product.Quantity)
+	err := row.Scan(&product.ID, &product.Name, &product.Price, &product.Quantity, &product.Category, &product.Currency)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	} else if err != nil {
@@ -48,10 +49,10 @@ func (r *ProductRepository) GetProductByID(productID int) (*models.Product, erro
 func (r *ProductRepository) UpdateProduct(product *models.Product) error {
 	query := `
 		UPDATE products
-		SET name = $1, price = $2, quantity = $3 
-		WHERE id = $4
+		SET name = $1, price = $2, quantity = $3, category = $4, currency = $5
+		WHERE id = $6
 	`
-	result, err := r.db.Exec(query, product.Name, product.Price, product.Quantity, product.ID)
+	result, err := r.db.Exec(query, product.Name, product.Price, product.Quantity, product.Category, product.Currency, product.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
@@ -80,7 +81,7 @@ func (r *ProductRepository) DeleteProductByID(productID int) error {
 }
 
 func (r *ProductRepository) GetAllProducts() ([]models.Product, error) {
-	query := "SELECT id, name, price, quantity FROM products"
+	query := "SELECT id, name, price, quantity, category, currency FROM products"
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products: %w", err)
@@ -90,7 +91,7 @@ func (r *ProductRepository) GetAllProducts() ([]models.Product, error) {
 	var products []models.Product
 	for rows.Next() {
 		var product models.Product
-		err = rows.Scan(&product.ID, &product.Name, &product.Price, &product.Quantity)
+		err = rows.Scan(&product.ID, &product.Name, &product.Price, &product.Quantity, &product.Category, &product.Currency)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan product row: %w", err)
 		}
@@ -99,3 +100,125 @@ func (r *ProductRepository) GetAllProducts() ([]models.Product, error) {
 
 	return products, nil
 }
+
+// ImportProducts загружает строки импорта одной транзакцией через COPY во временную таблицу,
+// затем создает новые продукты и обновляет существующие. Пустая валюта строки обновления и отсутствие
+// колонки category оставляют валюту и категорию продукта без изменений. Для каждой строки заполняются
+// ProductID и Status; строки с несуществующим id помечаются как ошибочные.
+func (r *ProductRepository) ImportProducts(rows []models.ProductImportRow) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		CREATE TEMP TABLE product_import (
+			row_num INTEGER NOT NULL,
+			id BIGINT,
+			name VARCHAR(255) NOT NULL,
+			price DECIMAL(10, 2) NOT NULL,
+			quantity INT NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			category VARCHAR(100),
+			is_new BOOLEAN NOT NULL
+		) ON COMMIT DROP
+	`)
+	if err != nil {
+		return fmt.Errorf("could not create import table: %v", err)
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("product_import", "row_num", "id", "name", "price", "quantity", "currency", "category", "is_new"))
+	if err != nil {
+		return fmt.Errorf("could not start products copy: %v", err)
+	}
+
+	for _, row := range rows {
+		id := sql.NullInt64{Int64: int64(row.Product.ID), Valid: row.Product.ID != 0}
+		// NULL означает, что колонки category в файле нет и категорию нужно оставить прежней
+		category := sql.NullString{String: row.Product.Category, Valid: row.HasCategory}
+		_, err = stmt.Exec(row.Row, id, row.Product.Name, row.Product.Price, row.Product.Quantity, row.Product.Currency, category, !id.Valid)
+		if err != nil {
+			stmt.Close()
+			return fmt.Errorf("could not copy product row %d: %v", row.Row, err)
+		}
+	}
+
+	if _, err = stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("could not copy products: %v", err)
+	}
+	if err = stmt.Close(); err != nil {
+		return fmt.Errorf("could not copy products: %v", err)
+	}
+
+	// Идентификаторы новых продуктов выделяются заранее, чтобы сопоставить их со строками файла
+	createdIDs, err := queryImportRows(tx, `
+		UPDATE product_import
+		SET id = nextval(pg_get_serial_sequence('products', 'id'))
+		WHERE is_new
+		RETURNING row_num, id
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO products (id, name, price, quantity, currency, category)
+		SELECT id, name, price, quantity, currency, COALESCE(category, '')
+		FROM product_import
+		WHERE is_new
+	`)
+	if err != nil {
+		return fmt.Errorf("could not create imported products: %v", err)
+	}
+
+	updatedIDs, err := queryImportRows(tx, `
+		UPDATE products p
+		SET name = i.name, price = i.price, quantity = i.quantity, currency = COALESCE(NULLIF(i.currency, ''), p.currency),
+		    category = COALESCE(i.category, p.category)
+		FROM product_import i
+		WHERE NOT i.is_new AND p.id = i.id
+		RETURNING i.row_num, p.id
+	`)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit products import: %v", err)
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if id, ok := createdIDs[row.Row]; ok {
+			row.ProductID, row.Status = id, models.ProductImportCreated
+		} else if id, ok := updatedIDs[row.Row]; ok {
+			row.ProductID, row.Status = id, models.ProductImportUpdated
+		} else {
+			row.ProductID, row.Status = row.Product.ID, models.ProductImportFailed
+			row.Error = fmt.Sprintf("no product found with id %d", row.Product.ID)
+		}
+	}
+
+	return nil
+}
+
+// queryImportRows выполняет запрос, возвращающий пары (номер строки, id продукта)
+func queryImportRows(tx *sql.Tx, query string) (map[int]int, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("could not import products: %v", err)
+	}
+	defer rows.Close()
+
+	ids := map[int]int{}
+	for rows.Next() {
+		var rowNum, id int
+		if err := rows.Scan(&rowNum, &id); err != nil {
+			return nil, fmt.Errorf("could not scan imported product: %v", err)
+		}
+		ids[rowNum] = id
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"database/sql"
+	"fmt"
+)
+
+type TaxRuleRepository struct {
+	db *sql.DB
+}
+
+func NewTaxRuleRepository(db *sql.DB) *TaxRuleRepository {
+	return &TaxRuleRepository{db: db}
+}
+
+// GetTaxRules возвращает правила, подходящие для региона: правила самого региона и правила для любого региона
+func (r *TaxRuleRepository) GetTaxRules(region string) ([]models.TaxRule, error) {
+	query := `
+		SELECT id, region, category, rate, inclusive
+		FROM tax_rules
+		WHERE region = '' OR region = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(query, region)
+	if err != nil {
+		return nil, fmt.Errorf("could not get tax rules: %v", err)
+	}
+	defer rows.Close()
+
+	rules := []models.TaxRule{}
+	for rows.Next() {
+		var rule models.TaxRule
+		err := rows.Scan(&rule.ID, &rule.Region, &rule.Category, &rule.Rate, &rule.Inclusive)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan tax rule: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+// webhookColumns колонки подписки в порядке, в котором их читает scanWebhook
+const webhookColumns = "id, url, secret, event_types, is_active, created_at, updated_at"
+
+// webhookDeliveryColumns колонки доставки в порядке, в котором их читает scanWebhookDelivery
+const webhookDeliveryColumns = "id, webhook_id, event_type, payload, status, attempts, response_status, last_error, created_at, updated_at"
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateWebhook(webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (url, secret, event_types, is_active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes), webhook.IsActive).
+		Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("could not create webhook: %v", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) UpdateWebhook(webhook *models.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, secret = $2, event_types = $3, is_active = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes), webhook.IsActive, webhook.ID).
+		Scan(&webhook.CreatedAt, &webhook.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w with id: %d", models.ErrWebhookNotFound, webhook.ID)
+	} else if err != nil {
+		return fmt.Errorf("could not update webhook: %v", err)
+	}
+	return nil
+}
+
+// DeleteWebhook удаляет подписку вместе с историей ее доставок
+func (r *WebhookRepository) DeleteWebhook(webhookID int) error {
+	result, err := r.db.Exec("DELETE FROM webhooks WHERE id = $1", webhookID)
+	if err != nil {
+		return fmt.Errorf("could not delete webhook: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not delete webhook: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with id: %d", models.ErrWebhookNotFound, webhookID)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetWebhookByID(webhookID int) (*models.Webhook, error) {
+	query := fmt.Sprintf("SELECT %s FROM webhooks WHERE id = $1", webhookColumns)
+
+	var webhook models.Webhook
+	err := scanWebhook(r.db.QueryRow(query, webhookID), &webhook)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w with id: %d", models.ErrWebhookNotFound, webhookID)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get webhook by id: %v", err)
+	}
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) GetAllWebhooks() ([]models.Webhook, error) {
+	query := fmt.Sprintf("SELECT %s FROM webhooks ORDER BY id", webhookColumns)
+	return r.queryWebhooks(query)
+}
+
+// GetActiveWebhooksByEvent возвращает активные подписки на события типа eventType
+func (r *WebhookRepository) GetActiveWebhooksByEvent(eventType string) ([]models.Webhook, error) {
+	query := fmt.Sprintf("SELECT %s FROM webhooks WHERE is_active AND $1 = ANY(event_types) ORDER BY id", webhookColumns)
+	return r.queryWebhooks(query, eventType)
+}
+
+func (r *WebhookRepository) queryWebhooks(query string, args ...interface{}) ([]models.Webhook, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get webhooks: %v", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		var webhook models.Webhook
+		if err := scanWebhook(rows, &webhook); err != nil {
+			return nil, fmt.Errorf("could not scan webhook: %v", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepository) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, delivery.WebhookID, delivery.EventType, []byte(delivery.Payload), delivery.Status).
+		Scan(&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("could not create webhook delivery: %v", err)
+	}
+	return nil
+}
+
+// UpdateWebhookDelivery сохраняет результат очередной попытки отправки
+func (r *WebhookRepository) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_status = $3, last_error = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING updated_at
+	`
+
+	responseStatus := sql.NullInt64{Int64: int64(delivery.ResponseStatus), Valid: delivery.ResponseStatus != 0}
+	lastError := sql.NullString{String: delivery.LastError, Valid: delivery.LastError != ""}
+	err := r.db.QueryRow(query, delivery.Status, delivery.Attempts, responseStatus, lastError, delivery.ID).
+		Scan(&delivery.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w with id: %d", models.ErrWebhookDeliveryNotFound, delivery.ID)
+	} else if err != nil {
+		return fmt.Errorf("could not update webhook delivery: %v", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetWebhookDeliveryByID(deliveryID int) (*models.WebhookDelivery, error) {
+	query := fmt.Sprintf("SELECT %s FROM webhook_deliveries WHERE id = $1", webhookDeliveryColumns)
+
+	var delivery models.WebhookDelivery
+	err := scanWebhookDelivery(r.db.QueryRow(query, deliveryID), &delivery)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w with id: %d", models.ErrWebhookDeliveryNotFound, deliveryID)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get webhook delivery by id: %v", err)
+	}
+	return &delivery, nil
+}
+
+// GetWebhookDeliveries возвращает доставки подписки, начиная с последних
+func (r *WebhookRepository) GetWebhookDeliveries(webhookID int) ([]models.WebhookDelivery, error) {
+	query := fmt.Sprintf("SELECT %s FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY created_at DESC, id DESC", webhookDeliveryColumns)
+
+	rows, err := r.db.Query(query, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			return nil, fmt.Errorf("could not scan webhook delivery: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// ClaimStalePendingDeliveries забирает до limit доставок, которые находятся в статусе pending и не обновлялись
+// с момента staleBefore. У забранных доставок обновляется updated_at, поэтому другие реплики их не заберут,
+// пока они снова не зависнут.
+func (r *WebhookRepository) ClaimStalePendingDeliveries(staleBefore time.Time, limit int) ([]models.WebhookDelivery, error) {
+	query := fmt.Sprintf(`
+		UPDATE webhook_deliveries
+		SET updated_at = NOW()
+		WHERE id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = $1 AND updated_at < $2
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s
+	`, webhookDeliveryColumns)
+
+	rows, err := r.db.Query(query, models.WebhookDeliveryPending, staleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not claim pending webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			return nil, fmt.Errorf("could not scan webhook delivery: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// scanWebhook читает колонки webhookColumns в webhook
+func scanWebhook(row rowScanner, webhook *models.Webhook) error {
+	var eventTypes pq.StringArray
+	err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &eventTypes, &webhook.IsActive, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	webhook.EventTypes = []string(eventTypes)
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = []string{}
+	}
+	return nil
+}
+
+// scanWebhookDelivery читает колонки webhookDeliveryColumns в delivery
+func scanWebhookDelivery(row rowScanner, delivery *models.WebhookDelivery) error {
+	var (
+		payload        []byte
+		responseStatus sql.NullInt64
+		lastError      sql.NullString
+	)
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventType, &payload, &delivery.Status, &delivery.Attempts,
+		&responseStatus, &lastError, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	delivery.Payload = payload
+	delivery.ResponseStatus = int(responseStatus.Int64)
+	delivery.LastError = lastError.String
+	return nil
+}
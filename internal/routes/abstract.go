@@ -7,10 +7,65 @@ import (
 // OrderHandlerInterface определяет методы для управления заказами.
 type OrderHandlerInterface interface {
 	GetOrdersByFilters(w http.ResponseWriter, r *http.Request)
+	ExportOrders(w http.ResponseWriter, r *http.Request)
 	GetOrderByID(w http.ResponseWriter, r *http.Request)
 	CreateOrder(w http.ResponseWriter, r *http.Request)
+	BulkCreateOrders(w http.ResponseWriter, r *http.Request)
+	BulkTransitionOrders(w http.ResponseWriter, r *http.Request)
 	UpdateOrder(w http.ResponseWriter, r *http.Request)
+	PatchOrder(w http.ResponseWriter, r *http.Request)
+	TransitionOrder(w http.ResponseWriter, r *http.Request)
+	CancelOrder(w http.ResponseWriter, r *http.Request)
+	GetOrderStatusHistory(w http.ResponseWriter, r *http.Request)
+	AddOrderNote(w http.ResponseWriter, r *http.Request)
+	GetOrderNotes(w http.ResponseWriter, r *http.Request)
 	DeleteOrder(w http.ResponseWriter, r *http.Request)
+	RestoreOrder(w http.ResponseWriter, r *http.Request)
+	PurgeDeletedOrders(w http.ResponseWriter, r *http.Request)
+}
+
+// CouponHandlerInterface определяет методы для управления купонами.
+type CouponHandlerInterface interface {
+	GetAllCoupons(w http.ResponseWriter, r *http.Request)
+	GetCouponByID(w http.ResponseWriter, r *http.Request)
+	CreateCoupon(w http.ResponseWriter, r *http.Request)
+	UpdateCoupon(w http.ResponseWriter, r *http.Request)
+	DeleteCoupon(w http.ResponseWriter, r *http.Request)
+}
+
+// CustomerHandlerInterface определяет методы для управления клиентами.
+type CustomerHandlerInterface interface {
+	GetAllCustomers(w http.ResponseWriter, r *http.Request)
+	GetCustomerByID(w http.ResponseWriter, r *http.Request)
+	GetCustomerOrders(w http.ResponseWriter, r *http.Request)
+	CreateCustomer(w http.ResponseWriter, r *http.Request)
+	UpdateCustomer(w http.ResponseWriter, r *http.Request)
+	DeleteCustomer(w http.ResponseWriter, r *http.Request)
+}
+
+// ExchangeRateHandlerInterface определяет методы для управления курсами валют.
+type ExchangeRateHandlerInterface interface {
+	GetAllExchangeRates(w http.ResponseWriter, r *http.Request)
+	SetExchangeRate(w http.ResponseWriter, r *http.Request)
+	DeleteExchangeRate(w http.ResponseWriter, r *http.Request)
+}
+
+// WebhookHandlerInterface определяет методы для управления подписками на события заказов.
+type WebhookHandlerInterface interface {
+	GetAllWebhooks(w http.ResponseWriter, r *http.Request)
+	GetWebhookByID(w http.ResponseWriter, r *http.Request)
+	GetWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	UpdateWebhook(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request)
+}
+
+// AnalyticsHandlerInterface определяет методы отчетов по продажам.
+type AnalyticsHandlerInterface interface {
+	GetRevenue(w http.ResponseWriter, r *http.Request)
+	GetOrdersByStatus(w http.ResponseWriter, r *http.Request)
+	GetTopProducts(w http.ResponseWriter, r *http.Request)
 }
 
 // ProductHandlerInterface определяет методы для управления продуктами.
@@ -19,6 +74,8 @@ type ProductHandlerInterface interface {
 	GetProductByID(w http.ResponseWriter, r *http.Request)
 	CreateProduct(w http.ResponseWriter, r *http.Request)
 	UpdateProduct(w http.ResponseWriter, r *http.Request)
+	PatchProduct(w http.ResponseWriter, r *http.Request)
+	ImportProducts(w http.ResponseWriter, r *http.Request)
 	DeleteProduct(w http.ResponseWriter, r *http.Request)
 }
 
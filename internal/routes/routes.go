@@ -20,12 +20,23 @@ func (rt *Routes) SetupOrderRoutes(orderHandler OrderHandlerInterface) {
 
 		// Эндпоинты для роли User
 		r.With(middleware.RoleMiddleware("User", "Admin")).Post("/", orderHandler.CreateOrder)
+		r.With(middleware.RoleMiddleware("User", "Admin")).Post("/bulk", orderHandler.BulkCreateOrders)
+		r.With(middleware.RoleMiddleware("User", "Admin")).Post("/bulk-status", orderHandler.BulkTransitionOrders)
 		r.With(middleware.RoleMiddleware("User", "Admin")).Get("/", orderHandler.GetOrdersByFilters)
 		r.With(middleware.RoleMiddleware("User", "Admin")).Get("/{id}", orderHandler.GetOrderByID)
+		r.With(middleware.RoleMiddleware("User", "Admin")).Get("/{id}/history", orderHandler.GetOrderStatusHistory)
+		r.With(middleware.RoleMiddleware("User", "Admin")).Get("/{id}/notes", orderHandler.GetOrderNotes)
+		r.With(middleware.RoleMiddleware("User", "Admin")).Post("/{id}/notes", orderHandler.AddOrderNote)
 		r.With(middleware.RoleMiddleware("User", "Admin")).Put("/{id}", orderHandler.UpdateOrder)
+		r.With(middleware.RoleMiddleware("User", "Admin")).Patch("/{id}", orderHandler.PatchOrder)
+		r.With(middleware.RoleMiddleware("User", "Admin")).Post("/{id}/transitions", orderHandler.TransitionOrder)
+		r.With(middleware.RoleMiddleware("User", "Admin")).Post("/{id}/cancel", orderHandler.CancelOrder)
 
 		// Эндпоинты для роли Admin
+		r.With(middleware.RoleMiddleware("Admin")).Get("/export", orderHandler.ExportOrders)
 		r.With(middleware.RoleMiddleware("Admin")).Delete("/{id}", orderHandler.DeleteOrder)
+		r.With(middleware.RoleMiddleware("Admin")).Post("/{id}/restore", orderHandler.RestoreOrder)
+		r.With(middleware.RoleMiddleware("Admin")).Post("/purge", orderHandler.PurgeDeletedOrders)
 	})
 }
 
@@ -35,7 +46,9 @@ func (rt *Routes) SetupProductRoutes(productHandler ProductHandlerInterface) {
 
 		// Эндпоинты для роли Admin
 		r.With(middleware.RoleMiddleware("Admin")).Post("/", productHandler.CreateProduct)
+		r.With(middleware.RoleMiddleware("Admin")).Post("/import", productHandler.ImportProducts)
 		r.With(middleware.RoleMiddleware("Admin")).Put("/{id}", productHandler.UpdateProduct)
+		r.With(middleware.RoleMiddleware("Admin")).Patch("/{id}", productHandler.PatchProduct)
 		r.With(middleware.RoleMiddleware("Admin")).Delete("/{id}", productHandler.DeleteProduct)
 
 		r.Get("/", productHandler.GetAllProducts)
@@ -43,6 +56,70 @@ func (rt *Routes) SetupProductRoutes(productHandler ProductHandlerInterface) {
 	})
 }
 
+func (rt *Routes) SetupCouponRoutes(couponHandler CouponHandlerInterface) {
+	rt.r.Route("/coupons", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		// Эндпоинты для роли Admin
+		r.With(middleware.RoleMiddleware("Admin")).Get("/", couponHandler.GetAllCoupons)
+		r.With(middleware.RoleMiddleware("Admin")).Get("/{id}", couponHandler.GetCouponByID)
+		r.With(middleware.RoleMiddleware("Admin")).Post("/", couponHandler.CreateCoupon)
+		r.With(middleware.RoleMiddleware("Admin")).Put("/{id}", couponHandler.UpdateCoupon)
+		r.With(middleware.RoleMiddleware("Admin")).Delete("/{id}", couponHandler.DeleteCoupon)
+	})
+}
+
+func (rt *Routes) SetupCustomerRoutes(customerHandler CustomerHandlerInterface) {
+	rt.r.Route("/customers", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		// Эндпоинты для роли Admin
+		r.With(middleware.RoleMiddleware("Admin")).Get("/", customerHandler.GetAllCustomers)
+		r.With(middleware.RoleMiddleware("Admin")).Get("/{id}", customerHandler.GetCustomerByID)
+		r.With(middleware.RoleMiddleware("Admin")).Get("/{id}/orders", customerHandler.GetCustomerOrders)
+		r.With(middleware.RoleMiddleware("Admin")).Post("/", customerHandler.CreateCustomer)
+		r.With(middleware.RoleMiddleware("Admin")).Put("/{id}", customerHandler.UpdateCustomer)
+		r.With(middleware.RoleMiddleware("Admin")).Delete("/{id}", customerHandler.DeleteCustomer)
+	})
+}
+
+func (rt *Routes) SetupExchangeRateRoutes(exchangeRateHandler ExchangeRateHandlerInterface) {
+	rt.r.Route("/exchange-rates", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		// Эндпоинты для роли Admin
+		r.With(middleware.RoleMiddleware("Admin")).Get("/", exchangeRateHandler.GetAllExchangeRates)
+		r.With(middleware.RoleMiddleware("Admin")).Put("/", exchangeRateHandler.SetExchangeRate)
+		r.With(middleware.RoleMiddleware("Admin")).Delete("/{from}/{to}", exchangeRateHandler.DeleteExchangeRate)
+	})
+}
+
+func (rt *Routes) SetupWebhookRoutes(webhookHandler WebhookHandlerInterface) {
+	rt.r.Route("/webhooks", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		// Эндпоинты для роли Admin
+		r.With(middleware.RoleMiddleware("Admin")).Get("/", webhookHandler.GetAllWebhooks)
+		r.With(middleware.RoleMiddleware("Admin")).Get("/{id}", webhookHandler.GetWebhookByID)
+		r.With(middleware.RoleMiddleware("Admin")).Get("/{id}/deliveries", webhookHandler.GetWebhookDeliveries)
+		r.With(middleware.RoleMiddleware("Admin")).Post("/", webhookHandler.CreateWebhook)
+		r.With(middleware.RoleMiddleware("Admin")).Post("/deliveries/{id}/replay", webhookHandler.ReplayWebhookDelivery)
+		r.With(middleware.RoleMiddleware("Admin")).Put("/{id}", webhookHandler.UpdateWebhook)
+		r.With(middleware.RoleMiddleware("Admin")).Delete("/{id}", webhookHandler.DeleteWebhook)
+	})
+}
+
+func (rt *Routes) SetupAnalyticsRoutes(analyticsHandler AnalyticsHandlerInterface) {
+	rt.r.Route("/analytics", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		// Эндпоинты для роли Admin
+		r.With(middleware.RoleMiddleware("Admin")).Get("/revenue", analyticsHandler.GetRevenue)
+		r.With(middleware.RoleMiddleware("Admin")).Get("/orders-by-status", analyticsHandler.GetOrdersByStatus)
+		r.With(middleware.RoleMiddleware("Admin")).Get("/top-products", analyticsHandler.GetTopProducts)
+	})
+}
+
 func (rt *Routes) SetupAuthRoutes(authHandler AuthHandlerInterface) {
 	rt.r.Post("/register", authHandler.RegisterUser)
 	rt.r.Post("/login", authHandler.LoginUser)
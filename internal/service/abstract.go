@@ -1,6 +1,9 @@
 package service
 
-import "TestTask/internal/models"
+import (
+	"TestTask/internal/models"
+	"time"
+)
 
 type UserRepositoryInterface interface {
 	CreateUser(user *models.User) error
@@ -10,10 +13,19 @@ type UserRepositoryInterface interface {
 
 type OrderRepositoryInterface interface {
 	CreateOrder(order *models.Order) error
-	UpdateOrder(order *models.Order) error
+	UpdateOrder(order *models.Order, oldStatus string, change *models.OrderStatusChange) error
+	UpdateOrderStatus(change *models.OrderStatusChange) (int, error)
+	CancelOrder(change *models.OrderStatusChange, cancellation *models.OrderCancellation) (int, error)
 	DeleteOrder(orderID int) error
+	RestoreOrder(orderID int) error
+	PurgeDeletedOrders(before time.Time) (int, error)
+	CancelStalePendingOrders(createdBefore, cancelledAt time.Time, limit int) ([]int, error)
 	GetOrderByID(orderID int) (*models.Order, error)
-	GetOrdersByFilters(status string, minPrice, maxPrice float64) ([]models.Order, error)
+	GetOrderStatusHistory(orderID int) ([]models.OrderStatusChange, error)
+	CreateOrderNote(note *models.OrderNote) error
+	GetOrderNotes(orderID int, includeInternal bool) ([]models.OrderNote, error)
+	GetOrdersByFilters(filter models.OrderFilter) (*models.OrderPage, error)
+	StreamOrdersByFilters(filter models.OrderFilter, fn func(order *models.Order) error) error
 }
 
 type ProductRepositoryInterface interface {
@@ -22,14 +34,63 @@ type ProductRepositoryInterface interface {
 	DeleteProductByID(productID int) error
 	GetProductByID(productID int) (*models.Product, error)
 	GetAllProducts() ([]models.Product, error)
+	ImportProducts(rows []models.ProductImportRow) error
+}
+
+type CustomerRepositoryInterface interface {
+	CreateCustomer(customer *models.Customer) error
+	UpdateCustomer(customer *models.Customer) error
+	DeleteCustomer(customerID int) error
+	GetCustomerByID(customerID int) (*models.Customer, error)
+	GetAllCustomers() ([]models.Customer, error)
+	FindOrCreateCustomerByName(name string) (*models.Customer, error)
+}
+
+type CouponRepositoryInterface interface {
+	CreateCoupon(coupon *models.Coupon) error
+	UpdateCoupon(coupon *models.Coupon) error
+	DeleteCoupon(couponID int) error
+	GetCouponByID(couponID int) (*models.Coupon, error)
+	GetCouponByCode(code string) (*models.Coupon, error)
+	GetAllCoupons() ([]models.Coupon, error)
+}
+
+type TaxRuleRepositoryInterface interface {
+	GetTaxRules(region string) ([]models.TaxRule, error)
+}
+
+type ExchangeRateRepositoryInterface interface {
+	SaveExchangeRates(rates []models.ExchangeRate) error
+	DeleteExchangeRate(fromCurrency, toCurrency string) error
+	GetExchangeRate(fromCurrency, toCurrency string) (*models.ExchangeRate, error)
+	GetAllExchangeRates() ([]models.ExchangeRate, error)
+	AssignBaseCurrency(baseCurrency string) (int, error)
 }
 
 type CacheInterface interface {
 	SetOrder(orderID int, order *models.Order)
 	GetOrder(orderID int) (*models.Order, bool)
 	DeleteOrder(orderID int)
-	SetOrders(key string, orders []models.Order)
-	GetOrders(key string) ([]models.Order, bool)
+	SetOrders(key string, page *models.OrderPage)
+	GetOrders(key string) (*models.OrderPage, bool)
+}
+
+type AnalyticsCacheInterface interface {
+	SetAnalytics(key string, report interface{}, ttl time.Duration)
+	GetAnalytics(key string) (interface{}, bool)
+}
+
+type AnalyticsRepositoryInterface interface {
+	GetRevenue(filter models.AnalyticsFilter) ([]models.RevenuePoint, error)
+	GetOrdersByStatus(filter models.AnalyticsFilter) ([]models.OrderStatusStats, error)
+	GetTopProducts(filter models.AnalyticsFilter) ([]models.ProductSales, error)
+}
+
+type IdempotencyRepositoryInterface interface {
+	ReserveIdempotencyKey(record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	SaveIdempotencyResponse(userID int, key string, status int, headers map[string]string, body []byte) error
+	DeleteIdempotencyKey(userID int, key string) error
+	DeleteExpiredIdempotencyKeys(now time.Time) (int, error)
 }
 
 type LogRepository interface {
@@ -41,6 +102,26 @@ type ProducerInterface interface {
 	Close() error
 }
 
+// WebhookNotifierInterface отправляет события заказов подписчикам вебхуков
+type WebhookNotifierInterface interface {
+	Notify(eventType string, data interface{})
+}
+
+type WebhookRepositoryInterface interface {
+	CreateWebhook(webhook *models.Webhook) error
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(webhookID int) error
+	GetWebhookByID(webhookID int) (*models.Webhook, error)
+	GetAllWebhooks() ([]models.Webhook, error)
+	GetActiveWebhooksByEvent(eventType string) ([]models.Webhook, error)
+	CreateWebhookDelivery(delivery *models.WebhookDelivery) error
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) error
+	GetWebhookDeliveryByID(deliveryID int) (*models.WebhookDelivery, error)
+	GetWebhookDeliveries(webhookID int) ([]models.WebhookDelivery, error)
+	ClaimStalePendingDeliveries(staleBefore time.Time, limit int) ([]models.WebhookDelivery, error)
+}
+
 type EventServiceInterface interface {
 	PublishOrderStatusChanged(orderID int, oldStatus, newStatus string)
+	PublishOrderCancelled(orderID int, oldStatus string, cancellation *models.OrderCancellation)
 }
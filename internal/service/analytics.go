@@ -0,0 +1,118 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultAnalyticsInterval = models.AnalyticsIntervalDay
+	DefaultTopProductsLimit  = 10
+	MaxTopProductsLimit      = 100
+)
+
+// AnalyticsService строит отчеты по продажам. Отчеты кэшируются на cacheTTL: данные для дашбордов
+// могут отставать на это время, зато повторные запросы не нагружают базу данных агрегатами.
+type AnalyticsService struct {
+	repo         AnalyticsRepositoryInterface
+	cache        AnalyticsCacheInterface
+	baseCurrency string
+	cacheTTL     time.Duration
+}
+
+func NewAnalyticsService(repo AnalyticsRepositoryInterface, cache AnalyticsCacheInterface, baseCurrency string, cacheTTL time.Duration) *AnalyticsService {
+	return &AnalyticsService{repo: repo, cache: cache, baseCurrency: baseCurrency, cacheTTL: cacheTTL}
+}
+
+// GetRevenue возвращает выручку в базовой валюте по интервалам. Пустой интервал заменяется на day.
+func (s *AnalyticsService) GetRevenue(filter models.AnalyticsFilter) (*models.RevenueReport, error) {
+	if filter.Interval == "" {
+		filter.Interval = DefaultAnalyticsInterval
+	}
+	switch filter.Interval {
+	case models.AnalyticsIntervalDay, models.AnalyticsIntervalWeek, models.AnalyticsIntervalMonth:
+	default:
+		return nil, fmt.Errorf("%w: interval must be %s, %s or %s", models.ErrInvalidFilter,
+			models.AnalyticsIntervalDay, models.AnalyticsIntervalWeek, models.AnalyticsIntervalMonth)
+	}
+	if err := validateAnalyticsFilter(filter); err != nil {
+		return nil, err
+	}
+
+	cacheKey := analyticsCacheKey("revenue", filter)
+	if cached, found := s.cache.GetAnalytics(cacheKey); found {
+		return cached.(*models.RevenueReport), nil
+	}
+
+	points, err := s.repo.GetRevenue(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RevenueReport{Interval: filter.Interval, Currency: s.baseCurrency, Points: points}
+	s.cache.SetAnalytics(cacheKey, report, s.cacheTTL)
+	return report, nil
+}
+
+// GetOrdersByStatus возвращает количество и сумму заказов в базовой валюте по статусам
+func (s *AnalyticsService) GetOrdersByStatus(filter models.AnalyticsFilter) ([]models.OrderStatusStats, error) {
+	filter.Interval = ""
+	filter.Limit = 0
+	if err := validateAnalyticsFilter(filter); err != nil {
+		return nil, err
+	}
+
+	cacheKey := analyticsCacheKey("orders_by_status", filter)
+	if cached, found := s.cache.GetAnalytics(cacheKey); found {
+		return cached.([]models.OrderStatusStats), nil
+	}
+
+	stats, err := s.repo.GetOrdersByStatus(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.SetAnalytics(cacheKey, stats, s.cacheTTL)
+	return stats, nil
+}
+
+// GetTopProducts возвращает продукты с наибольшей выручкой. Пустой лимит заменяется значением по умолчанию.
+func (s *AnalyticsService) GetTopProducts(filter models.AnalyticsFilter) ([]models.ProductSales, error) {
+	filter.Interval = ""
+	if filter.Limit == 0 {
+		filter.Limit = DefaultTopProductsLimit
+	}
+	if filter.Limit < 0 || filter.Limit > MaxTopProductsLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrInvalidFilter, MaxTopProductsLimit)
+	}
+	if err := validateAnalyticsFilter(filter); err != nil {
+		return nil, err
+	}
+
+	cacheKey := analyticsCacheKey("top_products", filter)
+	if cached, found := s.cache.GetAnalytics(cacheKey); found {
+		return cached.([]models.ProductSales), nil
+	}
+
+	products, err := s.repo.GetTopProducts(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.SetAnalytics(cacheKey, products, s.cacheTTL)
+	return products, nil
+}
+
+func validateAnalyticsFilter(filter models.AnalyticsFilter) error {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return fmt.Errorf("%w: from must not be after to", models.ErrInvalidFilter)
+	}
+	return nil
+}
+
+// analyticsCacheKey возвращает ключ кэша отчета report с параметрами filter
+func analyticsCacheKey(report string, filter models.AnalyticsFilter) string {
+	return fmt.Sprintf("%s_%s_%s_%s_%d",
+		report, formatFilterTime(filter.From), formatFilterTime(filter.To), filter.Interval, filter.Limit)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"fmt"
+	"strings"
+)
+
+// MaxCouponCodeLength максимальная длина кода купона
+const MaxCouponCodeLength = 64
+
+type CouponService struct {
+	repo CouponRepositoryInterface
+}
+
+func NewCouponService(repo CouponRepositoryInterface) *CouponService {
+	return &CouponService{repo: repo}
+}
+
+func (s *CouponService) CreateCoupon(coupon *models.Coupon) error {
+	if err := validateCoupon(coupon); err != nil {
+		return err
+	}
+
+	return s.repo.CreateCoupon(coupon)
+}
+
+func (s *CouponService) UpdateCoupon(coupon *models.Coupon) error {
+	if err := validateCoupon(coupon); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateCoupon(coupon)
+}
+
+func (s *CouponService) DeleteCoupon(couponID int) error {
+	return s.repo.DeleteCoupon(couponID)
+}
+
+func (s *CouponService) GetCouponByID(couponID int) (*models.Coupon, error) {
+	return s.repo.GetCouponByID(couponID)
+}
+
+func (s *CouponService) GetAllCoupons() ([]models.Coupon, error) {
+	return s.repo.GetAllCoupons()
+}
+
+// validateCoupon проверяет условия купона перед сохранением и приводит код к верхнему регистру
+func validateCoupon(coupon *models.Coupon) error {
+	coupon.Code = strings.ToUpper(strings.TrimSpace(coupon.Code))
+	if coupon.Code == "" || len(coupon.Code) > MaxCouponCodeLength {
+		return fmt.Errorf("%w: code must contain 1 to %d characters", models.ErrInvalidCouponData, MaxCouponCodeLength)
+	}
+
+	switch coupon.DiscountType {
+	case models.DiscountTypePercentage:
+		if coupon.DiscountValue <= 0 || coupon.DiscountValue > 100*models.MoneyScale {
+			return fmt.Errorf("%w: percentage discount must be greater than 0 and at most 100", models.ErrInvalidCouponData)
+		}
+	case models.DiscountTypeFixed:
+		if coupon.DiscountValue <= 0 {
+			return fmt.Errorf("%w: fixed discount must be greater than 0", models.ErrInvalidCouponData)
+		}
+	default:
+		return fmt.Errorf("%w: discount type must be %s or %s", models.ErrInvalidCouponData, models.DiscountTypePercentage, models.DiscountTypeFixed)
+	}
+
+	if coupon.MinOrderValue < 0 || coupon.MaxUses < 0 || coupon.MaxUsesPerUser < 0 {
+		return fmt.Errorf("%w: minimum order value and usage limits must not be negative", models.ErrInvalidCouponData)
+	}
+	if coupon.ValidFrom != nil && coupon.ValidTo != nil && coupon.ValidTo.Before(*coupon.ValidFrom) {
+		return fmt.Errorf("%w: valid_to must not be before valid_from", models.ErrInvalidCouponData)
+	}
+	return nil
+}
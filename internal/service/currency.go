@@ -0,0 +1,95 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// CurrencyConverter переводит суммы между валютами по курсам из таблицы exchange_rates
+type CurrencyConverter struct {
+	repo         ExchangeRateRepositoryInterface
+	baseCurrency string
+}
+
+func NewCurrencyConverter(repo ExchangeRateRepositoryInterface, baseCurrency string) *CurrencyConverter {
+	return &CurrencyConverter{repo: repo, baseCurrency: baseCurrency}
+}
+
+// BaseCurrency возвращает валюту, в которой ведется отчетность
+func (c *CurrencyConverter) BaseCurrency() string {
+	return c.baseCurrency
+}
+
+// AssignBaseCurrency записывает базовую валюту в продукты и заказы без валюты, созданные до появления валют.
+// Вызывается при запуске, до обработки запросов. Возвращает количество обновленных записей.
+func (c *CurrencyConverter) AssignBaseCurrency() (int, error) {
+	return c.repo.AssignBaseCurrency(c.baseCurrency)
+}
+
+// Rate возвращает курс обмена from на to, округленный до models.RateScale знаков. Если курс пары не задан,
+// используется обратный курс, а для пары двух небазовых валют - кросс-курс через базовую валюту.
+func (c *CurrencyConverter) Rate(from, to string) (float64, error) {
+	rate, err := c.exactRate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return models.RoundRate(rate), nil
+}
+
+// Convert переводит сумму из валюты from в валюту to. Обратный и кросс-курс не округляются,
+// сумма округляется до цента один раз.
+func (c *CurrencyConverter) Convert(amount models.Money, from, to string) (models.Money, error) {
+	rate, err := c.exactRate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return amount.MulRat(rate), nil
+}
+
+// exactRate рассчитывает курс обмена from на to без округления
+func (c *CurrencyConverter) exactRate(from, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	rate, err := c.pairRate(from, to)
+	if !errors.Is(err, models.ErrExchangeRateNotFound) || from == c.baseCurrency || to == c.baseCurrency {
+		return rate, err
+	}
+
+	toBase, err := c.pairRate(from, c.baseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	fromBase, err := c.pairRate(c.baseCurrency, to)
+	if err != nil {
+		return nil, err
+	}
+	return toBase.Mul(toBase, fromBase), nil
+}
+
+// pairRate ищет прямой курс пары, затем обратный
+func (c *CurrencyConverter) pairRate(from, to string) (*big.Rat, error) {
+	rate, err := c.repo.GetExchangeRate(from, to)
+	if err == nil {
+		return models.DecimalRate(rate.Rate), nil
+	}
+	if !errors.Is(err, models.ErrExchangeRateNotFound) {
+		return nil, err
+	}
+
+	inverse, inverseErr := c.repo.GetExchangeRate(to, from)
+	if inverseErr != nil {
+		if errors.Is(inverseErr, models.ErrExchangeRateNotFound) {
+			return nil, err
+		}
+		return nil, inverseErr
+	}
+	inverseRate := models.DecimalRate(inverse.Rate)
+	if inverseRate.Sign() == 0 {
+		return nil, fmt.Errorf("%w: zero rate %s/%s", models.ErrInvalidExchangeRate, to, from)
+	}
+	return inverseRate.Inv(inverseRate), nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+const (
+	// MaxCustomerNameLength максимальная длина имени клиента
+	MaxCustomerNameLength = 255
+	// MaxCustomerPhoneLength максимальная длина телефона клиента
+	MaxCustomerPhoneLength = 50
+	// MaxCustomerAddresses максимальное количество адресов клиента
+	MaxCustomerAddresses = 20
+)
+
+type CustomerService struct {
+	repo CustomerRepositoryInterface
+}
+
+func NewCustomerService(repo CustomerRepositoryInterface) *CustomerService {
+	return &CustomerService{repo: repo}
+}
+
+func (s *CustomerService) CreateCustomer(customer *models.Customer) error {
+	if err := validateCustomer(customer); err != nil {
+		return err
+	}
+
+	return s.repo.CreateCustomer(customer)
+}
+
+func (s *CustomerService) UpdateCustomer(customer *models.Customer) error {
+	if err := validateCustomer(customer); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateCustomer(customer)
+}
+
+func (s *CustomerService) DeleteCustomer(customerID int) error {
+	return s.repo.DeleteCustomer(customerID)
+}
+
+func (s *CustomerService) GetCustomerByID(customerID int) (*models.Customer, error) {
+	return s.repo.GetCustomerByID(customerID)
+}
+
+func (s *CustomerService) GetAllCustomers() ([]models.Customer, error) {
+	return s.repo.GetAllCustomers()
+}
+
+// validateCustomer проверяет данные клиента перед сохранением, убирает пробелы по краям и приводит email к нижнему регистру
+func validateCustomer(customer *models.Customer) error {
+	customer.Name = strings.TrimSpace(customer.Name)
+	if customer.Name == "" || len([]rune(customer.Name)) > MaxCustomerNameLength {
+		return fmt.Errorf("%w: name must contain 1 to %d characters", models.ErrInvalidCustomerData, MaxCustomerNameLength)
+	}
+
+	customer.Email = strings.ToLower(strings.TrimSpace(customer.Email))
+	if customer.Email != "" {
+		address, err := mail.ParseAddress(customer.Email)
+		if err != nil || address.Address != customer.Email {
+			return fmt.Errorf("%w: invalid email %q", models.ErrInvalidCustomerData, customer.Email)
+		}
+	}
+
+	customer.Phone = strings.TrimSpace(customer.Phone)
+	if len(customer.Phone) > MaxCustomerPhoneLength {
+		return fmt.Errorf("%w: phone must be at most %d characters", models.ErrInvalidCustomerData, MaxCustomerPhoneLength)
+	}
+
+	if len(customer.Addresses) > MaxCustomerAddresses {
+		return fmt.Errorf("%w: customer can have at most %d addresses", models.ErrInvalidCustomerData, MaxCustomerAddresses)
+	}
+	if customer.Addresses == nil {
+		customer.Addresses = []models.Address{}
+	}
+	for i := range customer.Addresses {
+		address := &customer.Addresses[i]
+		address.Line1 = strings.TrimSpace(address.Line1)
+		address.Line2 = strings.TrimSpace(address.Line2)
+		address.City = strings.TrimSpace(address.City)
+		address.PostalCode = strings.TrimSpace(address.PostalCode)
+		address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+		if address.Line1 == "" || address.City == "" || address.Country == "" {
+			return fmt.Errorf("%w: address %d must have line1, city and country", models.ErrInvalidCustomerData, i+1)
+		}
+	}
+	return nil
+}
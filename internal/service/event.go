@@ -1,25 +1,63 @@
 package service
 
 import (
+	"TestTask/internal/models"
 	"encoding/json"
 	"log"
+	"time"
 )
 
+// Типы событий о заказах для подписчиков вебхуков. В сообщение Kafka тип события не записывается:
+// каждая смена статуса, в том числе отмена, публикуется одним сообщением в прежнем формате.
+const (
+	EventOrderStatusChanged = "order_status_changed"
+	EventOrderCancelled     = "order_cancelled"
+)
+
+// EventService публикует события о заказах в Kafka и отправляет их подписчикам вебхуков
 type EventService struct {
 	producer ProducerInterface
+	webhooks WebhookNotifierInterface
 }
 
-func NewEventService(producer ProducerInterface) *EventService {
-	return &EventService{producer: producer}
+func NewEventService(producer ProducerInterface, webhooks WebhookNotifierInterface) *EventService {
+	return &EventService{producer: producer, webhooks: webhooks}
 }
 
 func (e *EventService) PublishOrderStatusChanged(orderID int, oldStatus, newStatus string) {
-	event := map[string]interface{}{
+	event := statusChangedEvent(orderID, oldStatus, newStatus)
+
+	e.webhooks.Notify(EventOrderStatusChanged, event)
+	e.publish(event)
+}
+
+// PublishOrderCancelled публикует смену статуса заказа на cancelled с причиной отмены. В Kafka уходит одно
+// сообщение о смене статуса с дополнительными полями причины, подписчики вебхуков получают и событие
+// о смене статуса, и событие об отмене. Вместе с ним PublishOrderStatusChanged не вызывается.
+func (e *EventService) PublishOrderCancelled(orderID int, oldStatus string, cancellation *models.OrderCancellation) {
+	e.webhooks.Notify(EventOrderStatusChanged, statusChangedEvent(orderID, oldStatus, models.OrderStatusCancelled))
+
+	event := statusChangedEvent(orderID, oldStatus, models.OrderStatusCancelled)
+	event["reason_code"] = cancellation.ReasonCode
+	event["note"] = cancellation.Note
+	event["cancelled_by"] = cancellation.CancelledBy
+	event["cancelled_at"] = cancellation.CancelledAt.Format(time.RFC3339)
+
+	e.webhooks.Notify(EventOrderCancelled, event)
+	e.publish(event)
+}
+
+// statusChangedEvent данные события о смене статуса заказа
+func statusChangedEvent(orderID int, oldStatus, newStatus string) map[string]interface{} {
+	return map[string]interface{}{
 		"order_id":   orderID,
 		"old_status": oldStatus,
 		"new_status": newStatus,
 	}
+}
 
+// publish публикует событие в Kafka
+func (e *EventService) publish(event map[string]interface{}) {
 	message, err := json.Marshal(event)
 	if err != nil {
 		log.Printf("Failed to marshal event: %v\n", err)
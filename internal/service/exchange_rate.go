@@ -0,0 +1,123 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// exchangeRateColumns колонки CSV-файла курсов в обязательном порядке
+var exchangeRateColumns = []string{"from_currency", "to_currency", "rate"}
+
+type ExchangeRateService struct {
+	repo ExchangeRateRepositoryInterface
+}
+
+func NewExchangeRateService(repo ExchangeRateRepositoryInterface) *ExchangeRateService {
+	return &ExchangeRateService{repo: repo}
+}
+
+// SetExchangeRate создает курс пары валют или обновляет существующий
+func (s *ExchangeRateService) SetExchangeRate(rate *models.ExchangeRate) error {
+	if err := validateExchangeRate(rate); err != nil {
+		return err
+	}
+
+	rates := []models.ExchangeRate{*rate}
+	err := s.repo.SaveExchangeRates(rates)
+	if err != nil {
+		return err
+	}
+
+	*rate = rates[0]
+	return nil
+}
+
+func (s *ExchangeRateService) DeleteExchangeRate(fromCurrency, toCurrency string) error {
+	return s.repo.DeleteExchangeRate(strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency))
+}
+
+func (s *ExchangeRateService) GetAllExchangeRates() ([]models.ExchangeRate, error) {
+	return s.repo.GetAllExchangeRates()
+}
+
+// LoadExchangeRatesFile загружает курсы из CSV-файла, см. LoadExchangeRates
+func (s *ExchangeRateService) LoadExchangeRatesFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not open exchange rates file: %w", err)
+	}
+	defer file.Close()
+
+	return s.LoadExchangeRates(file)
+}
+
+// LoadExchangeRates загружает курсы из CSV с заголовком from_currency, to_currency, rate.
+// Курсы сохраняются, только если все строки файла валидны. Возвращает количество загруженных курсов.
+func (s *ExchangeRateService) LoadExchangeRates(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = 3
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("%w: could not read CSV: %v", models.ErrInvalidExchangeRate, err)
+	}
+	if len(records) == 0 {
+		return 0, fmt.Errorf("%w: missing CSV header", models.ErrInvalidExchangeRate)
+	}
+	for i, name := range exchangeRateColumns {
+		if strings.ToLower(strings.TrimSpace(records[0][i])) != name {
+			return 0, fmt.Errorf("%w: CSV header must be %s", models.ErrInvalidExchangeRate, strings.Join(exchangeRateColumns, ","))
+		}
+	}
+
+	rates := make([]models.ExchangeRate, 0, len(records)-1)
+	for i, record := range records[1:] {
+		rate := models.ExchangeRate{FromCurrency: record[0], ToCurrency: record[1]}
+
+		// Строка 1 - заголовок
+		row := i + 2
+
+		rate.Rate, err = strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: row %d: invalid rate %q", models.ErrInvalidExchangeRate, row, record[2])
+		}
+		if err = validateExchangeRate(&rate); err != nil {
+			return 0, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		return 0, nil
+	}
+
+	err = s.repo.SaveExchangeRates(rates)
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// validateExchangeRate приводит коды валют к верхнему регистру и проверяет курс
+func validateExchangeRate(rate *models.ExchangeRate) error {
+	rate.FromCurrency = strings.ToUpper(strings.TrimSpace(rate.FromCurrency))
+	rate.ToCurrency = strings.ToUpper(strings.TrimSpace(rate.ToCurrency))
+
+	if !models.IsSupportedCurrency(rate.FromCurrency) || !models.IsSupportedCurrency(rate.ToCurrency) {
+		return fmt.Errorf("%w: unsupported currency pair %s/%s", models.ErrInvalidExchangeRate, rate.FromCurrency, rate.ToCurrency)
+	}
+	if rate.FromCurrency == rate.ToCurrency {
+		return fmt.Errorf("%w: currencies of the pair must differ", models.ErrInvalidExchangeRate)
+	}
+	if rate.Rate <= 0 {
+		return fmt.Errorf("%w: rate must be greater than 0", models.ErrInvalidExchangeRate)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// MaxIdempotencyKeyLength максимальная длина значения заголовка Idempotency-Key
+const MaxIdempotencyKeyLength = 255
+
+type IdempotencyService struct {
+	repo IdempotencyRepositoryInterface
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(repo IdempotencyRepositoryInterface, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// Begin резервирует ключ за запросом пользователя. Если ключ уже использовался с тем же запросом
+// и ответ сохранен, возвращается сохраненная запись, которую нужно отдать клиенту повторно.
+// nil означает, что запрос нужно выполнить и затем вызвать Complete или Release.
+func (s *IdempotencyService) Begin(userID int, key, requestHash string) (*models.IdempotencyRecord, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: key must be 1 to %d characters long", models.ErrInvalidIdempotencyKey, MaxIdempotencyKeyLength)
+	}
+
+	now := time.Now()
+	record := &models.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	existing, err := s.repo.ReserveIdempotencyKey(record)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, models.ErrIdempotencyKeyReused
+	}
+	if existing.ResponseStatus == 0 {
+		return nil, models.ErrIdempotencyKeyInUse
+	}
+
+	return existing, nil
+}
+
+// Complete сохраняет ответ на запрос для повторной отдачи до истечения срока действия ключа
+func (s *IdempotencyService) Complete(userID int, key string, status int, headers map[string]string, body []byte) error {
+	return s.repo.SaveIdempotencyResponse(userID, key, status, headers, body)
+}
+
+// PurgeExpired удаляет просроченные ключи и возвращает их количество
+func (s *IdempotencyService) PurgeExpired() (int, error) {
+	return s.repo.DeleteExpiredIdempotencyKeys(time.Now())
+}
+
+// RunPurgeExpired раз в interval удаляет просроченные ключи, пока не будет отменен ctx
+func (s *IdempotencyService) RunPurgeExpired(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		log.Println("Purging of expired idempotency keys is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpired()
+			if err != nil {
+				log.Printf("Failed to purge expired idempotency keys: %v\n", err)
+			}
+			if purged > 0 {
+				log.Printf("Purged %d expired idempotency keys\n", purged)
+			}
+		}
+	}
+}
+
+// Release освобождает ключ запроса, завершившегося ошибкой, чтобы клиент мог его повторить
+func (s *IdempotencyService) Release(userID int, key string) error {
+	return s.repo.DeleteIdempotencyKey(userID, key)
+}
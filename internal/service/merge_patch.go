@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// errInvalidMergePatch возвращается, если документ не является JSON-объектом
+var errInvalidMergePatch = errors.New("merge patch must be a JSON object")
+
+// applyMergePatch применяет к target документ JSON Merge Patch (RFC 7396).
+// target должен быть указателем на структуру, которую можно сериализовать в JSON-объект.
+func applyMergePatch(target interface{}, patch []byte) error {
+	var patchDoc map[string]interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil || patchDoc == nil {
+		return errInvalidMergePatch
+	}
+
+	original, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+
+	var targetDoc map[string]interface{}
+	if err := json.Unmarshal(original, &targetDoc); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(mergePatch(targetDoc, patchDoc))
+	if err != nil {
+		return err
+	}
+
+	// Поля, удаленные патчем, должны получить нулевые значения, поэтому target предварительно обнуляется
+	value := reflect.ValueOf(target).Elem()
+	value.Set(reflect.Zero(value.Type()))
+
+	return json.Unmarshal(merged, target)
+}
+
+// mergePatch рекурсивно применяет patch к target: значение null удаляет поле,
+// вложенные объекты объединяются, любые другие значения заменяют исходные.
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
@@ -2,30 +2,105 @@ package service
 
 import (
 	"TestTask/internal/models"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
+const (
+	DefaultOrdersSort  = "-created_at"
+	DefaultOrdersLimit = 20
+	MaxOrdersLimit     = 100
+	MaxBulkOrders      = 500
+	// MaxCancellationNoteLength максимальная длина комментария к отмене заказа
+	MaxCancellationNoteLength = 1000
+	// MaxRegionLength максимальная длина кода региона заказа
+	MaxRegionLength = 50
+	// MaxOrderNoteLength максимальная длина заметки к заказу
+	MaxOrderNoteLength = 2000
+)
+
 type OrderService struct {
 	repo         OrderRepositoryInterface
+	productRepo  ProductRepositoryInterface
+	customerRepo CustomerRepositoryInterface
+	couponRepo   CouponRepositoryInterface
+	taxes        *TaxCalculator
+	currencies   *CurrencyConverter
 	cache        CacheInterface
 	eventService EventServiceInterface
+	// deletedRetention сколько хранятся "мягко" удаленные заказы до окончательной очистки
+	deletedRetention time.Duration
+	// pendingTTL через сколько после создания неподтвержденный заказ отменяется автоматически
+	pendingTTL time.Duration
 }
 
-func NewOrderService(repo OrderRepositoryInterface, cache CacheInterface, eventService EventServiceInterface) *OrderService {
+func NewOrderService(repo OrderRepositoryInterface, productRepo ProductRepositoryInterface, customerRepo CustomerRepositoryInterface, couponRepo CouponRepositoryInterface, taxes *TaxCalculator, currencies *CurrencyConverter, cache CacheInterface, eventService EventServiceInterface, deletedRetention, pendingTTL time.Duration) *OrderService {
 	return &OrderService{
-		repo:         repo,
-		cache:        cache,
-		eventService: eventService,
+		repo:             repo,
+		productRepo:      productRepo,
+		customerRepo:     customerRepo,
+		couponRepo:       couponRepo,
+		taxes:            taxes,
+		currencies:       currencies,
+		cache:            cache,
+		eventService:     eventService,
+		deletedRetention: deletedRetention,
+		pendingTTL:       pendingTTL,
 	}
 }
 
+// CreateOrder создает заказ. Цены позиций, подытог, налог и итоговая сумма рассчитываются по каталогу продуктов
+// и налоговым правилам региона заказа в валюте заказа, значения, присланные клиентом, игнорируются.
+// Заказ всегда привязывается к клиенту из справочника: если customer_id не указан, клиент ищется
+// по имени без учета регистра и пробелов по краям и создается, если не найден.
 func (s *OrderService) CreateOrder(order *models.Order) error {
-	if order.CustomerName == "" || order.TotalPrice <= 0 || order.ProductID <= 0 {
-		return fmt.Errorf("invalid order data")
+	if (order.CustomerName == "" && order.CustomerID == 0) || len(order.Items) == 0 {
+		return models.ErrInvalidOrderData
+	}
+
+	for _, item := range order.Items {
+		if item.ProductID <= 0 || item.Quantity <= 0 {
+			return models.ErrInvalidOrderData
+		}
+	}
+
+	err := s.resolveCustomer(order)
+	if err != nil {
+		return err
 	}
 
-	err := s.repo.CreateOrder(order)
+	order.CouponCode = strings.ToUpper(strings.TrimSpace(order.CouponCode))
+	order.Region = strings.ToUpper(strings.TrimSpace(order.Region))
+	order.Currency = strings.ToUpper(strings.TrimSpace(order.Currency))
+	if order.Currency == "" {
+		order.Currency = s.currencies.BaseCurrency()
+	}
+	if !models.IsSupportedCurrency(order.Currency) {
+		return fmt.Errorf("%w: unsupported currency %q", models.ErrInvalidOrderData, order.Currency)
+	}
+	if len(order.Region) > MaxRegionLength {
+		return fmt.Errorf("%w: region must be at most %d characters", models.ErrInvalidOrderData, MaxRegionLength)
+	}
+	order.DiscountAmount = 0
+
+	// Условия применения купона проверяются в репозитории под блокировкой купона, в одной транзакции
+	// со списанием использования
+	err = s.priceOrder(order)
+	if err != nil {
+		return err
+	}
+
+	// Новый заказ всегда начинает жизненный цикл со статуса pending
+	if order.Status == "" {
+		order.Status = models.OrderStatusPending
+	}
+	if order.Status != models.OrderStatusPending {
+		return fmt.Errorf("%w: new order must be in status %s", models.ErrInvalidOrderData, models.OrderStatusPending)
+	}
+
+	err = s.repo.CreateOrder(order)
 	if err != nil {
 		return err
 	}
@@ -33,22 +108,116 @@ func (s *OrderService) CreateOrder(order *models.Order) error {
 	return nil
 }
 
-func (s *OrderService) UpdateOrder(order *models.Order) error {
-	if order.CustomerName == "" || order.TotalPrice <= 0 {
-		return fmt.Errorf("invalid order data")
+// BulkCreateOrders создает заказы по одному, с той же валидацией и резервированием товара, что и CreateOrder.
+// Ошибка одного заказа не отменяет остальные: результат каждого заказа возвращается отдельно.
+func (s *OrderService) BulkCreateOrders(orders []models.Order) ([]models.BulkOrderResult, error) {
+	if len(orders) == 0 || len(orders) > MaxBulkOrders {
+		return nil, fmt.Errorf("%w: batch must contain 1 to %d orders", models.ErrInvalidOrderData, MaxBulkOrders)
+	}
+
+	results := make([]models.BulkOrderResult, len(orders))
+	for i := range orders {
+		order := &orders[i]
+		results[i].Index = i
+
+		err := s.CreateOrder(order)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].OrderID = order.ID
+		results[i].Order = order
+	}
+
+	return results, nil
+}
+
+// BulkTransitionOrders переводит заказы в новые статусы через TransitionOrder. Каждое изменение
+// выполняется в собственной транзакции, событие о смене статуса публикуется для каждого измененного заказа.
+func (s *OrderService) BulkTransitionOrders(updates []models.OrderStatusUpdate, actor models.Actor) ([]models.BulkOrderResult, error) {
+	if len(updates) == 0 || len(updates) > MaxBulkOrders {
+		return nil, fmt.Errorf("%w: batch must contain 1 to %d status updates", models.ErrInvalidOrderData, MaxBulkOrders)
+	}
+
+	results := make([]models.BulkOrderResult, len(updates))
+	for i, update := range updates {
+		results[i].Index = i
+		results[i].OrderID = update.OrderID
+
+		order, err := s.TransitionOrder(update.OrderID, update.Status, actor, update.Reason)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Order = order
+	}
+
+	return results, nil
+}
+
+// UpdateOrder обновляет данные заказа. Позиции, регион, валюта, суммы, налог и скидка не меняются:
+// они зафиксированы в момент создания заказа. Если order.Version не равна нулю, заказ обновляется
+// только при совпадении версии, иначе возвращается ErrVersionConflict.
+func (s *OrderService) UpdateOrder(order *models.Order, actor models.Actor) error {
+	if (order.CustomerName == "" && order.CustomerID == 0) || !IsValidOrderStatus(order.Status) {
+		return models.ErrInvalidOrderData
+	}
+
+	existingOrder, err := s.getAccessibleOrder(order.ID, actor)
+	if err != nil {
+		return fmt.Errorf("failed to get existing order: %w", err)
 	}
 
-	existingOrder, err := s.repo.GetOrderByID(order.ID)
+	// Заказ клиента остается привязанным к нему, если в запросе не указан другой клиент
+	if order.CustomerID == 0 {
+		order.CustomerID = existingOrder.CustomerID
+	}
+	err = s.resolveCustomer(order)
 	if err != nil {
-		return fmt.Errorf("failed to get existing order: %v", err)
+		return err
 	}
 
+	order.UserID = existingOrder.UserID
+	order.TotalPrice = existingOrder.TotalPrice
+	order.Region = existingOrder.Region
+	order.Subtotal = existingOrder.Subtotal
+	order.TaxAmount = existingOrder.TaxAmount
+	order.Currency = existingOrder.Currency
+	order.ExchangeRate = existingOrder.ExchangeRate
+	order.BaseTotalPrice = existingOrder.BaseTotalPrice
+	order.CouponCode = existingOrder.CouponCode
+	order.DiscountAmount = existingOrder.DiscountAmount
+	order.Items = existingOrder.Items
+	order.CreatedAt = existingOrder.CreatedAt
+
 	oldStatus := existingOrder.Status
 
+	if oldStatus != order.Status && !CanTransitionOrderStatus(oldStatus, order.Status) {
+		return fmt.Errorf("%w: %s -> %s", models.ErrInvalidStatusTransition, oldStatus, order.Status)
+	}
+	if oldStatus != order.Status && order.Status == models.OrderStatusCancelled {
+		return cancelWithoutReasonError(oldStatus)
+	}
+
 	order.UpdatedAt = time.Now()
 
-	err = s.repo.UpdateOrder(order)
-	if err != nil {
+	var change *models.OrderStatusChange
+	if oldStatus != order.Status {
+		change = &models.OrderStatusChange{
+			OrderID:   order.ID,
+			OldStatus: oldStatus,
+			NewStatus: order.Status,
+			ChangedBy: actor.UserID,
+			CreatedAt: order.UpdatedAt,
+		}
+	}
+
+	err = s.repo.UpdateOrder(order, oldStatus, change)
+	if errors.Is(err, models.ErrVersionConflict) || errors.Is(err, models.ErrInvalidStatusTransition) {
+		// В кэше могла остаться устаревшая версия заказа
+		s.cache.DeleteOrder(order.ID)
+		return err
+	} else if err != nil {
 		return err
 	}
 
@@ -61,6 +230,189 @@ func (s *OrderService) UpdateOrder(order *models.Order) error {
 	return nil
 }
 
+// PatchOrder применяет к сохраненному заказу документ JSON Merge Patch и обновляет заказ так же,
+// как UpdateOrder: с проверкой версии, валидацией, историей статусов и событием о смене статуса
+func (s *OrderService) PatchOrder(orderID int, patch []byte, version int, actor models.Actor) (*models.Order, error) {
+	existingOrder, err := s.getAccessibleOrder(orderID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	order := *existingOrder
+	err = applyMergePatch(&order, patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidOrderData, err)
+	}
+	order.ID = orderID
+	order.Version = version
+
+	err = s.UpdateOrder(&order, actor)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// TransitionOrder переводит заказ в новый статус согласно графу переходов и записывает изменение в историю
+func (s *OrderService) TransitionOrder(orderID int, status string, actor models.Actor, reason string) (*models.Order, error) {
+	if !IsValidOrderStatus(status) {
+		return nil, models.ErrInvalidOrderData
+	}
+
+	order, err := s.getAccessibleOrder(orderID, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing order: %w", err)
+	}
+
+	oldStatus := order.Status
+
+	if !CanTransitionOrderStatus(oldStatus, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", models.ErrInvalidStatusTransition, oldStatus, status)
+	}
+	if status == models.OrderStatusCancelled {
+		return nil, cancelWithoutReasonError(oldStatus)
+	}
+
+	change := &models.OrderStatusChange{
+		OrderID:   orderID,
+		OldStatus: oldStatus,
+		NewStatus: status,
+		ChangedBy: actor.UserID,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+
+	version, err := s.repo.UpdateOrderStatus(change)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Status = status
+	order.UpdatedAt = change.CreatedAt
+	order.Version = version
+
+	s.cache.SetOrder(order.ID, order)
+	s.eventService.PublishOrderStatusChanged(order.ID, oldStatus, order.Status)
+
+	return order, nil
+}
+
+// CancelOrder отменяет заказ с указанием кода причины и комментария. Зарезервированный товар
+// возвращается на склад, публикуется событие об отмене с причиной.
+func (s *OrderService) CancelOrder(orderID int, reasonCode, note string, actor models.Actor) (*models.Order, error) {
+	if !IsValidCancellationReason(reasonCode) {
+		return nil, fmt.Errorf("%w: unknown cancellation reason %q", models.ErrInvalidOrderData, reasonCode)
+	}
+	if reasonCode == models.CancellationReasonOther && strings.TrimSpace(note) == "" {
+		return nil, fmt.Errorf("%w: note is required for cancellation reason %q", models.ErrInvalidOrderData, reasonCode)
+	}
+	if len([]rune(note)) > MaxCancellationNoteLength {
+		return nil, fmt.Errorf("%w: note must not exceed %d characters", models.ErrInvalidOrderData, MaxCancellationNoteLength)
+	}
+
+	order, err := s.getAccessibleOrder(orderID, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing order: %w", err)
+	}
+
+	oldStatus := order.Status
+
+	if !CanTransitionOrderStatus(oldStatus, models.OrderStatusCancelled) {
+		return nil, fmt.Errorf("%w: %s -> %s", models.ErrInvalidStatusTransition, oldStatus, models.OrderStatusCancelled)
+	}
+
+	now := time.Now()
+	change := &models.OrderStatusChange{
+		OrderID:   orderID,
+		OldStatus: oldStatus,
+		NewStatus: models.OrderStatusCancelled,
+		ChangedBy: actor.UserID,
+		Reason:    reasonCode,
+		CreatedAt: now,
+	}
+	cancellation := &models.OrderCancellation{
+		OrderID:     orderID,
+		ReasonCode:  reasonCode,
+		Note:        note,
+		CancelledBy: actor.UserID,
+		CancelledAt: now,
+	}
+
+	version, err := s.repo.CancelOrder(change, cancellation)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Status = models.OrderStatusCancelled
+	order.UpdatedAt = now
+	order.Version = version
+	order.Cancellation = cancellation
+
+	s.cache.SetOrder(order.ID, order)
+	s.eventService.PublishOrderCancelled(order.ID, oldStatus, cancellation)
+
+	return order, nil
+}
+
+// cancelWithoutReasonError ошибка отмены заказа в обход CancelOrder. Отмена всегда проходит через CancelOrder,
+// чтобы у каждого отмененного заказа была записана причина и было опубликовано событие об отмене.
+func cancelWithoutReasonError(oldStatus string) error {
+	return fmt.Errorf("%w: %s -> %s: orders are cancelled with a reason code through the cancel endpoint",
+		models.ErrInvalidStatusTransition, oldStatus, models.OrderStatusCancelled)
+}
+
+// GetOrderStatusHistory возвращает историю смены статусов существующего заказа
+func (s *OrderService) GetOrderStatusHistory(orderID int, actor models.Actor) ([]models.OrderStatusChange, error) {
+	_, err := s.getAccessibleOrder(orderID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetOrderStatusHistory(orderID)
+}
+
+// AddOrderNote добавляет заметку к заказу от имени actor. Внутренние заметки может оставлять только администратор,
+// пользователь с ролью User пишет видимые ему заметки к своим заказам.
+func (s *OrderService) AddOrderNote(orderID int, text string, internal bool, actor models.Actor) (*models.OrderNote, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("%w: note text is required", models.ErrInvalidOrderData)
+	}
+	if len([]rune(text)) > MaxOrderNoteLength {
+		return nil, fmt.Errorf("%w: note must not exceed %d characters", models.ErrInvalidOrderData, MaxOrderNoteLength)
+	}
+
+	_, err := s.getAccessibleOrder(orderID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if internal && !actor.IsAdmin() {
+		return nil, models.ErrOrderNoteForbidden
+	}
+
+	note := &models.OrderNote{
+		OrderID:    orderID,
+		AuthorID:   actor.UserID,
+		Text:       text,
+		IsInternal: internal,
+	}
+	if err := s.repo.CreateOrderNote(note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// GetOrderNotes возвращает заметки к заказу. Пользователю с ролью User возвращаются только видимые ему заметки.
+func (s *OrderService) GetOrderNotes(orderID int, actor models.Actor) ([]models.OrderNote, error) {
+	_, err := s.getAccessibleOrder(orderID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetOrderNotes(orderID, actor.IsAdmin())
+}
+
 func (s *OrderService) DeleteOrder(orderID int) error {
 	err := s.repo.DeleteOrder(orderID)
 	if err != nil {
@@ -71,9 +423,35 @@ func (s *OrderService) DeleteOrder(orderID int) error {
 	return nil
 }
 
-func (s *OrderService) GetOrderByID(orderID int) (*models.Order, error) {
+// RestoreOrder восстанавливает удаленный заказ
+func (s *OrderService) RestoreOrder(orderID int) error {
+	err := s.repo.RestoreOrder(orderID)
+	if err != nil {
+		return err
+	}
+
+	s.cache.DeleteOrder(orderID)
+	return nil
+}
+
+// PurgeDeletedOrders окончательно удаляет заказы, которые были удалены раньше, чем deletedRetention назад,
+// и возвращает их количество
+func (s *OrderService) PurgeDeletedOrders() (int, error) {
+	if s.deletedRetention <= 0 {
+		return 0, errors.New("retention period for deleted orders is not configured")
+	}
+
+	return s.repo.PurgeDeletedOrders(time.Now().Add(-s.deletedRetention))
+}
+
+// GetOrderByID возвращает заказ, если он доступен actor. Чужой заказ для пользователя с ролью User
+// неотличим от несуществующего.
+func (s *OrderService) GetOrderByID(orderID int, actor models.Actor) (*models.Order, error) {
 	cachedOrder, found := s.cache.GetOrder(orderID)
 	if found {
+		if !actor.CanAccessOrder(cachedOrder) {
+			return nil, fmt.Errorf("%w with id: %d", models.ErrOrderNotFound, orderID)
+		}
 		return cachedOrder, nil
 	}
 
@@ -83,22 +461,202 @@ func (s *OrderService) GetOrderByID(orderID int) (*models.Order, error) {
 	}
 
 	s.cache.SetOrder(orderID, order)
+	if !actor.CanAccessOrder(order) {
+		return nil, fmt.Errorf("%w with id: %d", models.ErrOrderNotFound, orderID)
+	}
 	return order, nil
 }
 
-func (s *OrderService) GetOrdersByFilters(status string, minPrice, maxPrice float64) ([]models.Order, error) {
-	cacheKey := fmt.Sprintf("%s_%f_%f", status, minPrice, maxPrice)
+// getAccessibleOrder читает заказ из репозитория в обход кэша и проверяет доступ к нему actor
+func (s *OrderService) getAccessibleOrder(orderID int, actor models.Actor) (*models.Order, error) {
+	order, err := s.repo.GetOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
 
-	cachedOrders, found := s.cache.GetOrders(cacheKey)
+	if !actor.CanAccessOrder(order) {
+		return nil, fmt.Errorf("%w with id: %d", models.ErrOrderNotFound, orderID)
+	}
+	return order, nil
+}
+
+// GetOrdersByFilters возвращает страницу заказов. Пустые параметры сортировки и лимита заменяются значениями по умолчанию.
+// Пользователю с ролью User возвращаются только его заказы.
+func (s *OrderService) GetOrdersByFilters(filter models.OrderFilter, actor models.Actor) (*models.OrderPage, error) {
+	if !actor.IsAdmin() {
+		filter.UserID = actor.UserID
+	}
+	if filter.Sort == "" {
+		filter.Sort = DefaultOrdersSort
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultOrdersLimit
+	}
+
+	err := validateOrderFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf(
+		"%s_%s_%s_%s_%s_%s_%d_%d_%s_%d_%t_%s_%d_%s",
+		strings.Join(filter.Statuses, ","), filter.MinPrice, filter.MaxPrice,
+		formatFilterTime(filter.CreatedFrom), formatFilterTime(filter.CreatedTo), formatFilterTime(filter.UpdatedSince),
+		filter.ProductID, filter.CustomerID, strings.ToLower(filter.CustomerName), filter.UserID, filter.Deleted, filter.Sort, filter.Limit, filter.Cursor,
+	)
+
+	cachedPage, found := s.cache.GetOrders(cacheKey)
 	if found {
-		return cachedOrders, nil
+		return cachedPage, nil
 	}
 
-	orders, err := s.repo.GetOrdersByFilters(status, minPrice, maxPrice)
+	page, err := s.repo.GetOrdersByFilters(filter)
 	if err != nil {
 		return nil, err
 	}
 
-	s.cache.SetOrders(cacheKey, orders)
-	return orders, nil
+	s.cache.SetOrders(cacheKey, page)
+	return page, nil
+}
+
+// ExportOrders передает в fn все заказы, подходящие под фильтр. Лимит и курсор фильтра не учитываются.
+func (s *OrderService) ExportOrders(filter models.OrderFilter, fn func(order *models.Order) error) error {
+	if filter.Sort == "" {
+		filter.Sort = DefaultOrdersSort
+	}
+	filter.Limit = 0
+	filter.Cursor = ""
+
+	err := validateOrderFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.StreamOrdersByFilters(filter, fn)
+}
+
+// validateOrderFilter проверяет допустимость и согласованность параметров фильтра
+func validateOrderFilter(filter models.OrderFilter) error {
+	if filter.Limit < 0 || filter.Limit > MaxOrdersLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", models.ErrInvalidFilter, MaxOrdersLimit)
+	}
+
+	for _, status := range filter.Statuses {
+		if !IsValidOrderStatus(status) {
+			return fmt.Errorf("%w: unknown status %q", models.ErrInvalidFilter, status)
+		}
+	}
+
+	if filter.MinPrice < 0 || filter.MaxPrice < 0 {
+		return fmt.Errorf("%w: price bounds must not be negative", models.ErrInvalidFilter)
+	}
+
+	if filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return fmt.Errorf("%w: min_price must not exceed max_price", models.ErrInvalidFilter)
+	}
+
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && filter.CreatedFrom.After(filter.CreatedTo) {
+		return fmt.Errorf("%w: created_from must not be after created_to", models.ErrInvalidFilter)
+	}
+
+	if filter.ProductID < 0 {
+		return fmt.Errorf("%w: product_id must be positive", models.ErrInvalidFilter)
+	}
+
+	if filter.CustomerID < 0 {
+		return fmt.Errorf("%w: customer_id must be positive", models.ErrInvalidFilter)
+	}
+
+	return nil
+}
+
+// formatFilterTime форматирует границу периода для ключа кэша
+func formatFilterTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// resolveCustomer проверяет, что клиент заказа существует, и записывает в заказ ID и имя клиента из справочника.
+// Если в заказе указано только имя, клиент находится или создается по имени.
+func (s *OrderService) resolveCustomer(order *models.Order) error {
+	if order.CustomerID == 0 {
+		name := strings.TrimSpace(order.CustomerName)
+		if name == "" || len([]rune(name)) > MaxCustomerNameLength {
+			return fmt.Errorf("%w: customer_name must contain 1 to %d characters", models.ErrInvalidOrderData, MaxCustomerNameLength)
+		}
+
+		customer, err := s.customerRepo.FindOrCreateCustomerByName(name)
+		if err != nil {
+			return fmt.Errorf("failed to find or create customer %q: %w", name, err)
+		}
+
+		order.CustomerID = customer.ID
+		order.CustomerName = customer.Name
+		return nil
+	}
+	if order.CustomerID < 0 {
+		return fmt.Errorf("%w: invalid customer_id", models.ErrInvalidOrderData)
+	}
+
+	customer, err := s.customerRepo.GetCustomerByID(order.CustomerID)
+	if errors.Is(err, models.ErrCustomerNotFound) {
+		return fmt.Errorf("%w: no customer found with id %d", models.ErrInvalidOrderData, order.CustomerID)
+	} else if err != nil {
+		return fmt.Errorf("failed to get customer %d: %w", order.CustomerID, err)
+	}
+
+	order.CustomerName = customer.Name
+	return nil
+}
+
+// priceOrder фиксирует в позициях текущие цены продуктов из каталога в валюте заказа, пересчитывает подытог,
+// налог, скидку по промокоду и итоговую сумму заказа и запоминает курс валюты заказа к базовой валюте
+func (s *OrderService) priceOrder(order *models.Order) error {
+	exchangeRate, err := s.currencies.Rate(order.Currency, s.currencies.BaseCurrency())
+	if err != nil {
+		return fmt.Errorf("failed to get exchange rate of order currency: %w", err)
+	}
+	order.ExchangeRate = exchangeRate
+
+	lines := make([]TaxLine, 0, len(order.Items))
+
+	for i := range order.Items {
+		item := &order.Items[i]
+
+		product, err := s.productRepo.GetProductByID(item.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to get product %d: %w", item.ProductID, err)
+		}
+		if product == nil {
+			return fmt.Errorf("%w: no product found with id %d", models.ErrInvalidOrderData, item.ProductID)
+		}
+
+		item.UnitPrice, err = s.currencies.Convert(product.Price, product.Currency, order.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to convert price of product %d: %w", item.ProductID, err)
+		}
+		lines = append(lines, TaxLine{Category: product.Category, Amount: item.UnitPrice.Mul(item.Quantity)})
+	}
+
+	subtotal, tax, err := s.taxes.Calculate(order.Region, lines)
+	if err != nil {
+		return err
+	}
+
+	order.Subtotal = subtotal
+	order.TaxAmount = tax
+	order.SetTotal(subtotal + tax)
+
+	if order.CouponCode != "" {
+		coupon, err := s.couponRepo.GetCouponByCode(order.CouponCode)
+		if errors.Is(err, models.ErrCouponNotFound) {
+			return fmt.Errorf("%w: unknown coupon %s", models.ErrCouponNotApplicable, order.CouponCode)
+		} else if err != nil {
+			return fmt.Errorf("failed to get coupon %s: %w", order.CouponCode, err)
+		}
+		order.ApplyDiscount(coupon.Discount(order.Subtotal, order.ExchangeRate))
+	}
+	return nil
 }
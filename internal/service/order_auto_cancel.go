@@ -0,0 +1,70 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"context"
+	"log"
+	"time"
+)
+
+// AutoCancelBatchSize сколько просроченных заказов отменяется в одной транзакции
+const AutoCancelBatchSize = 100
+
+// CancelStalePendingOrders отменяет заказы, которые находятся в статусе pending дольше pendingTTL,
+// удаляет их из кэша и публикует события об отмене. Возвращает количество отмененных заказов.
+// Если pendingTTL не задан, автоматическая отмена отключена.
+func (s *OrderService) CancelStalePendingOrders() (int, error) {
+	if s.pendingTTL <= 0 {
+		return 0, nil
+	}
+
+	cancelled := 0
+	for {
+		now := time.Now()
+		orderIDs, err := s.repo.CancelStalePendingOrders(now.Add(-s.pendingTTL), now, AutoCancelBatchSize)
+		if err != nil {
+			return cancelled, err
+		}
+
+		for _, orderID := range orderIDs {
+			s.cache.DeleteOrder(orderID)
+			s.eventService.PublishOrderCancelled(orderID, models.OrderStatusPending, &models.OrderCancellation{
+				OrderID:     orderID,
+				ReasonCode:  models.CancellationReasonExpired,
+				CancelledAt: now,
+			})
+		}
+		cancelled += len(orderIDs)
+
+		// Неполная пачка означает, что просроченных заказов больше нет или их обрабатывает другая реплика
+		if len(orderIDs) < AutoCancelBatchSize {
+			return cancelled, nil
+		}
+	}
+}
+
+// RunAutoCancel раз в interval отменяет просроченные заказы, пока не будет отменен ctx
+func (s *OrderService) RunAutoCancel(ctx context.Context, interval time.Duration) {
+	if s.pendingTTL <= 0 || interval <= 0 {
+		log.Println("Auto-cancellation of pending orders is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cancelled, err := s.CancelStalePendingOrders()
+			if err != nil {
+				log.Printf("Failed to cancel stale pending orders: %v\n", err)
+			}
+			if cancelled > 0 {
+				log.Printf("Cancelled %d stale pending orders\n", cancelled)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import "TestTask/internal/models"
+
+// orderStatusTransitions описывает допустимые переходы между статусами заказа.
+// Статусы delivered и cancelled являются конечными.
+var orderStatusTransitions = map[string][]string{
+	models.OrderStatusPending:   {models.OrderStatusConfirmed, models.OrderStatusCancelled},
+	models.OrderStatusConfirmed: {models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:   {models.OrderStatusDelivered},
+	models.OrderStatusDelivered: {},
+	models.OrderStatusCancelled: {},
+}
+
+// IsValidOrderStatus проверяет, что статус известен системе
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionOrderStatus проверяет, разрешен ли переход из статуса from в статус to
+func CanTransitionOrderStatus(from, to string) bool {
+	for _, allowed := range orderStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// cancellationReasons коды причин, с которыми можно отменить заказ
+var cancellationReasons = map[string]bool{
+	models.CancellationReasonCustomerRequest: true,
+	models.CancellationReasonPaymentFailed:   true,
+	models.CancellationReasonOutOfStock:      true,
+	models.CancellationReasonDuplicate:       true,
+	models.CancellationReasonFraudSuspected:  true,
+	models.CancellationReasonOther:           true,
+}
+
+// IsValidCancellationReason проверяет, что код причины отмены известен системе
+func IsValidCancellationReason(code string) bool {
+	return cancellationReasons[code]
+}
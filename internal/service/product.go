@@ -2,33 +2,263 @@ package service
 
 import (
 	"TestTask/internal/models"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxProductImportRows максимальное количество строк в одном файле импорта
+	MaxProductImportRows = 100000
+	// MaxProductNameLength максимальная длина названия продукта
+	MaxProductNameLength = 255
+	// MaxProductCategoryLength максимальная длина категории продукта
+	MaxProductCategoryLength = 100
 )
 
 type ProductService struct {
 	repo ProductRepositoryInterface
+	// baseCurrency валюта цены продуктов, для которых валюта не указана
+	baseCurrency string
 }
 
-func NewProductService(repo ProductRepositoryInterface) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo ProductRepositoryInterface, baseCurrency string) *ProductService {
+	return &ProductService{repo: repo, baseCurrency: baseCurrency}
 }
 
 func (s *ProductService) CreateProduct(product *models.Product) error {
-	if product.Name == "" || product.Price <= 0 {
-		return fmt.Errorf("invalid product data")
+	if strings.TrimSpace(product.Currency) == "" {
+		product.Currency = s.baseCurrency
+	}
+	if err := s.validateProduct(product); err != nil {
+		return err
 	}
 
 	return s.repo.CreateProduct(product)
 }
 
+// UpdateProduct заменяет продукт. Если валюта не указана, сохраняется текущая валюта продукта:
+// иначе цена в EUR или USD без изменения суммы стала бы ценой в базовой валюте.
 func (s *ProductService) UpdateProduct(product *models.Product) error {
-	if product.Name == "" || product.Price <= 0 {
-		return fmt.Errorf("invalid product data")
+	if err := s.validateProduct(product); err != nil {
+		return err
+	}
+
+	if product.Currency == "" {
+		existingProduct, err := s.repo.GetProductByID(product.ID)
+		if err != nil {
+			return err
+		}
+		if existingProduct == nil {
+			return fmt.Errorf("%w with id: %d", models.ErrProductNotFound, product.ID)
+		}
+		product.Currency = existingProduct.Currency
 	}
 
 	return s.repo.UpdateProduct(product)
 }
 
+// validateProduct проверяет данные продукта перед сохранением. Пустая валюта не проверяется:
+// ее заполняет вызывающий код - базовой валютой для новых продуктов или текущей для существующих.
+func (s *ProductService) validateProduct(product *models.Product) error {
+	if product.Name == "" || product.Price <= 0 || product.Quantity < 0 {
+		return models.ErrInvalidProductData
+	}
+	if len([]rune(product.Name)) > MaxProductNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", models.ErrInvalidProductData, MaxProductNameLength)
+	}
+	if len([]rune(product.Category)) > MaxProductCategoryLength {
+		return fmt.Errorf("%w: category must be at most %d characters", models.ErrInvalidProductData, MaxProductCategoryLength)
+	}
+
+	product.Currency = strings.ToUpper(strings.TrimSpace(product.Currency))
+	if product.Currency != "" && !models.IsSupportedCurrency(product.Currency) {
+		return fmt.Errorf("%w: unsupported currency %q", models.ErrInvalidProductData, product.Currency)
+	}
+	return nil
+}
+
+// PatchProduct применяет к продукту документ JSON Merge Patch и сохраняет результат
+// с той же валидацией, что и полное обновление
+func (s *ProductService) PatchProduct(productID int, patch []byte) (*models.Product, error) {
+	existingProduct, err := s.repo.GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if existingProduct == nil {
+		return nil, fmt.Errorf("%w with id: %d", models.ErrProductNotFound, productID)
+	}
+
+	product := *existingProduct
+	err = applyMergePatch(&product, patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidProductData, err)
+	}
+	product.ID = productID
+
+	err = s.UpdateProduct(&product)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// ImportProducts загружает каталог продуктов из CSV с заголовком name, price, quantity и необязательными id, currency и category.
+// Строки с id обновляют существующие продукты, без id - создают новые. Каждая строка проверяется
+// по тем же правилам, что и CreateProduct; невалидные строки попадают в отчет и не импортируются.
+// Новые продукты без валюты получают базовую валюту, у существующих пустая валюта не меняется.
+// Если колонки category нет, категория существующих продуктов не меняется, а новые создаются без категории.
+func (s *ProductService) ImportProducts(r io.Reader) (*models.ProductImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not read CSV header: %v", models.ErrInvalidProductData, err)
+	}
+
+	columns, err := productImportColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ProductImportReport{Rows: []models.ProductImportRow{}}
+	valid := []models.ProductImportRow{}
+	seenIDs := map[int]int{}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		row := models.ProductImportRow{Status: models.ProductImportFailed}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("could not read CSV: %w", err)
+			}
+			row.Row = parseErr.StartLine
+			row.Error = parseErr.Err.Error()
+			report.Rows = append(report.Rows, row)
+			continue
+		}
+		row.Row, _ = reader.FieldPos(0)
+
+		if len(valid)+len(report.Rows) >= MaxProductImportRows {
+			return nil, fmt.Errorf("%w: file must contain at most %d rows", models.ErrInvalidProductData, MaxProductImportRows)
+		}
+
+		_, row.HasCategory = columns["category"]
+		row.Product, err = parseProductRecord(record, columns)
+		if err == nil {
+			if row.Product.ID == 0 && strings.TrimSpace(row.Product.Currency) == "" {
+				row.Product.Currency = s.baseCurrency
+			}
+			err = s.validateProduct(&row.Product)
+		}
+		if err == nil && row.Product.ID != 0 {
+			if firstRow, ok := seenIDs[row.Product.ID]; ok {
+				err = fmt.Errorf("product id %d is already used in row %d", row.Product.ID, firstRow)
+			}
+			seenIDs[row.Product.ID] = row.Row
+		}
+		if err != nil {
+			row.Error = err.Error()
+			report.Rows = append(report.Rows, row)
+			continue
+		}
+
+		valid = append(valid, row)
+	}
+
+	if len(valid) > 0 {
+		err = s.repo.ImportProducts(valid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report.Rows = append(report.Rows, valid...)
+	sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Row < report.Rows[j].Row })
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case models.ProductImportCreated:
+			report.Created++
+		case models.ProductImportUpdated:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+// productImportColumns сопоставляет названия колонок заголовка с их позициями
+func productImportColumns(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "id", "name", "price", "quantity", "currency", "category":
+			columns[name] = i
+		default:
+			return nil, fmt.Errorf("%w: unknown column %q", models.ErrInvalidProductData, name)
+		}
+	}
+
+	for _, required := range []string{"name", "price", "quantity"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", models.ErrInvalidProductData, required)
+		}
+	}
+	return columns, nil
+}
+
+// parseProductRecord разбирает строку CSV в продукт
+func parseProductRecord(record []string, columns map[string]int) (models.Product, error) {
+	var (
+		product models.Product
+		err     error
+	)
+
+	product.Name = strings.TrimSpace(record[columns["name"]])
+
+	product.Price, err = models.ParseMoney(record[columns["price"]])
+	if err != nil {
+		return product, fmt.Errorf("invalid price %q", record[columns["price"]])
+	}
+
+	product.Quantity, err = strconv.Atoi(strings.TrimSpace(record[columns["quantity"]]))
+	if err != nil {
+		return product, fmt.Errorf("invalid quantity %q", record[columns["quantity"]])
+	}
+
+	if i, ok := columns["currency"]; ok {
+		product.Currency = record[i]
+	}
+
+	if i, ok := columns["category"]; ok {
+		product.Category = strings.TrimSpace(record[i])
+	}
+
+	if i, ok := columns["id"]; ok && strings.TrimSpace(record[i]) != "" {
+		product.ID, err = strconv.Atoi(strings.TrimSpace(record[i]))
+		if err != nil || product.ID <= 0 {
+			return product, fmt.Errorf("invalid id %q", record[i])
+		}
+	}
+
+	return product, nil
+}
+
 func (s *ProductService) DeleteProduct(productID int) error {
 	return s.repo.DeleteProductByID(productID)
 }
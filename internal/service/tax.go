@@ -0,0 +1,90 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"fmt"
+	"math/big"
+)
+
+// TaxLine облагаемая позиция заказа: стоимость позиции по ценам каталога и категория продукта
+type TaxLine struct {
+	Category string
+	Amount   models.Money
+}
+
+// TaxCalculator рассчитывает подытог и налог заказа по правилам из таблицы tax_rules
+type TaxCalculator struct {
+	repo TaxRuleRepositoryInterface
+}
+
+func NewTaxCalculator(repo TaxRuleRepositoryInterface) *TaxCalculator {
+	return &TaxCalculator{repo: repo}
+}
+
+// Calculate возвращает подытог без налога и сумму налога для позиций заказа в регионе.
+// Для каждой позиции выбирается самое точное правило: регион и категория, затем только регион,
+// затем только категория, затем правило по умолчанию. Если правило не найдено, позиция налогом не облагается.
+// При включенном в цену налоге (Inclusive) налог выделяется из стоимости позиции, иначе начисляется сверху.
+func (c *TaxCalculator) Calculate(region string, lines []TaxLine) (models.Money, models.Money, error) {
+	rules, err := c.repo.GetTaxRules(region)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get tax rules: %w", err)
+	}
+
+	// Налог копится точной дробью в центах и округляется один раз для всего заказа. Подытог считается
+	// от итоговой суммы с налогом, чтобы для цен с налогом итог совпадал с ценами каталога.
+	var (
+		gross        models.Money
+		inclusiveTax = new(big.Rat)
+		exclusiveTax = new(big.Rat)
+	)
+	for _, line := range lines {
+		gross += line.Amount
+
+		rule := matchTaxRule(rules, region, line.Category)
+		if rule == nil {
+			continue
+		}
+
+		rate := models.DecimalRate(rule.Rate)
+		lineTax := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(line.Amount)), rate)
+		if rule.Inclusive {
+			// Налог, включенный в цену: amount - amount / (1 + rate) = amount * rate / (1 + rate)
+			inclusiveTax.Add(inclusiveTax, lineTax.Quo(lineTax, rate.Add(rate, big.NewRat(1, 1))))
+		} else {
+			exclusiveTax.Add(exclusiveTax, lineTax)
+		}
+	}
+
+	gross += models.MoneyFromRat(exclusiveTax)
+	tax := models.MoneyFromRat(inclusiveTax.Add(inclusiveTax, exclusiveTax))
+	return gross - tax, tax, nil
+}
+
+// matchTaxRule выбирает правило с наибольшей точностью совпадения; точное совпадение региона важнее категории
+func matchTaxRule(rules []models.TaxRule, region, category string) *models.TaxRule {
+	var (
+		best      *models.TaxRule
+		bestScore = -1
+	)
+
+	for i := range rules {
+		rule := &rules[i]
+		if (rule.Region != "" && rule.Region != region) || (rule.Category != "" && rule.Category != category) {
+			continue
+		}
+
+		score := 0
+		if rule.Region != "" {
+			score += 2
+		}
+		if rule.Category != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"TestTask/internal/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Заголовки запроса с событием
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	// MaxWebhookURLLength максимальная длина адреса вебхука
+	MaxWebhookURLLength = 2048
+	// webhookSecretBytes длина генерируемого ключа подписи в байтах
+	webhookSecretBytes = 32
+	// maxWebhookResponseBytes сколько байт ответа вычитывается, чтобы переиспользовать соединение
+	maxWebhookResponseBytes = 64 << 10
+	// PendingDeliveriesBatchSize сколько зависших доставок забирается на повторную отправку за один запрос
+	PendingDeliveriesBatchSize = 100
+	// NotificationQueueSize сколько событий может ждать записи доставок
+	NotificationQueueSize = 1000
+)
+
+// WebhookEventTypes типы событий, на которые можно подписаться
+var WebhookEventTypes = []string{EventOrderStatusChanged, EventOrderCancelled}
+
+// webhookEvent тело запроса с событием
+type webhookEvent struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// webhookNotification событие в очереди на запись доставок
+type webhookNotification struct {
+	eventType string
+	payload   []byte
+}
+
+// WebhookService управляет подписками партнеров и отправляет им события заказов.
+// События принимаются в очередь, из которой фоновый обработчик записывает доставки в базу данных
+// и отправляет их: до maxAttempts попыток
+// с паузой initialBackoff, удваивающейся после каждой неудачи. Доставки, которые остаются в статусе pending
+// дольше pendingTimeout (например, прерванные перезапуском сервиса), отправляются заново ResendPendingDeliveries.
+// pendingTimeout должен быть больше самой длинной паузы между попытками, иначе доставка может уйти дважды.
+type WebhookService struct {
+	repo           WebhookRepositoryInterface
+	client         *http.Client
+	maxAttempts    int
+	initialBackoff time.Duration
+	pendingTimeout time.Duration
+	notifications  chan webhookNotification
+	wg             sync.WaitGroup
+}
+
+func NewWebhookService(repo WebhookRepositoryInterface, client *http.Client, maxAttempts int, initialBackoff, pendingTimeout time.Duration) *WebhookService {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	s := &WebhookService{
+		repo:           repo,
+		client:         client,
+		maxAttempts:    maxAttempts,
+		initialBackoff: initialBackoff,
+		pendingTimeout: pendingTimeout,
+		notifications:  make(chan webhookNotification, NotificationQueueSize),
+	}
+	go s.processNotifications()
+	return s
+}
+
+// CreateWebhook создает подписку. Если ключ подписи не задан, он генерируется.
+func (s *WebhookService) CreateWebhook(webhook *models.Webhook) error {
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+
+	return s.repo.CreateWebhook(webhook)
+}
+
+// UpdateWebhook заменяет подписку. Если ключ подписи не задан, сохраняется прежний.
+func (s *WebhookService) UpdateWebhook(webhook *models.Webhook) error {
+	if strings.TrimSpace(webhook.Secret) == "" {
+		existing, err := s.repo.GetWebhookByID(webhook.ID)
+		if err != nil {
+			return err
+		}
+		webhook.Secret = existing.Secret
+	}
+
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateWebhook(webhook)
+}
+
+func (s *WebhookService) DeleteWebhook(webhookID int) error {
+	return s.repo.DeleteWebhook(webhookID)
+}
+
+func (s *WebhookService) GetWebhookByID(webhookID int) (*models.Webhook, error) {
+	return s.repo.GetWebhookByID(webhookID)
+}
+
+func (s *WebhookService) GetAllWebhooks() ([]models.Webhook, error) {
+	return s.repo.GetAllWebhooks()
+}
+
+// GetWebhookDeliveries возвращает историю доставок подписки
+func (s *WebhookService) GetWebhookDeliveries(webhookID int) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.GetWebhookByID(webhookID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetWebhookDeliveries(webhookID)
+}
+
+// Notify ставит событие eventType в очередь и сразу возвращает управление: поиск подписок и запись доставок
+// выполняются в фоне, поэтому медленная или недоступная таблица вебхуков не задерживает обработку заказов.
+// Если очередь переполнена, событие не отправляется. Ошибки только логируются.
+func (s *WebhookService) Notify(eventType string, data interface{}) {
+	payload, err := json.Marshal(webhookEvent{Event: eventType, OccurredAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		log.Printf("Failed to marshal webhook event: %v\n", err)
+		return
+	}
+
+	s.wg.Add(1)
+	select {
+	case s.notifications <- webhookNotification{eventType: eventType, payload: payload}:
+	default:
+		s.wg.Done()
+		log.Printf("Webhook notification queue is full, event %s is dropped\n", eventType)
+	}
+}
+
+// processNotifications записывает доставки событий из очереди и запускает их отправку
+func (s *WebhookService) processNotifications() {
+	for notification := range s.notifications {
+		s.createDeliveries(notification.eventType, notification.payload)
+		s.wg.Done()
+	}
+}
+
+// createDeliveries записывает доставки события для всех активных подписок на eventType и запускает их отправку
+func (s *WebhookService) createDeliveries(eventType string, payload []byte) {
+	webhooks, err := s.repo.GetActiveWebhooksByEvent(eventType)
+	if err != nil {
+		log.Printf("Failed to get webhooks for event %s: %v\n", eventType, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		delivery := models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventType: eventType,
+			Payload:   payload,
+			Status:    models.WebhookDeliveryPending,
+		}
+		if err := s.repo.CreateWebhookDelivery(&delivery); err != nil {
+			log.Printf("Failed to record webhook delivery for webhook %d: %v\n", webhook.ID, err)
+			continue
+		}
+
+		s.dispatch(webhook, delivery)
+	}
+}
+
+// ReplayDelivery повторно отправляет тело доставки deliveryID на вебхук, даже если подписка отключена.
+// Повтор записывается новой доставкой, исходная остается в истории без изменений.
+func (s *WebhookService) ReplayDelivery(deliveryID int) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetWebhookDeliveryByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.GetWebhookByID(original.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := models.WebhookDelivery{
+		WebhookID: original.WebhookID,
+		EventType: original.EventType,
+		Payload:   original.Payload,
+		Status:    models.WebhookDeliveryPending,
+	}
+	if err := s.repo.CreateWebhookDelivery(&replay); err != nil {
+		return nil, err
+	}
+
+	s.dispatch(*webhook, replay)
+	return &replay, nil
+}
+
+// ResendPendingDeliveries забирает доставки, которые находятся в статусе pending дольше pendingTimeout,
+// и продолжает их отправку с оставшимися попытками. Доставки отключенных подписок помечаются как неудачные.
+// Доставка забирается атомарно, поэтому несколько реплик не отправляют ее одновременно.
+// Возвращает количество забранных доставок.
+func (s *WebhookService) ResendPendingDeliveries() (int, error) {
+	resent := 0
+	for {
+		deliveries, err := s.repo.ClaimStalePendingDeliveries(time.Now().Add(-s.pendingTimeout), PendingDeliveriesBatchSize)
+		if err != nil {
+			return resent, err
+		}
+
+		webhooks := map[int]*models.Webhook{}
+		for _, delivery := range deliveries {
+			webhook, ok := webhooks[delivery.WebhookID]
+			if !ok {
+				webhook, err = s.repo.GetWebhookByID(delivery.WebhookID)
+				if err != nil {
+					log.Printf("Failed to get webhook %d for delivery %d: %v\n", delivery.WebhookID, delivery.ID, err)
+					continue
+				}
+				webhooks[delivery.WebhookID] = webhook
+			}
+
+			if !webhook.IsActive {
+				delivery.Status = models.WebhookDeliveryFailed
+				delivery.LastError = "webhook is inactive"
+				if err := s.repo.UpdateWebhookDelivery(&delivery); err != nil {
+					log.Printf("Failed to update webhook delivery %d: %v\n", delivery.ID, err)
+				}
+				continue
+			}
+
+			s.dispatch(*webhook, delivery)
+		}
+		resent += len(deliveries)
+
+		if len(deliveries) < PendingDeliveriesBatchSize {
+			return resent, nil
+		}
+	}
+}
+
+// RunPendingDeliveries отправляет зависшие доставки при запуске и затем раз в interval, пока не будет отменен ctx
+func (s *WebhookService) RunPendingDeliveries(ctx context.Context, interval time.Duration) {
+	if s.pendingTimeout <= 0 || interval <= 0 {
+		log.Println("Resending of pending webhook deliveries is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		resent, err := s.ResendPendingDeliveries()
+		if err != nil {
+			log.Printf("Failed to resend pending webhook deliveries: %v\n", err)
+		}
+		if resent > 0 {
+			log.Printf("Resending %d pending webhook deliveries\n", resent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Wait ждет, пока будут обработаны события из очереди и завершены отправки, запущенные Notify и ReplayDelivery
+func (s *WebhookService) Wait() {
+	s.wg.Wait()
+}
+
+// SignWebhookPayload возвращает значение заголовка X-Webhook-Signature для тела payload
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// dispatch отправляет доставку в фоне. Подписка и доставка передаются по значению,
+// поэтому вызывающий код может продолжать пользоваться своими копиями.
+func (s *WebhookService) dispatch(webhook models.Webhook, delivery models.WebhookDelivery) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.deliver(&webhook, &delivery)
+	}()
+}
+
+// deliver отправляет доставку, пока вебхук не ответит статусом 2xx или не закончатся попытки,
+// и сохраняет результат каждой попытки. Попытки, выполненные до перезапуска, учитываются:
+// доставка, забранная ResendPendingDeliveries, получает только оставшиеся попытки (но не меньше одной).
+func (s *WebhookService) deliver(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	for {
+		statusCode, err := s.send(webhook, delivery)
+		delivery.Attempts++
+		delivery.ResponseStatus = statusCode
+		delivery.LastError = ""
+		switch {
+		case err == nil:
+			delivery.Status = models.WebhookDeliverySucceeded
+		case delivery.Attempts >= s.maxAttempts:
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.LastError = err.Error()
+		default:
+			delivery.LastError = err.Error()
+		}
+
+		if updateErr := s.repo.UpdateWebhookDelivery(delivery); updateErr != nil {
+			log.Printf("Failed to update webhook delivery %d: %v\n", delivery.ID, updateErr)
+		}
+		if delivery.Status != models.WebhookDeliveryPending {
+			break
+		}
+
+		// Пауза удваивается после каждой неудачной попытки: initialBackoff, 2 * initialBackoff, ...
+		time.Sleep(s.initialBackoff << (delivery.Attempts - 1))
+	}
+	if delivery.Status == models.WebhookDeliverySucceeded {
+		return
+	}
+
+	log.Printf("Webhook delivery %d to %s failed after %d attempts: %s\n", delivery.ID, webhook.URL, delivery.Attempts, delivery.LastError)
+}
+
+// send выполняет одну попытку отправки и возвращает HTTP статус ответа
+func (s *WebhookService) send(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// validateWebhook проверяет подписку перед сохранением, убирает повторяющиеся типы событий
+// и генерирует ключ подписи, если он не задан
+func validateWebhook(webhook *models.Webhook) error {
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	if webhook.URL == "" || len(webhook.URL) > MaxWebhookURLLength {
+		return fmt.Errorf("%w: url must contain 1 to %d characters", models.ErrInvalidWebhookData, MaxWebhookURLLength)
+	}
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", models.ErrInvalidWebhookData)
+	}
+
+	if len(webhook.EventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", models.ErrInvalidWebhookData)
+	}
+	eventTypes := make([]string, 0, len(webhook.EventTypes))
+	seen := make(map[string]bool, len(webhook.EventTypes))
+	for _, eventType := range webhook.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !isWebhookEventType(eventType) {
+			return fmt.Errorf("%w: unknown event type %q, expected one of %s",
+				models.ErrInvalidWebhookData, eventType, strings.Join(WebhookEventTypes, ", "))
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	webhook.EventTypes = eventTypes
+
+	webhook.Secret = strings.TrimSpace(webhook.Secret)
+	if webhook.Secret == "" {
+		secret := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("could not generate webhook secret: %v", err)
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	return nil
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, known := range WebhookEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
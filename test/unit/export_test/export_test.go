@@ -0,0 +1,64 @@
+package export_test
+
+import (
+	"TestTask/internal/export"
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer, err := export.NewWriter(export.FormatCSV, &buf)
+	assert.NoError(t, err)
+
+	createdAt := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, writer.WriteRow("id", "customer_name", "total_price", "created_at"))
+	assert.NoError(t, writer.WriteRow(1, "Doe, John", 25.45, createdAt))
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, "id,customer_name,total_price,created_at\n1,\"Doe, John\",25.45,2025-01-10T12:00:00Z\n", buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer, err := export.NewWriter(export.FormatXLSX, &buf)
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.WriteRow("id", "customer_name"))
+	assert.NoError(t, writer.WriteRow(1, "John <Doe> & Co"))
+	assert.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "xl/workbook.xml")
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.True(t, strings.HasSuffix(sheet, "</sheetData></worksheet>"))
+	assert.Contains(t, sheet, `<c r="A2"><v>1</v></c>`)
+	assert.Contains(t, sheet, `John &lt;Doe&gt; &amp; Co`)
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := export.NewWriter("pdf", io.Discard)
+	assert.Error(t, err)
+	assert.False(t, export.IsSupportedFormat("pdf"))
+}
@@ -0,0 +1,198 @@
+package handlers_test
+
+import (
+	"TestTask/internal/handlers"
+	"TestTask/internal/middleware"
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// MockOrderService для мока OrderServiceInterface. Вызов методов, которые не переопределены, приводит к панике.
+type MockOrderService struct {
+	mock.Mock
+	handlers.OrderServiceInterface
+}
+
+func (m *MockOrderService) CreateOrder(order *models.Order) error {
+	args := m.Called(order)
+	order.ID = 1
+	return args.Error(0)
+}
+
+// MockLogService для мока LogServiceInterface
+type MockLogService struct {
+	mock.Mock
+}
+
+func (m *MockLogService) CreateLog(action, details string, userID int) error {
+	args := m.Called(action, details, userID)
+	return args.Error(0)
+}
+
+// memoryIdempotencyRepository хранит ключи идемпотентности в памяти
+type memoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func newMemoryIdempotencyRepository() *memoryIdempotencyRepository {
+	return &memoryIdempotencyRepository{records: map[string]models.IdempotencyRecord{}}
+}
+
+func (r *memoryIdempotencyRepository) ReserveIdempotencyKey(record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.records[record.Key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return &existing, nil
+	}
+	r.records[record.Key] = *record
+	return nil, nil
+}
+
+func (r *memoryIdempotencyRepository) SaveIdempotencyResponse(userID int, key string, status int, headers map[string]string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record := r.records[key]
+	record.ResponseStatus = status
+	record.ResponseHeaders = headers
+	record.ResponseBody = body
+	r.records[key] = record
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteIdempotencyKey(userID int, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, key)
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteExpiredIdempotencyKeys(now time.Time) (int, error) {
+	return 0, nil
+}
+
+// newIdempotentOrderHandler обработчик заказов с ключами идемпотентности в памяти
+func newIdempotentOrderHandler() (*handlers.OrderHandler, *MockOrderService) {
+	orderService := new(MockOrderService)
+	logService := new(MockLogService)
+	logService.On("CreateLog", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	idempotencyService := service.NewIdempotencyService(newMemoryIdempotencyRepository(), time.Hour)
+	return handlers.NewOrderHandler(orderService, logService, idempotencyService), orderService
+}
+
+// createOrder отправляет запрос на создание заказа от имени пользователя 3
+func createOrder(handler *handlers.OrderHandler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 3))
+
+	rec := httptest.NewRecorder()
+	handler.CreateOrder(rec, req)
+	return rec
+}
+
+const orderBody = `{"customer_name":"John Doe","items":[{"product_id":1,"quantity":1}]}`
+
+func TestIdempotentCreateOrderReplaysResponse(t *testing.T) {
+	handler, orderService := newIdempotentOrderHandler()
+	orderService.On("CreateOrder", mock.Anything).Return(nil).Once()
+
+	first := createOrder(handler, "key-1", orderBody)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.NotEmpty(t, first.Header().Get("ETag"))
+
+	// Повтор с тем же ключом и телом отдает сохраненный ответ без повторного создания заказа
+	replay := createOrder(handler, "key-1", orderBody)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, first.Header().Get("ETag"), replay.Header().Get("ETag"))
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+
+	orderService.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestIdempotentCreateOrderRejectsDifferentBody(t *testing.T) {
+	handler, orderService := newIdempotentOrderHandler()
+	orderService.On("CreateOrder", mock.Anything).Return(nil).Once()
+
+	first := createOrder(handler, "key-1", orderBody)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	// Тот же ключ с другим телом запроса
+	other := createOrder(handler, "key-1", `{"customer_name":"Jane Doe","items":[{"product_id":1,"quantity":2}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, other.Code)
+
+	orderService.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestIdempotentCreateOrderRejectsConcurrentRequest(t *testing.T) {
+	handler, orderService := newIdempotentOrderHandler()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	orderService.On("CreateOrder", mock.Anything).Run(func(args mock.Arguments) {
+		close(started)
+		<-finish
+	}).Return(nil).Once()
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- createOrder(handler, "key-1", orderBody)
+	}()
+	<-started
+
+	// Первый запрос с этим ключом еще выполняется
+	concurrent := createOrder(handler, "key-1", orderBody)
+	assert.Equal(t, http.StatusConflict, concurrent.Code)
+
+	close(finish)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	orderService.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestIdempotentCreateOrderReleasesKeyOnError(t *testing.T) {
+	handler, orderService := newIdempotentOrderHandler()
+	orderService.On("CreateOrder", mock.Anything).Return(models.ErrOutOfStock).Once()
+	orderService.On("CreateOrder", mock.Anything).Return(nil).Once()
+
+	failed := createOrder(handler, "key-1", orderBody)
+	assert.Equal(t, http.StatusConflict, failed.Code)
+
+	// Неуспешный ответ не сохраняется: повтор с тем же ключом выполняет запрос заново
+	retry := createOrder(handler, "key-1", orderBody)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+
+	orderService.AssertNumberOfCalls(t, "CreateOrder", 2)
+}
+
+func TestIdempotentCreateOrderReleasesKeyOnPanic(t *testing.T) {
+	handler, orderService := newIdempotentOrderHandler()
+	orderService.On("CreateOrder", mock.Anything).Run(func(args mock.Arguments) {
+		panic("unexpected failure")
+	}).Return(nil).Once()
+	orderService.On("CreateOrder", mock.Anything).Return(nil).Once()
+
+	// Паника передается дальше, как и без ключа идемпотентности
+	assert.Panics(t, func() {
+		createOrder(handler, "key-1", orderBody)
+	})
+
+	// Ключ освобожден: повтор выполняется, а не отклоняется как запрос, который еще обрабатывается
+	retry := createOrder(handler, "key-1", orderBody)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+
+	orderService.AssertNumberOfCalls(t, "CreateOrder", 2)
+}
@@ -0,0 +1,97 @@
+package models_test
+
+import (
+	"TestTask/internal/models"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected models.Money
+	}{
+		{"25.45", 2545},
+		{" 10 ", 1000},
+		{"0.1", 10},
+		{"-3.5", -350},
+		{"1e2", 10000},
+		{"0.005", 1},
+		{"-0.005", -1},
+		{"0.0049", 0},
+	}
+
+	for _, tt := range tests {
+		result, err := models.ParseMoney(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, result, tt.input)
+	}
+
+	for _, input := range []string{"", "abc", "1,5", "1/3", "0x10", "1.5.5", "99999999999999999999999"} {
+		_, err := models.ParseMoney(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "25.40", models.Money(2540).String())
+	assert.Equal(t, "0.05", models.Money(5).String())
+	assert.Equal(t, "-0.05", models.Money(-5).String())
+	assert.Equal(t, "0.00", models.Money(0).String())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var product models.Product
+
+	// Сумма принимается как числом, так и строкой
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": 10.1}`), &product))
+	assert.Equal(t, models.Money(1010), product.Price)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": "0.30"}`), &product))
+	assert.Equal(t, models.Money(30), product.Price)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": "ten"}`), &product))
+	assert.Error(t, json.Unmarshal([]byte(`{"price": true}`), &product))
+
+	data, err := json.Marshal(models.OrderItem{ProductID: 1, Quantity: 3, UnitPrice: 1010})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id": 0, "order_id": 0, "product_id": 1, "quantity": 3, "unit_price": 10.10}`, string(data))
+}
+
+func TestMoneyScan(t *testing.T) {
+	var m models.Money
+
+	assert.NoError(t, m.Scan([]byte("99.99")))
+	assert.Equal(t, models.Money(9999), m)
+
+	assert.NoError(t, m.Scan(int64(5)))
+	assert.Equal(t, models.Money(500), m)
+
+	assert.NoError(t, m.Scan(0.1+0.2))
+	assert.Equal(t, models.Money(30), m)
+
+	assert.NoError(t, m.Scan(nil))
+	assert.Equal(t, models.Money(0), m)
+
+	assert.Error(t, m.Scan(true))
+
+	value, err := models.Money(2545).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "25.45", value)
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// Сумма десяти позиций по 0.10 точно равна 1.00, в отличие от float64
+	var total models.Money
+	for i := 0; i < 10; i++ {
+		total += models.Money(10)
+	}
+	assert.Equal(t, models.Money(100), total)
+
+	assert.Equal(t, models.Money(3030), models.Money(1010).Mul(3))
+	assert.Equal(t, models.Money(70), models.Money(1000).MulRate(0.07))
+	assert.Equal(t, models.Money(333), models.Money(1000).MulDiv(1, 3))
+	assert.Equal(t, models.Money(667), models.Money(1000).MulDiv(2, 3))
+	assert.Equal(t, models.Money(0), models.Money(1000).MulDiv(1, 0))
+}
@@ -0,0 +1,16 @@
+package models_test
+
+import (
+	"TestTask/internal/models"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWebhookJSONOmitsSecret(t *testing.T) {
+	webhook := models.Webhook{ID: 1, URL: "https://partner.example.com/hooks", Secret: "secret", EventTypes: []string{"order_cancelled"}}
+
+	data, err := json.Marshal(webhook)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+}
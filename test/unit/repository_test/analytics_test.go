@@ -0,0 +1,100 @@
+package repository_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetRevenue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC)
+	filter := models.AnalyticsFilter{From: from, To: to, Interval: models.AnalyticsIntervalWeek}
+
+	mock.ExpectQuery(`SELECT date_trunc\(\$1, created_at\) AS period, COUNT\(\*\), COALESCE\(SUM\(base_total_price\), 0\) FROM orders WHERE orders.is_deleted = false AND orders.created_at >= \$2 AND orders.created_at <= \$3 AND orders.status <> 'cancelled' GROUP BY period`).
+		WithArgs(models.AnalyticsIntervalWeek, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"period", "count", "sum"}).
+			AddRow(from, 3, "100.00").
+			AddRow(from.AddDate(0, 0, 7), 1, "15.50"))
+
+	points, err := analyticsRepo.GetRevenue(filter)
+	assert.NoError(t, err)
+	assert.Len(t, points, 2)
+	assert.Equal(t, models.Money(10000), points[0].Revenue)
+	assert.Equal(t, models.Money(3333), points[0].AverageOrderValue)
+	assert.Equal(t, models.Money(1550), points[1].AverageOrderValue)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetOrdersByStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+
+	mock.ExpectQuery(`SELECT status, COUNT\(\*\), COALESCE\(SUM\(base_total_price\), 0\) FROM orders WHERE orders.is_deleted = false GROUP BY status`).
+		WithArgs().
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count", "sum"}).
+			AddRow(models.OrderStatusCancelled, 1, "20.00").
+			AddRow(models.OrderStatusPending, 2, "45.10"))
+
+	stats, err := analyticsRepo.GetOrdersByStatus(models.AnalyticsFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.OrderStatusStats{
+		{Status: models.OrderStatusCancelled, Orders: 1, Revenue: models.Money(2000)},
+		{Status: models.OrderStatusPending, Orders: 2, Revenue: models.Money(4510)},
+	}, stats)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetTopProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Выручка позиции - ее доля в итоговой сумме заказа в базовой валюте
+	mock.ExpectQuery(`o.base_total_price \* oi.quantity \* oi.unit_price / NULLIF\(SUM\(oi.quantity \* oi.unit_price\) OVER \(PARTITION BY o.id\), 0\) AS revenue `+
+		`FROM order_items oi JOIN orders o ON o.id = oi.order_id WHERE o.is_deleted = false AND o.created_at >= \$1 AND o.status <> 'cancelled' \) lines `+
+		`LEFT JOIN products p ON p.id = lines.product_id (.+) LIMIT \$2`).
+		WithArgs(from, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "name", "quantity", "revenue"}).
+			AddRow(2, "Product 2", 10, "250.00").
+			AddRow(7, "", 1, "99.99"))
+
+	products, err := analyticsRepo.GetTopProducts(models.AnalyticsFilter{From: from, Limit: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ProductSales{
+		{ProductID: 2, Name: "Product 2", Quantity: 10, Revenue: models.Money(25000)},
+		{ProductID: 7, Quantity: 1, Revenue: models.Money(9999)},
+	}, products)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
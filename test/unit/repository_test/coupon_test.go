@@ -0,0 +1,145 @@
+package repository_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// couponRowColumns колонки, которые репозиторий выбирает для каждого купона
+var couponRowColumns = []string{
+	"id", "code", "discount_type", "discount_value", "min_order_value", "valid_from", "valid_to",
+	"max_uses", "max_uses_per_user", "used_count", "disabled", "created_at", "updated_at",
+}
+
+func TestCreateCoupon(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	couponRepo := repository.NewCouponRepository(db)
+
+	validTo := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	coupon := &models.Coupon{
+		Code: "WELCOME10", DiscountType: "percentage", DiscountValue: 1000, MinOrderValue: 5000,
+		ValidTo: &validTo, MaxUses: 100, MaxUsesPerUser: 1,
+	}
+
+	mock.ExpectQuery(`INSERT INTO coupons`).
+		WithArgs("WELCOME10", "percentage", models.Money(1000), models.Money(5000), sql.NullTime{}, sql.NullTime{Time: validTo, Valid: true}, 100, 1, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "used_count", "created_at", "updated_at"}).AddRow(1, 0, time.Now(), time.Now()))
+
+	err = couponRepo.CreateCoupon(coupon)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, coupon.ID)
+
+	// Код уже занят другим купоном
+	mock.ExpectQuery(`INSERT INTO coupons`).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	err = couponRepo.CreateCoupon(coupon)
+	assert.ErrorIs(t, err, models.ErrCouponCodeTaken)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetCouponByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	couponRepo := repository.NewCouponRepository(db)
+
+	validFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM coupons WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(couponRowColumns).
+			AddRow(1, "FLAT5", "fixed", 5.0, 0.0, validFrom, nil, 0, 0, 12, false, validFrom, validFrom))
+
+	coupon, err := couponRepo.GetCouponByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "FLAT5", coupon.Code)
+	assert.Equal(t, validFrom, *coupon.ValidFrom)
+	assert.Nil(t, coupon.ValidTo)
+	assert.Equal(t, 12, coupon.UsedCount)
+
+	// Несуществующий купон
+	mock.ExpectQuery(`SELECT (.+) FROM coupons WHERE id = \$1`).
+		WithArgs(2).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = couponRepo.GetCouponByID(2)
+	assert.ErrorIs(t, err, models.ErrCouponNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetCouponByCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	couponRepo := repository.NewCouponRepository(db)
+
+	mock.ExpectQuery(`SELECT (.+) FROM coupons WHERE code = \$1`).
+		WithArgs("WELCOME10").
+		WillReturnRows(sqlmock.NewRows(couponRowColumns).
+			AddRow(3, "WELCOME10", "percentage", 10.0, 50.0, nil, nil, 100, 1, 4, false, time.Now(), time.Now()))
+
+	coupon, err := couponRepo.GetCouponByCode("WELCOME10")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, coupon.ID)
+	assert.Equal(t, models.Money(1000), coupon.DiscountValue)
+
+	// Неизвестный код
+	mock.ExpectQuery(`SELECT (.+) FROM coupons WHERE code = \$1`).
+		WithArgs("UNKNOWN").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = couponRepo.GetCouponByCode("UNKNOWN")
+	assert.ErrorIs(t, err, models.ErrCouponNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestDeleteCoupon(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	couponRepo := repository.NewCouponRepository(db)
+
+	mock.ExpectExec(`DELETE FROM coupons WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM coupons WHERE id = \$1`).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, couponRepo.DeleteCoupon(1))
+	assert.ErrorIs(t, couponRepo.DeleteCoupon(2), models.ErrCouponNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
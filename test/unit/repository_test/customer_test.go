@@ -0,0 +1,162 @@
+package repository_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// customerRowColumns колонки, которые репозиторий выбирает для каждого клиента
+var customerRowColumns = []string{"id", "name", "email", "phone", "addresses", "created_at", "updated_at"}
+
+func TestCreateCustomer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	customerRepo := repository.NewCustomerRepository(db)
+
+	createdAt := time.Now()
+	customer := &models.Customer{
+		Name:      "John Doe",
+		Email:     "john.doe@example.com",
+		Addresses: []models.Address{{Line1: "Tverskaya st. 1", City: "Moscow", Country: "RU"}},
+	}
+
+	mock.ExpectQuery(`INSERT INTO customers \(name, email, phone, addresses\)`).
+		WithArgs("John Doe", sql.NullString{String: "john.doe@example.com", Valid: true}, sql.NullString{},
+			[]byte(`[{"line1":"Tverskaya st. 1","city":"Moscow","country":"RU"}]`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, createdAt, createdAt))
+
+	err = customerRepo.CreateCustomer(customer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, customer.ID)
+
+	// Email уже занят другим клиентом
+	mock.ExpectQuery(`INSERT INTO customers`).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	err = customerRepo.CreateCustomer(&models.Customer{Name: "Jane Doe", Email: "john.doe@example.com"})
+	assert.ErrorIs(t, err, models.ErrCustomerEmailTaken)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetCustomerByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	customerRepo := repository.NewCustomerRepository(db)
+
+	createdAt := time.Now()
+
+	mock.ExpectQuery(`SELECT (.+) FROM customers WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(customerRowColumns).
+			AddRow(1, "John Doe", nil, "+7 900 123-45-67", []byte(`[{"line1":"Tverskaya st. 1","city":"Moscow","country":"RU"}]`), createdAt, createdAt))
+	mock.ExpectQuery(`SELECT (.+) FROM customers WHERE id = \$1`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(customerRowColumns))
+
+	customer, err := customerRepo.GetCustomerByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Customer{
+		ID:        1,
+		Name:      "John Doe",
+		Phone:     "+7 900 123-45-67",
+		Addresses: []models.Address{{Line1: "Tverskaya st. 1", City: "Moscow", Country: "RU"}},
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}, customer)
+
+	_, err = customerRepo.GetCustomerByID(2)
+	assert.ErrorIs(t, err, models.ErrCustomerNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestDeleteCustomer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	customerRepo := repository.NewCustomerRepository(db)
+
+	mock.ExpectExec(`DELETE FROM customers WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM customers WHERE id = \$1`).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, customerRepo.DeleteCustomer(1))
+	assert.ErrorIs(t, customerRepo.DeleteCustomer(2), models.ErrCustomerNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestFindOrCreateCustomerByName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	customerRepo := repository.NewCustomerRepository(db)
+
+	createdAt := time.Now()
+
+	// Клиент с таким именем уже есть: возвращается он, новый не создается
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\(LOWER\(\$1\)\)\)`).
+		WithArgs("john doe").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT (.+) FROM customers WHERE LOWER\(name\) = LOWER\(\$1\) ORDER BY id LIMIT 1`).
+		WithArgs("john doe").
+		WillReturnRows(sqlmock.NewRows(customerRowColumns).AddRow(3, "John Doe", nil, nil, []byte(`[]`), createdAt, createdAt))
+	mock.ExpectCommit()
+
+	customer, err := customerRepo.FindOrCreateCustomerByName("john doe")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, customer.ID)
+	assert.Equal(t, "John Doe", customer.Name)
+
+	// Клиента нет: он создается с переданным именем
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+		WithArgs("Jane Doe").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT (.+) FROM customers WHERE LOWER\(name\) = LOWER\(\$1\)`).
+		WithArgs("Jane Doe").
+		WillReturnRows(sqlmock.NewRows(customerRowColumns))
+	mock.ExpectQuery(`INSERT INTO customers \(name\) VALUES \(\$1\) RETURNING (.+)`).
+		WithArgs("Jane Doe").
+		WillReturnRows(sqlmock.NewRows(customerRowColumns).AddRow(4, "Jane Doe", nil, nil, []byte(`[]`), createdAt, createdAt))
+	mock.ExpectCommit()
+
+	customer, err = customerRepo.FindOrCreateCustomerByName("Jane Doe")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, customer.ID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
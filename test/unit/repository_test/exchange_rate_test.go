@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSaveExchangeRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+
+	updatedAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+	rates := []models.ExchangeRate{
+		{FromCurrency: "EUR", ToCurrency: "RUB", Rate: 98.5},
+		{FromCurrency: "USD", ToCurrency: "RUB", Rate: 91.25},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO exchange_rates (.+) ON CONFLICT \(from_currency, to_currency\) DO UPDATE`).
+		WithArgs("EUR", "RUB", 98.5).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updatedAt))
+	mock.ExpectQuery(`INSERT INTO exchange_rates`).
+		WithArgs("USD", "RUB", 91.25).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updatedAt))
+	mock.ExpectCommit()
+
+	err = exchangeRateRepo.SaveExchangeRates(rates)
+	assert.NoError(t, err)
+	assert.Equal(t, updatedAt, rates[1].UpdatedAt)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+
+	updatedAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+	columns := []string{"from_currency", "to_currency", "rate", "updated_at"}
+
+	mock.ExpectQuery(`SELECT (.+) FROM exchange_rates WHERE from_currency = \$1 AND to_currency = \$2`).
+		WithArgs("EUR", "RUB").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("EUR", "RUB", 98.5, updatedAt))
+	mock.ExpectQuery(`SELECT (.+) FROM exchange_rates WHERE from_currency = \$1 AND to_currency = \$2`).
+		WithArgs("USD", "EUR").
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	rate, err := exchangeRateRepo.GetExchangeRate("EUR", "RUB")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ExchangeRate{FromCurrency: "EUR", ToCurrency: "RUB", Rate: 98.5, UpdatedAt: updatedAt}, rate)
+
+	_, err = exchangeRateRepo.GetExchangeRate("USD", "EUR")
+	assert.ErrorIs(t, err, models.ErrExchangeRateNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestDeleteExchangeRate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+
+	mock.ExpectExec(`DELETE FROM exchange_rates WHERE from_currency = \$1 AND to_currency = \$2`).
+		WithArgs("EUR", "RUB").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM exchange_rates`).
+		WithArgs("USD", "EUR").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, exchangeRateRepo.DeleteExchangeRate("EUR", "RUB"))
+	assert.ErrorIs(t, exchangeRateRepo.DeleteExchangeRate("USD", "EUR"), models.ErrExchangeRateNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestAssignBaseCurrency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	rateRepo := repository.NewExchangeRateRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE products SET currency = \$1 WHERE currency IS NULL`).
+		WithArgs("USD").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE orders SET currency = \$1, exchange_rate = 1, base_total_price = total_price WHERE currency IS NULL`).
+		WithArgs("USD").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	assigned, err := rateRepo.AssignBaseCurrency("USD")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, assigned)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
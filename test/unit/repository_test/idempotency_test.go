@@ -0,0 +1,119 @@
+package repository_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+
+	now := time.Now()
+	record := &models.IdempotencyRecord{
+		UserID:      1,
+		Key:         "key-1",
+		RequestHash: "hash",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(24 * time.Hour),
+	}
+
+	// Новый ключ резервируется
+	mock.ExpectQuery(`INSERT INTO idempotency_keys (.+) ON CONFLICT`).
+		WithArgs(1, "key-1", "hash", record.CreatedAt, record.ExpiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+
+	existing, err := idempotencyRepo.ReserveIdempotencyKey(record)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Действующий ключ не перезаписывается, возвращается сохраненный ответ
+	mock.ExpectQuery(`INSERT INTO idempotency_keys (.+) ON CONFLICT`).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT (.+) FROM idempotency_keys`).
+		WithArgs(1, "key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "response_status", "response_headers", "response_body", "created_at", "expires_at"}).
+			AddRow("hash", 201, []byte(`{"ETag":"\"1\""}`), []byte(`{"ID":1}`), now, record.ExpiresAt))
+
+	existing, err = idempotencyRepo.ReserveIdempotencyKey(record)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, existing.ResponseStatus)
+	assert.Equal(t, []byte(`{"ID":1}`), existing.ResponseBody)
+	assert.Equal(t, map[string]string{"ETag": `"1"`}, existing.ResponseHeaders)
+
+	// Исходный запрос еще выполняется: статус ответа не сохранен
+	mock.ExpectQuery(`INSERT INTO idempotency_keys (.+) ON CONFLICT`).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT (.+) FROM idempotency_keys`).
+		WithArgs(1, "key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "response_status", "response_headers", "response_body", "created_at", "expires_at"}).
+			AddRow("hash", nil, nil, nil, now, record.ExpiresAt))
+
+	existing, err = idempotencyRepo.ReserveIdempotencyKey(record)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, existing.ResponseStatus)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestSaveIdempotencyResponse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+
+	mock.ExpectExec(`UPDATE idempotency_keys SET response_status = \$1, response_headers = \$2, response_body = \$3`).
+		WithArgs(201, []byte(`{"ETag":"\"1\"","Location":"/orders/1"}`), []byte(`{}`), 1, "key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM idempotency_keys`).
+		WithArgs(1, "key-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = idempotencyRepo.SaveIdempotencyResponse(1, "key-1", 201, map[string]string{"ETag": `"1"`, "Location": "/orders/1"}, []byte(`{}`))
+	assert.NoError(t, err)
+
+	err = idempotencyRepo.DeleteIdempotencyKey(1, "key-2")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestDeleteExpiredIdempotencyKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+
+	now := time.Now()
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE expires_at <= \$1`).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	deleted, err := idempotencyRepo.DeleteExpiredIdempotencyKeys(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, deleted)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
@@ -3,12 +3,22 @@ package repository_test
 import (
 	"TestTask/internal/models"
 	"TestTask/internal/repository"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
 )
 
+// orderRowColumns колонки, которые репозиторий выбирает для каждого заказа
+var orderRowColumns = []string{
+	"id", "customer_id", "customer_name", "status", "total_price", "created_at", "updated_at", "is_deleted", "version", "user_id",
+	"coupon_code", "discount_amount", "region", "subtotal", "tax_amount", "currency", "exchange_rate", "base_total_price",
+}
+
 func TestCreateOrder(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -18,24 +28,230 @@ func TestCreateOrder(t *testing.T) {
 
 	orderRepo := repository.NewOrderRepository(db)
 
+	order := &models.Order{
+		CustomerID:     4,
+		CustomerName:   "John Doe",
+		Status:         "pending",
+		Region:         "DE",
+		Currency:       "EUR",
+		Subtotal:       9999,
+		TaxAmount:      1900,
+		TotalPrice:     11899,
+		ExchangeRate:   98.5,
+		BaseTotalPrice: 1172052,
+		UserID:         5,
+		Items: []models.OrderItem{
+			{ProductID: 1, Quantity: 1, UnitPrice: 4999},
+			{ProductID: 2, Quantity: 2, UnitPrice: 2500},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT quantity FROM products WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(5))
+	mock.ExpectExec(`UPDATE products SET quantity = quantity - \$1`).
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT quantity FROM products WHERE id = \$1 FOR UPDATE`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(2))
+	mock.ExpectExec(`UPDATE products SET quantity = quantity - \$1`).
+		WithArgs(2, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO orders`).
+		WithArgs(order.CustomerName, order.Status, order.TotalPrice, sql.NullInt64{Int64: 5, Valid: true}, sql.NullString{}, models.Money(0),
+			order.Region, order.Subtotal, order.TaxAmount, order.Currency, order.ExchangeRate, order.BaseTotalPrice,
+			sql.NullInt64{Int64: 4, Valid: true}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "version"}).AddRow(1, time.Now(), time.Now(), 1))
+	mock.ExpectQuery(`INSERT INTO order_items`).
+		WithArgs(1, 1, 1, models.Money(4999)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectQuery(`INSERT INTO order_items`).
+		WithArgs(1, 2, 2, models.Money(2500)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectCommit()
+
+	err = orderRepo.CreateOrder(order)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, order.ID)
+	assert.Equal(t, 10, order.Items[0].ID)
+	assert.Equal(t, 1, order.Items[1].OrderID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+
+}
+
+func TestCreateOrderWithCoupon(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	newOrder := func() *models.Order {
+		return &models.Order{
+			CustomerName:   "John Doe",
+			Status:         "pending",
+			Currency:       "RUB",
+			ExchangeRate:   1,
+			Subtotal:       8000,
+			DiscountAmount: 800,
+			TaxAmount:      1440,
+			TotalPrice:     8640,
+			BaseTotalPrice: 8640,
+			UserID:         5,
+			CouponCode:     "WELCOME10",
+			Items:          []models.OrderItem{{ProductID: 1, Quantity: 2, UnitPrice: 4000}},
+		}
+	}
+	expectStock := func() {
+		mock.ExpectQuery(`SELECT quantity FROM products`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(10))
+		mock.ExpectExec(`UPDATE products`).
+			WithArgs(2, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	couponRow := func(usedCount int) *sqlmock.Rows {
+		return sqlmock.NewRows(couponRowColumns).
+			AddRow(3, "WELCOME10", "percentage", 10.0, 50.0, nil, nil, 100, 1, usedCount, false, time.Now(), time.Now())
+	}
+
+	// Скидка рассчитана сервисом, репозиторий проверяет купон под блокировкой
+	// и списывает использование в той же транзакции
+	order := newOrder()
+	mock.ExpectBegin()
+	expectStock()
+	mock.ExpectQuery(`SELECT (.+) FROM coupons WHERE code = \$1 FOR UPDATE`).
+		WithArgs("WELCOME10").
+		WillReturnRows(couponRow(4))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM coupon_redemptions`).
+		WithArgs(3, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`UPDATE coupons SET used_count = used_count \+ 1`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO orders`).
+		WithArgs("John Doe", "pending", models.Money(8640), sql.NullInt64{Int64: 5, Valid: true}, sql.NullString{String: "WELCOME10", Valid: true}, models.Money(800),
+			"", models.Money(8000), models.Money(1440), "RUB", 1.0, models.Money(8640), sql.NullInt64{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "version"}).AddRow(1, time.Now(), time.Now(), 1))
+	mock.ExpectExec(`INSERT INTO coupon_redemptions`).
+		WithArgs(3, 1, sql.NullInt64{Int64: 5, Valid: true}, models.Money(800)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO order_items`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectCommit()
+
+	err = orderRepo.CreateOrder(order)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(8640), order.TotalPrice)
+
+	// Пользователь уже использовал купон максимальное количество раз
+	order = newOrder()
+	mock.ExpectBegin()
+	expectStock()
+	mock.ExpectQuery(`SELECT (.+) FROM coupons WHERE code = \$1 FOR UPDATE`).
+		WithArgs("WELCOME10").
+		WillReturnRows(couponRow(5))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM coupon_redemptions`).
+		WithArgs(3, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	err = orderRepo.CreateOrder(order)
+	assert.ErrorIs(t, err, models.ErrCouponNotApplicable)
+
+	// Неизвестный код купона
+	order = newOrder()
+	mock.ExpectBegin()
+	expectStock()
+	mock.ExpectQuery(`SELECT (.+) FROM coupons WHERE code = \$1 FOR UPDATE`).
+		WithArgs("WELCOME10").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = orderRepo.CreateOrder(order)
+	assert.ErrorIs(t, err, models.ErrCouponNotApplicable)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestCreateOrderRollsBackOnItemError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
 	order := &models.Order{
 		CustomerName: "John Doe",
 		Status:       "pending",
-		TotalPrice:   99.99,
-		ProductID:    1,
+		TotalPrice:   9999,
+		Items:        []models.OrderItem{{ProductID: 42, Quantity: 1, UnitPrice: 9999}},
 	}
 
-	mock.ExpectExec(`INSERT INTO orders`).
-		WithArgs(order.CustomerName, order.Status, order.TotalPrice, order.ProductID).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT quantity FROM products`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(10))
+	mock.ExpectExec(`UPDATE products`).
+		WithArgs(1, 42).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO orders`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "version"}).AddRow(1, time.Now(), time.Now(), 1))
+	mock.ExpectQuery(`INSERT INTO order_items`).
+		WillReturnError(fmt.Errorf("foreign key violation"))
+	mock.ExpectRollback()
 
 	err = orderRepo.CreateOrder(order)
-	assert.NoError(t, err)
+	assert.Error(t, err)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unmet expectations: %v", err)
 	}
+}
+
+func TestCreateOrderOutOfStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
 
+	orderRepo := repository.NewOrderRepository(db)
+
+	// Две позиции одного продукта суммируются при проверке остатка
+	order := &models.Order{
+		CustomerName: "John Doe",
+		Status:       "pending",
+		TotalPrice:   9999,
+		Items: []models.OrderItem{
+			{ProductID: 1, Quantity: 2, UnitPrice: 2000},
+			{ProductID: 1, Quantity: 2, UnitPrice: 2000},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT quantity FROM products`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+	mock.ExpectRollback()
+
+	err = orderRepo.CreateOrder(order)
+	assert.ErrorIs(t, err, models.ErrOutOfStock)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
 }
 
 func TestUpdateOrder(t *testing.T) {
@@ -51,17 +267,319 @@ func TestUpdateOrder(t *testing.T) {
 		ID:           1,
 		CustomerName: "Jane Doe",
 		Status:       "confirmed",
-		TotalPrice:   119.99,
+		TotalPrice:   11999,
 		UpdatedAt:    time.Now(),
+		Version:      3,
 	}
 
-	mock.ExpectExec(`UPDATE orders`).
-		WithArgs(order.CustomerName, order.Status, order.TotalPrice, order.UpdatedAt, order.ID).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	change := &models.OrderStatusChange{
+		OrderID:   1,
+		OldStatus: "pending",
+		NewStatus: "confirmed",
+		ChangedBy: 7,
+		CreatedAt: order.UpdatedAt,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders (.+) FOR UPDATE`).
+		WithArgs(order.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("pending"))
+	mock.ExpectQuery(`UPDATE orders (.+) RETURNING version`).
+		WithArgs(order.CustomerName, order.Status, order.TotalPrice, order.UpdatedAt, order.ID, 3, sql.NullInt64{}).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+	mock.ExpectQuery(`INSERT INTO order_status_history`).
+		WithArgs(1, "pending", "confirmed", sql.NullInt64{Int64: 7, Valid: true}, sql.NullString{}, order.UpdatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(100))
+	mock.ExpectCommit()
+
+	err = orderRepo.UpdateOrder(order, "pending", change)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, change.ID)
+	assert.Equal(t, 4, order.Version)
+
+	// Без смены статуса история не пишется
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders (.+) FOR UPDATE`).
+		WithArgs(order.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("confirmed"))
+	mock.ExpectQuery(`UPDATE orders (.+) RETURNING version`).
+		WithArgs(order.CustomerName, order.Status, order.TotalPrice, order.UpdatedAt, order.ID, 4, sql.NullInt64{}).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(5))
+	mock.ExpectCommit()
 
-	err = orderRepo.UpdateOrder(order)
+	err = orderRepo.UpdateOrder(order, "confirmed", nil)
 	assert.NoError(t, err)
 
+	// Заказ уже изменен другим запросом: версия не совпадает
+	order.Version = 4
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders (.+) FOR UPDATE`).
+		WithArgs(order.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("confirmed"))
+	mock.ExpectQuery(`UPDATE orders (.+) RETURNING version`).
+		WithArgs(order.CustomerName, order.Status, order.TotalPrice, order.UpdatedAt, order.ID, 4, sql.NullInt64{}).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = orderRepo.UpdateOrder(order, "confirmed", nil)
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+
+	// Без проверки версии статус успел измениться параллельным запросом: переход проверялся для другого статуса
+	order.Version = 0
+	order.Status = "shipped"
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders (.+) FOR UPDATE`).
+		WithArgs(order.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("cancelled"))
+	mock.ExpectRollback()
+
+	err = orderRepo.UpdateOrder(order, "confirmed", nil)
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	updatedAt := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE orders (.+) RETURNING version`).
+		WithArgs("confirmed", updatedAt, 1, "pending").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectQuery(`INSERT INTO order_status_history`).
+		WithArgs(1, "pending", "confirmed", sql.NullInt64{Int64: 7, Valid: true}, sql.NullString{String: "Payment received", Valid: true}, updatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(100))
+	mock.ExpectCommit()
+
+	version, err := orderRepo.UpdateOrderStatus(&models.OrderStatusChange{
+		OrderID: 1, OldStatus: "pending", NewStatus: "confirmed", ChangedBy: 7, Reason: "Payment received", CreatedAt: updatedAt,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	// Статус уже изменен другим запросом
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE orders (.+) RETURNING version`).
+		WithArgs("confirmed", updatedAt, 1, "pending").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = orderRepo.UpdateOrderStatus(&models.OrderStatusChange{
+		OrderID: 1, OldStatus: "pending", NewStatus: "confirmed", ChangedBy: 7, CreatedAt: updatedAt,
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	// При отмене товар возвращается на склад
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE orders (.+) RETURNING version`).
+		WithArgs("cancelled", updatedAt, 1, "confirmed").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectExec(`UPDATE products p`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`INSERT INTO order_status_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(101))
+	mock.ExpectCommit()
+
+	_, err = orderRepo.UpdateOrderStatus(&models.OrderStatusChange{
+		OrderID: 1, OldStatus: "confirmed", NewStatus: "cancelled", ChangedBy: 7, CreatedAt: updatedAt,
+	})
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	cancelledAt := time.Now()
+	change := &models.OrderStatusChange{
+		OrderID: 1, OldStatus: "pending", NewStatus: "cancelled", ChangedBy: 7, Reason: "payment_failed", CreatedAt: cancelledAt,
+	}
+	cancellation := &models.OrderCancellation{
+		OrderID: 1, ReasonCode: "payment_failed", Note: "Card declined", CancelledBy: 7, CancelledAt: cancelledAt,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE orders (.+) RETURNING version`).
+		WithArgs("cancelled", cancelledAt, 1, "pending").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec(`UPDATE products p`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO order_status_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(100))
+	mock.ExpectExec(`INSERT INTO order_cancellations`).
+		WithArgs(1, "payment_failed", sql.NullString{String: "Card declined", Valid: true}, sql.NullInt64{Int64: 7, Valid: true}, cancelledAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	version, err := orderRepo.CancelOrder(change, cancellation)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	// Заказ уже отменен или отгружен другим запросом
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE orders (.+) RETURNING version`).
+		WithArgs("cancelled", cancelledAt, 1, "pending").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = orderRepo.CancelOrder(change, cancellation)
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestCancelStalePendingOrders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	cancelledAt := time.Now()
+	createdBefore := cancelledAt.Add(-72 * time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(true))
+	mock.ExpectQuery(`UPDATE orders (.+) FOR UPDATE SKIP LOCKED\s+\)\s+RETURNING id`).
+		WithArgs("cancelled", cancelledAt, "pending", createdBefore, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	for _, orderID := range []int{1, 2} {
+		mock.ExpectExec(`UPDATE products p`).
+			WithArgs(orderID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO order_status_history`).
+			WithArgs(orderID, "pending", "cancelled", sql.NullInt64{}, sql.NullString{String: "expired", Valid: true}, cancelledAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(100 + orderID))
+	}
+	mock.ExpectExec(`INSERT INTO order_cancellations`).
+		WithArgs(pq.Array([]int{1, 2}), "expired", cancelledAt).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	orderIDs, err := orderRepo.CancelStalePendingOrders(createdBefore, cancelledAt, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, orderIDs)
+
+	// Блокировку держит другая реплика
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(false))
+	mock.ExpectRollback()
+
+	orderIDs, err = orderRepo.CancelStalePendingOrders(createdBefore, cancelledAt, 100)
+	assert.NoError(t, err)
+	assert.Empty(t, orderIDs)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetOrderStatusHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	changedAt := time.Now()
+
+	mock.ExpectQuery(`SELECT (.+) FROM order_status_history`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "old_status", "new_status", "changed_by", "reason", "created_at"}).
+			AddRow(1, 1, "pending", "confirmed", 7, nil, changedAt).
+			AddRow(2, 1, "confirmed", "cancelled", nil, "Customer request", changedAt))
+
+	history, err := orderRepo.GetOrderStatusHistory(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.OrderStatusChange{
+		{ID: 1, OrderID: 1, OldStatus: "pending", NewStatus: "confirmed", ChangedBy: 7, CreatedAt: changedAt},
+		{ID: 2, OrderID: 1, OldStatus: "confirmed", NewStatus: "cancelled", Reason: "Customer request", CreatedAt: changedAt},
+	}, history)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestCreateOrderNote(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	createdAt := time.Now()
+	note := &models.OrderNote{OrderID: 1, AuthorID: 7, Text: "Fraud check passed", IsInternal: true}
+
+	mock.ExpectQuery(`INSERT INTO order_notes \(order_id, author_id, text, is_internal\)`).
+		WithArgs(1, sql.NullInt64{Int64: 7, Valid: true}, "Fraud check passed", true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, createdAt))
+
+	err = orderRepo.CreateOrderNote(note)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, note.ID)
+	assert.Equal(t, createdAt, note.CreatedAt)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetOrderNotes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	createdAt := time.Now()
+
+	mock.ExpectQuery(`SELECT (.+) FROM order_notes WHERE order_id = \$1 AND \(\$2 OR NOT is_internal\)`).
+		WithArgs(1, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "author_id", "text", "is_internal", "created_at"}).
+			AddRow(2, 1, 3, "Please call before delivery", false, createdAt).
+			AddRow(3, 1, nil, "Delivered to the neighbour", false, createdAt))
+
+	notes, err := orderRepo.GetOrderNotes(1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.OrderNote{
+		{ID: 2, OrderID: 1, AuthorID: 3, Text: "Please call before delivery", CreatedAt: createdAt},
+		{ID: 3, OrderID: 1, Text: "Delivered to the neighbour", CreatedAt: createdAt},
+	}, notes)
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unmet expectations: %v", err)
 	}
@@ -78,13 +596,122 @@ func TestDeleteOrder(t *testing.T) {
 
 	orderID := 1
 
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders (.+) FOR UPDATE`).
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("pending"))
 	mock.ExpectExec(`UPDATE orders`).
 		WithArgs(orderID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE products p`).
+		WithArgs(orderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	err = orderRepo.DeleteOrder(orderID)
 	assert.NoError(t, err)
 
+	// Отгруженный заказ не возвращает товар на склад
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders (.+) FOR UPDATE`).
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("shipped"))
+	mock.ExpectExec(`UPDATE orders`).
+		WithArgs(orderID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = orderRepo.DeleteOrder(orderID)
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestRestoreOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	orderID := 1
+
+	// Неотгруженный заказ повторно резервирует товар
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders WHERE id = \$1 AND is_deleted = true FOR UPDATE`).
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("pending"))
+	mock.ExpectQuery(`SELECT (.+) FROM order_items`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "unit_price"}).
+			AddRow(1, orderID, 1, 2, 50.0))
+	mock.ExpectQuery(`SELECT quantity FROM products WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(5))
+	mock.ExpectExec(`UPDATE products SET quantity = quantity - \$1 WHERE id = \$2`).
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE orders SET is_deleted = false, deleted_at = NULL`).
+		WithArgs(orderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = orderRepo.RestoreOrder(orderID)
+	assert.NoError(t, err)
+
+	// Если товара уже не хватает, заказ остается удаленным
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders (.+) FOR UPDATE`).
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("confirmed"))
+	mock.ExpectQuery(`SELECT (.+) FROM order_items`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "unit_price"}).
+			AddRow(1, orderID, 1, 2, 50.0))
+	mock.ExpectQuery(`SELECT quantity FROM products`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(1))
+	mock.ExpectRollback()
+
+	err = orderRepo.RestoreOrder(orderID)
+	assert.ErrorIs(t, err, models.ErrOutOfStock)
+
+	// Удаленный заказ не найден
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM orders (.+) FOR UPDATE`).
+		WithArgs(orderID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = orderRepo.RestoreOrder(orderID)
+	assert.ErrorIs(t, err, models.ErrOrderNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestPurgeDeletedOrders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	before := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`DELETE FROM orders WHERE is_deleted = true AND deleted_at < \$1`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := orderRepo.PurgeDeletedOrders(before)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unmet expectations: %v", err)
 	}
@@ -104,15 +731,19 @@ func TestGetOrderByID(t *testing.T) {
 		ID:           1,
 		CustomerName: "John Doe",
 		Status:       "pending",
-		TotalPrice:   99.99,
-		ProductID:    1,
+		TotalPrice:   9999,
+		Items:        []models.OrderItem{{ID: 10, OrderID: 1, ProductID: 1, Quantity: 1, UnitPrice: 9999}},
 		IsDeleted:    false,
 	}
 
-	mock.ExpectQuery(`SELECT`).
+	mock.ExpectQuery(`SELECT (.+) FROM orders`).
 		WithArgs(orderID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_name", "status", "total_price", "product_id", "created_at", "updated_at", "is_deleted"}).
-			AddRow(order.ID, order.CustomerName, order.Status, order.TotalPrice, order.ProductID, time.Now(), time.Now(), order.IsDeleted))
+		WillReturnRows(sqlmock.NewRows(orderRowColumns).
+			AddRow(order.ID, nil, order.CustomerName, order.Status, order.TotalPrice, time.Now(), time.Now(), order.IsDeleted, 2, 3, nil, 0.0, "", order.TotalPrice, 0.0, "RUB", 1.0, order.TotalPrice))
+	mock.ExpectQuery(`SELECT (.+) FROM order_items`).
+		WithArgs(pq.Array([]int{orderID})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "unit_price"}).
+			AddRow(10, 1, 1, 1, 99.99))
 
 	result, err := orderRepo.GetOrderByID(orderID)
 	assert.NoError(t, err)
@@ -120,8 +751,180 @@ func TestGetOrderByID(t *testing.T) {
 	assert.Equal(t, order.CustomerName, result.CustomerName)
 	assert.Equal(t, order.Status, result.Status)
 	assert.Equal(t, order.TotalPrice, result.TotalPrice)
-	assert.Equal(t, order.ProductID, result.ProductID)
+	assert.Equal(t, order.Items, result.Items)
 	assert.Equal(t, order.IsDeleted, result.IsDeleted)
+	assert.Equal(t, 2, result.Version)
+	assert.Equal(t, 3, result.UserID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetOrdersByFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	createdAt := time.Date(2025, 1, 10, 11, 36, 3, 0, time.UTC)
+	filter := models.OrderFilter{Statuses: []string{"pending"}, MinPrice: 1000, Sort: "-created_at", Limit: 2}
+	statuses := pq.Array([]string{"pending"})
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders WHERE is_deleted = false AND status = ANY\(\$1\) AND base_total_price >= \$2`).
+		WithArgs(statuses, models.Money(1000)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`ORDER BY created_at DESC, id DESC\s+LIMIT \$3`).
+		WithArgs(statuses, models.Money(1000), 3).
+		WillReturnRows(sqlmock.NewRows(orderRowColumns).
+			AddRow(3, nil, "John Doe", "pending", 30.0, createdAt.Add(2*time.Minute), createdAt, false, 1, nil, nil, 0.0, "", 30.0, 0.0, "RUB", 1.0, 30.0).
+			AddRow(2, nil, "Jane Doe", "pending", 20.0, createdAt.Add(time.Minute), createdAt, false, 1, nil, nil, 0.0, "", 20.0, 0.0, "RUB", 1.0, 20.0).
+			AddRow(1, nil, "Jim Doe", "pending", 10.0, createdAt, createdAt, false, 1, nil, nil, 0.0, "", 10.0, 0.0, "RUB", 1.0, 10.0))
+	mock.ExpectQuery(`SELECT (.+) FROM order_items`).
+		WithArgs(pq.Array([]int{3, 2})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "unit_price"}).
+			AddRow(30, 3, 1, 1, 30.0).
+			AddRow(20, 2, 1, 1, 20.0))
+
+	page, err := orderRepo.GetOrdersByFilters(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.TotalCount)
+	assert.Len(t, page.Orders, 2)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.Len(t, page.Orders[1].Items, 1)
+
+	// Следующая страница продолжается с позиции последнего заказа
+	filter.Cursor = page.NextCursor
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders`).
+		WithArgs(statuses, models.Money(1000)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`\(created_at, id\) < \(\$3, \$4\)\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$5`).
+		WithArgs(statuses, models.Money(1000), createdAt.Add(time.Minute), 2, 3).
+		WillReturnRows(sqlmock.NewRows(orderRowColumns).
+			AddRow(1, nil, "Jim Doe", "pending", 10.0, createdAt, createdAt, false, 1, nil, nil, 0.0, "", 10.0, 0.0, "RUB", 1.0, 10.0))
+	mock.ExpectQuery(`SELECT (.+) FROM order_items`).
+		WithArgs(pq.Array([]int{1})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "unit_price"}))
+
+	page, err = orderRepo.GetOrdersByFilters(filter)
+	assert.NoError(t, err)
+	assert.Len(t, page.Orders, 1)
+	assert.Empty(t, page.NextCursor)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetOrdersByFiltersExtendedFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	createdFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdTo := time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC)
+	updatedSince := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	filter := models.OrderFilter{
+		Statuses:     []string{"pending", "confirmed"},
+		CreatedFrom:  createdFrom,
+		CreatedTo:    createdTo,
+		UpdatedSince: updatedSince,
+		ProductID:    7,
+		CustomerName: "50%_doe",
+		UserID:       3,
+		Sort:         "total_price",
+		Limit:        10,
+	}
+
+	countArgs := []driver.Value{
+		pq.Array([]string{"pending", "confirmed"}), createdFrom, createdTo, updatedSince, 7, `%50\%\_doe%`, 3,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders WHERE is_deleted = false AND status = ANY\(\$1\) ` +
+		`AND created_at >= \$2 AND created_at <= \$3 AND updated_at >= \$4 ` +
+		`AND EXISTS \(SELECT 1 FROM order_items oi WHERE oi.order_id = orders.id AND oi.product_id = \$5\) ` +
+		`AND customer_name ILIKE \$6 AND user_id = \$7`).
+		WithArgs(countArgs...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`ORDER BY base_total_price ASC, id ASC\s+LIMIT \$8`).
+		WithArgs(append(countArgs, 11)...).
+		WillReturnRows(sqlmock.NewRows(orderRowColumns))
+
+	page, err := orderRepo.GetOrdersByFilters(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, page.TotalCount)
+	assert.Empty(t, page.Orders)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestStreamOrdersByFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	createdAt := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	filter := models.OrderFilter{Statuses: []string{"pending"}, Sort: "-created_at"}
+
+	mock.ExpectQuery(`SELECT (.+) FROM orders LEFT JOIN LATERAL (.+) WHERE is_deleted = false AND status = ANY\(\$1\) ORDER BY created_at DESC, id DESC`).
+		WithArgs(pq.Array([]string{"pending"})).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, orderRowColumns...), "ids", "product_ids", "quantities", "unit_prices")).
+			AddRow(2, nil, "Jane Doe", "pending", 25.45, createdAt, createdAt, false, 1, 5, "SPRING", 2.5, "DE", 27.95, 0.0, "EUR", 0.9, 22.91, "{20,21}", "{1,2}", "{2,1}", "{10.1,5.25}").
+			AddRow(1, nil, "John Doe", "pending", 10.0, createdAt, createdAt, false, 1, nil, nil, 0.0, "", 10.0, 0.0, "RUB", 1.0, 10.0, nil, nil, nil, nil))
+
+	var orders []models.Order
+	err = orderRepo.StreamOrdersByFilters(filter, func(order *models.Order) error {
+		orders = append(orders, *order)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+	assert.Equal(t, []models.OrderItem{
+		{ID: 20, OrderID: 2, ProductID: 1, Quantity: 2, UnitPrice: 1010},
+		{ID: 21, OrderID: 2, ProductID: 2, Quantity: 1, UnitPrice: 525},
+	}, orders[0].Items)
+	assert.Empty(t, orders[1].Items)
+	assert.Equal(t, 5, orders[0].UserID)
+	assert.Equal(t, "SPRING", orders[0].CouponCode)
+	assert.Equal(t, models.Money(250), orders[0].DiscountAmount)
+	assert.Zero(t, orders[1].UserID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetOrdersByFiltersInvalidParameters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	orderRepo := repository.NewOrderRepository(db)
+
+	// Неизвестное поле сортировки
+	_, err = orderRepo.GetOrdersByFilters(models.OrderFilter{Sort: "customer_name", Limit: 10})
+	assert.ErrorIs(t, err, models.ErrInvalidFilter)
+
+	// Испорченный курсор
+	_, err = orderRepo.GetOrdersByFilters(models.OrderFilter{Sort: "total_price", Limit: 10, Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, models.ErrInvalidFilter)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unmet expectations: %v", err)
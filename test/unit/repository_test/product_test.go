@@ -3,6 +3,7 @@ package repository
 import (
 	"TestTask/internal/models"
 	"TestTask/internal/repository"
+	"database/sql"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -19,12 +20,14 @@ func TestCreateProduct(t *testing.T) {
 
 	product := &models.Product{
 		Name:     "Product",
-		Price:    99.99,
+		Price:    9999,
 		Quantity: 50,
+		Category: "books",
+		Currency: "RUB",
 	}
 
 	mock.ExpectExec(`INSERT INTO products`).
-		WithArgs(product.Name, product.Price, product.Quantity).
+		WithArgs(product.Name, product.Price, product.Quantity, product.Category, product.Currency).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = productRepo.CreateProduct(product)
@@ -48,12 +51,13 @@ func TestUpdateProduct(t *testing.T) {
 	product := &models.Product{
 		ID:       1,
 		Name:     "Updated Product",
-		Price:    15.99,
+		Price:    1599,
 		Quantity: 5,
+		Currency: "EUR",
 	}
 
 	mock.ExpectExec(`UPDATE products`).
-		WithArgs(product.Name, product.Price, product.Quantity, product.ID).
+		WithArgs(product.Name, product.Price, product.Quantity, product.Category, product.Currency, product.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = productRepo.UpdateProduct(product)
@@ -77,14 +81,16 @@ func TestGetProductByID(t *testing.T) {
 	expectedProduct := &models.Product{
 		ID:       1,
 		Name:     "Test Product",
-		Price:    12.99,
+		Price:    1299,
 		Quantity: 10,
+		Category: "food",
+		Currency: "RUB",
 	}
 
 	mock.ExpectQuery(`SELECT id, name, price, quantity`).
 		WithArgs(productID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "quantity"}).
-			AddRow(expectedProduct.ID, expectedProduct.Name, expectedProduct.Price, expectedProduct.Quantity))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "quantity", "category", "currency"}).
+			AddRow(expectedProduct.ID, expectedProduct.Name, expectedProduct.Price, expectedProduct.Quantity, expectedProduct.Category, expectedProduct.Currency))
 
 	result, err := productRepo.GetProductByID(productID)
 	assert.NoError(t, err)
@@ -105,14 +111,14 @@ func TestGetAllProducts(t *testing.T) {
 	productRepo := repository.NewProductRepository(db)
 
 	expectedProducts := []models.Product{
-		{ID: 1, Name: "Product 1", Price: 10.99, Quantity: 5},
-		{ID: 2, Name: "Product 2", Price: 20.99, Quantity: 2},
+		{ID: 1, Name: "Product 1", Price: 1099, Quantity: 5, Category: "books", Currency: "RUB"},
+		{ID: 2, Name: "Product 2", Price: 2099, Quantity: 2, Currency: "USD"},
 	}
 
 	mock.ExpectQuery(`SELECT id, name, price, quantity`).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "quantity"}).
-			AddRow(expectedProducts[0].ID, expectedProducts[0].Name, expectedProducts[0].Price, expectedProducts[0].Quantity).
-			AddRow(expectedProducts[1].ID, expectedProducts[1].Name, expectedProducts[1].Price, expectedProducts[1].Quantity))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "quantity", "category", "currency"}).
+			AddRow(expectedProducts[0].ID, expectedProducts[0].Name, expectedProducts[0].Price, expectedProducts[0].Quantity, expectedProducts[0].Category, expectedProducts[0].Currency).
+			AddRow(expectedProducts[1].ID, expectedProducts[1].Name, expectedProducts[1].Price, expectedProducts[1].Quantity, expectedProducts[1].Category, expectedProducts[1].Currency))
 
 	result, err := productRepo.GetAllProducts()
 	assert.NoError(t, err)
@@ -145,3 +151,49 @@ func TestDeleteProduct(t *testing.T) {
 		t.Errorf("there were unmet expectations: %v", err)
 	}
 }
+
+func TestImportProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewProductRepository(db)
+
+	rows := []models.ProductImportRow{
+		{Row: 2, Product: models.Product{Name: "Product A", Price: 9999, Quantity: 10, Currency: "RUB"}},
+		{Row: 3, Product: models.Product{ID: 7, Name: "Product B", Price: 4950, Quantity: 3, Currency: "EUR", Category: "books"}, HasCategory: true},
+		{Row: 4, Product: models.Product{ID: 8, Name: "Product C", Price: 1000, Quantity: 1}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TEMP TABLE product_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+	copyStmt := mock.ExpectPrepare(`COPY "product_import"`)
+	copyStmt.ExpectExec().WithArgs(2, sql.NullInt64{}, "Product A", models.Money(9999), 10, "RUB", sql.NullString{}, true).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyStmt.ExpectExec().WithArgs(3, sql.NullInt64{Int64: 7, Valid: true}, "Product B", models.Money(4950), 3, "EUR", sql.NullString{String: "books", Valid: true}, false).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyStmt.ExpectExec().WithArgs(4, sql.NullInt64{Int64: 8, Valid: true}, "Product C", models.Money(1000), 1, "", sql.NullString{}, false).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyStmt.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`UPDATE product_import SET id = nextval`).
+		WillReturnRows(sqlmock.NewRows([]string{"row_num", "id"}).AddRow(2, 11))
+	mock.ExpectExec(`INSERT INTO products (.+) SELECT (.+) FROM product_import`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Продукта с id 8 нет в каталоге. Пустая валюта и отсутствующая категория не меняют значения продукта.
+	mock.ExpectQuery(`UPDATE products p (.+) currency = COALESCE\(NULLIF\(i.currency, ''\), p.currency\), category = COALESCE\(i.category, p.category\) FROM product_import i`).
+		WillReturnRows(sqlmock.NewRows([]string{"row_num", "id"}).AddRow(3, 7))
+	mock.ExpectCommit()
+
+	err = repo.ImportProducts(rows)
+	assert.NoError(t, err)
+
+	assert.Equal(t, models.ProductImportCreated, rows[0].Status)
+	assert.Equal(t, 11, rows[0].ProductID)
+	assert.Equal(t, models.ProductImportUpdated, rows[1].Status)
+	assert.Equal(t, 7, rows[1].ProductID)
+	assert.Equal(t, models.ProductImportFailed, rows[2].Status)
+	assert.Equal(t, "no product found with id 8", rows[2].Error)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package repository_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetTaxRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	taxRuleRepo := repository.NewTaxRuleRepository(db)
+
+	mock.ExpectQuery(`SELECT id, region, category, rate, inclusive FROM tax_rules WHERE region = '' OR region = \$1`).
+		WithArgs("DE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "region", "category", "rate", "inclusive"}).
+			AddRow(1, "", "", 0.2, false).
+			AddRow(2, "DE", "books", 0.07, true))
+
+	rules, err := taxRuleRepo.GetTaxRules("DE")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TaxRule{
+		{ID: 1, Rate: 0.2},
+		{ID: 2, Region: "DE", Category: "books", Rate: 0.07, Inclusive: true},
+	}, rules)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package repository_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var webhookRowColumns = []string{"id", "url", "secret", "event_types", "is_active", "created_at", "updated_at"}
+
+var webhookDeliveryRowColumns = []string{"id", "webhook_id", "event_type", "payload", "status", "attempts", "response_status", "last_error", "created_at", "updated_at"}
+
+func TestCreateWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	webhookRepo := repository.NewWebhookRepository(db)
+
+	createdAt := time.Date(2025, 2, 5, 10, 0, 0, 0, time.UTC)
+	webhook := &models.Webhook{
+		URL:        "https://partner.example.com/hooks",
+		Secret:     "secret",
+		EventTypes: []string{"order_status_changed", "order_cancelled"},
+		IsActive:   true,
+	}
+
+	mock.ExpectQuery(`INSERT INTO webhooks \(url, secret, event_types, is_active\)`).
+		WithArgs(webhook.URL, webhook.Secret, "{\"order_status_changed\",\"order_cancelled\"}", true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, createdAt, createdAt))
+
+	err = webhookRepo.CreateWebhook(webhook)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, webhook.ID)
+	assert.Equal(t, createdAt, webhook.CreatedAt)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetActiveWebhooksByEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	webhookRepo := repository.NewWebhookRepository(db)
+
+	createdAt := time.Date(2025, 2, 5, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM webhooks WHERE is_active AND \$1 = ANY\(event_types\) ORDER BY id`).
+		WithArgs("order_cancelled").
+		WillReturnRows(sqlmock.NewRows(webhookRowColumns).
+			AddRow(1, "https://partner.example.com/hooks", "secret", "{order_status_changed,order_cancelled}", true, createdAt, createdAt))
+
+	webhooks, err := webhookRepo.GetActiveWebhooksByEvent("order_cancelled")
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+	assert.Equal(t, []string{"order_status_changed", "order_cancelled"}, webhooks[0].EventTypes)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetWebhookByIDNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	webhookRepo := repository.NewWebhookRepository(db)
+
+	mock.ExpectQuery(`SELECT (.+) FROM webhooks WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = webhookRepo.GetWebhookByID(7)
+	assert.ErrorIs(t, err, models.ErrWebhookNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestCreateAndUpdateWebhookDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	webhookRepo := repository.NewWebhookRepository(db)
+
+	createdAt := time.Date(2025, 2, 5, 10, 0, 0, 0, time.UTC)
+	delivery := &models.WebhookDelivery{
+		WebhookID: 1,
+		EventType: "order_cancelled",
+		Payload:   []byte(`{"event":"order_cancelled"}`),
+		Status:    models.WebhookDeliveryPending,
+	}
+
+	mock.ExpectQuery(`INSERT INTO webhook_deliveries \(webhook_id, event_type, payload, status\)`).
+		WithArgs(1, "order_cancelled", []byte(`{"event":"order_cancelled"}`), models.WebhookDeliveryPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(10, createdAt, createdAt))
+
+	err = webhookRepo.CreateWebhookDelivery(delivery)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, delivery.ID)
+
+	delivery.Status = models.WebhookDeliveryFailed
+	delivery.Attempts = 1
+	delivery.LastError = "connection refused"
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries SET status = \$1, attempts = \$2, response_status = \$3, last_error = \$4`).
+		WithArgs(models.WebhookDeliveryFailed, 1, sql.NullInt64{}, sql.NullString{String: "connection refused", Valid: true}, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(createdAt.Add(time.Minute)))
+
+	err = webhookRepo.UpdateWebhookDelivery(delivery)
+	assert.NoError(t, err)
+	assert.Equal(t, createdAt.Add(time.Minute), delivery.UpdatedAt)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	webhookRepo := repository.NewWebhookRepository(db)
+
+	createdAt := time.Date(2025, 2, 5, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM webhook_deliveries WHERE webhook_id = \$1 ORDER BY created_at DESC`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(webhookDeliveryRowColumns).
+			AddRow(11, 1, "order_cancelled", []byte(`{"event":"order_cancelled"}`), models.WebhookDeliverySucceeded, 1, 200, nil, createdAt, createdAt).
+			AddRow(10, 1, "order_cancelled", []byte(`{"event": "order_cancelled", "data": {"order_id": 5}}`), models.WebhookDeliveryFailed, 5, nil, "connection refused", createdAt, createdAt))
+
+	deliveries, err := webhookRepo.GetWebhookDeliveries(1)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, 200, deliveries[0].ResponseStatus)
+	assert.Empty(t, deliveries[0].LastError)
+	assert.Equal(t, 0, deliveries[1].ResponseStatus)
+	assert.Equal(t, "connection refused", deliveries[1].LastError)
+	// Тело читается без изменений порядка ключей и пробелов, чтобы повторная отправка совпадала с исходной
+	assert.Equal(t, `{"event": "order_cancelled", "data": {"order_id": 5}}`, string(deliveries[1].Payload))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
+
+func TestClaimStalePendingDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("could not create mock database: %v", err)
+	}
+	defer db.Close()
+
+	webhookRepo := repository.NewWebhookRepository(db)
+
+	createdAt := time.Date(2025, 2, 5, 10, 0, 0, 0, time.UTC)
+	staleBefore := createdAt.Add(time.Hour)
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries SET updated_at = NOW\(\) WHERE id IN \( SELECT id FROM webhook_deliveries WHERE status = \$1 AND updated_at < \$2 ORDER BY id LIMIT \$3 FOR UPDATE SKIP LOCKED \) RETURNING`).
+		WithArgs(models.WebhookDeliveryPending, staleBefore, 100).
+		WillReturnRows(sqlmock.NewRows(webhookDeliveryRowColumns).
+			AddRow(10, 1, "order_cancelled", []byte(`{"event":"order_cancelled"}`), models.WebhookDeliveryPending, 2, 500, "unexpected response status 500", createdAt, staleBefore))
+
+	deliveries, err := webhookRepo.ClaimStalePendingDeliveries(staleBefore, 100)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unmet expectations: %v", err)
+	}
+}
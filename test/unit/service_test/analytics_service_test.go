@@ -0,0 +1,121 @@
+package service_test
+
+import (
+	"TestTask/internal/cache"
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+// MockAnalyticsRepository для мока AnalyticsRepositoryInterface
+type MockAnalyticsRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsRepository) GetRevenue(filter models.AnalyticsFilter) ([]models.RevenuePoint, error) {
+	args := m.Called(filter)
+	if result := args.Get(0); result != nil {
+		return result.([]models.RevenuePoint), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetOrdersByStatus(filter models.AnalyticsFilter) ([]models.OrderStatusStats, error) {
+	args := m.Called(filter)
+	if result := args.Get(0); result != nil {
+		return result.([]models.OrderStatusStats), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetTopProducts(filter models.AnalyticsFilter) ([]models.ProductSales, error) {
+	args := m.Called(filter)
+	if result := args.Get(0); result != nil {
+		return result.([]models.ProductSales), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestGetRevenueIsCached(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	analyticsService := service.NewAnalyticsService(mockRepo, cache.NewCacheService(), "RUB", time.Minute)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := []models.RevenuePoint{
+		{PeriodStart: from, Orders: 2, Revenue: models.Money(5000), AverageOrderValue: models.Money(2500)},
+	}
+	expectedFilter := models.AnalyticsFilter{From: from, Interval: models.AnalyticsIntervalDay}
+	mockRepo.On("GetRevenue", expectedFilter).Return(points, nil).Once()
+
+	report, err := analyticsService.GetRevenue(models.AnalyticsFilter{From: from})
+	assert.NoError(t, err)
+	assert.Equal(t, models.AnalyticsIntervalDay, report.Interval)
+	assert.Equal(t, "RUB", report.Currency)
+	assert.Equal(t, points, report.Points)
+
+	// Повторный запрос с теми же параметрами берется из кэша
+	report, err = analyticsService.GetRevenue(models.AnalyticsFilter{From: from, Interval: models.AnalyticsIntervalDay})
+	assert.NoError(t, err)
+	assert.Equal(t, points, report.Points)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRevenueInvalidFilter(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	analyticsService := service.NewAnalyticsService(mockRepo, cache.NewCacheService(), "RUB", time.Minute)
+
+	_, err := analyticsService.GetRevenue(models.AnalyticsFilter{Interval: "year"})
+	assert.ErrorIs(t, err, models.ErrInvalidFilter)
+
+	_, err = analyticsService.GetRevenue(models.AnalyticsFilter{
+		From: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidFilter)
+
+	mockRepo.AssertNotCalled(t, "GetRevenue", mock.Anything)
+}
+
+func TestGetOrdersByStatus(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	analyticsService := service.NewAnalyticsService(mockRepo, cache.NewCacheService(), "RUB", time.Minute)
+
+	stats := []models.OrderStatusStats{
+		{Status: models.OrderStatusPending, Orders: 3, Revenue: models.Money(12000)},
+	}
+	mockRepo.On("GetOrdersByStatus", models.AnalyticsFilter{}).Return(stats, nil).Once()
+
+	// Интервал и лимит не влияют на отчет по статусам и не попадают в ключ кэша
+	result, err := analyticsService.GetOrdersByStatus(models.AnalyticsFilter{Interval: models.AnalyticsIntervalWeek})
+	assert.NoError(t, err)
+	assert.Equal(t, stats, result)
+
+	result, err = analyticsService.GetOrdersByStatus(models.AnalyticsFilter{Limit: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, stats, result)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTopProducts(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	analyticsService := service.NewAnalyticsService(mockRepo, cache.NewCacheService(), "RUB", time.Minute)
+
+	products := []models.ProductSales{
+		{ProductID: 1, Name: "Product 1", Quantity: 4, Revenue: models.Money(8000)},
+	}
+	mockRepo.On("GetTopProducts", models.AnalyticsFilter{Limit: service.DefaultTopProductsLimit}).Return(products, nil).Once()
+
+	result, err := analyticsService.GetTopProducts(models.AnalyticsFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, products, result)
+
+	_, err = analyticsService.GetTopProducts(models.AnalyticsFilter{Limit: service.MaxTopProductsLimit + 1})
+	assert.ErrorIs(t, err, models.ErrInvalidFilter)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+// MockCouponRepository для мока CouponRepositoryInterface
+type MockCouponRepository struct {
+	mock.Mock
+}
+
+func (m *MockCouponRepository) CreateCoupon(coupon *models.Coupon) error {
+	args := m.Called(coupon)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) UpdateCoupon(coupon *models.Coupon) error {
+	args := m.Called(coupon)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) DeleteCoupon(couponID int) error {
+	args := m.Called(couponID)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) GetCouponByID(couponID int) (*models.Coupon, error) {
+	args := m.Called(couponID)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Coupon), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCouponRepository) GetCouponByCode(code string) (*models.Coupon, error) {
+	args := m.Called(code)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Coupon), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCouponRepository) GetAllCoupons() ([]models.Coupon, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]models.Coupon), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestCreateCoupon(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	couponService := service.NewCouponService(mockRepo)
+
+	coupon := &models.Coupon{Code: " welcome10 ", DiscountType: "percentage", DiscountValue: 1000}
+	mockRepo.On("CreateCoupon", coupon).Return(nil)
+
+	// Тест: код приводится к верхнему регистру
+	err := couponService.CreateCoupon(coupon)
+	assert.NoError(t, err)
+	assert.Equal(t, "WELCOME10", coupon.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateCouponValidation(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	couponService := service.NewCouponService(mockRepo)
+
+	validFrom := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	validTo := validFrom.Add(-time.Hour)
+
+	invalid := []models.Coupon{
+		{Code: "", DiscountType: "fixed", DiscountValue: 500},
+		{Code: "SALE", DiscountType: "bogo", DiscountValue: 500},
+		{Code: "SALE", DiscountType: "percentage", DiscountValue: 15000},
+		{Code: "SALE", DiscountType: "fixed", DiscountValue: 0},
+		{Code: "SALE", DiscountType: "fixed", DiscountValue: 500, MaxUses: -1},
+		{Code: "SALE", DiscountType: "fixed", DiscountValue: 500, ValidFrom: &validFrom, ValidTo: &validTo},
+	}
+
+	for _, coupon := range invalid {
+		err := couponService.CreateCoupon(&coupon)
+		assert.ErrorIs(t, err, models.ErrInvalidCouponData)
+	}
+
+	mockRepo.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+}
+
+func TestCouponCheckApplicable(t *testing.T) {
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+
+	coupon := &models.Coupon{Code: "FLAT15", DiscountType: "fixed", DiscountValue: 1500, MinOrderValue: 1000, MaxUses: 2, MaxUsesPerUser: 1}
+
+	// Фиксированная скидка не превышает сумму заказа
+	assert.NoError(t, coupon.CheckApplicable(now, 1200, 0))
+	assert.Equal(t, models.Money(1200), coupon.Discount(1200, 1))
+
+	// Сумма заказа меньше минимальной
+	assert.ErrorIs(t, coupon.CheckApplicable(now, 999, 0), models.ErrCouponNotApplicable)
+
+	// Пользователь исчерпал свой лимит
+	assert.ErrorIs(t, coupon.CheckApplicable(now, 2000, 1), models.ErrCouponNotApplicable)
+
+	// Общий лимит исчерпан
+	coupon.UsedCount = 2
+	assert.ErrorIs(t, coupon.CheckApplicable(now, 2000, 0), models.ErrCouponNotApplicable)
+
+	// Срок действия истек
+	coupon.UsedCount = 0
+	coupon.ValidTo = &expired
+	assert.ErrorIs(t, coupon.CheckApplicable(now, 2000, 0), models.ErrCouponNotApplicable)
+
+	// Процентная скидка округляется до копеек
+	percentage := &models.Coupon{Code: "SAVE15", DiscountType: "percentage", DiscountValue: 1500}
+	assert.Equal(t, models.Money(150), percentage.Discount(999, 1))
+}
@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+)
+
+// MockExchangeRateRepository для мока ExchangeRateRepositoryInterface
+type MockExchangeRateRepository struct {
+	mock.Mock
+}
+
+func (m *MockExchangeRateRepository) SaveExchangeRates(rates []models.ExchangeRate) error {
+	args := m.Called(rates)
+	return args.Error(0)
+}
+
+func (m *MockExchangeRateRepository) DeleteExchangeRate(fromCurrency, toCurrency string) error {
+	args := m.Called(fromCurrency, toCurrency)
+	return args.Error(0)
+}
+
+func (m *MockExchangeRateRepository) GetExchangeRate(fromCurrency, toCurrency string) (*models.ExchangeRate, error) {
+	args := m.Called(fromCurrency, toCurrency)
+	if result := args.Get(0); result != nil {
+		return result.(*models.ExchangeRate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockExchangeRateRepository) AssignBaseCurrency(baseCurrency string) (int, error) {
+	args := m.Called(baseCurrency)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockExchangeRateRepository) GetAllExchangeRates() ([]models.ExchangeRate, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]models.ExchangeRate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// newCurrencyConverter возвращает конвертер с базовой валютой RUB и заданными курсами; остальных курсов нет
+func newCurrencyConverter(rates ...models.ExchangeRate) *service.CurrencyConverter {
+	mockRepo := new(MockExchangeRateRepository)
+	for i := range rates {
+		mockRepo.On("GetExchangeRate", rates[i].FromCurrency, rates[i].ToCurrency).Return(&rates[i], nil).Maybe()
+	}
+	mockRepo.On("GetExchangeRate", mock.Anything, mock.Anything).Return(nil, models.ErrExchangeRateNotFound).Maybe()
+	return service.NewCurrencyConverter(mockRepo, models.CurrencyRUB)
+}
+
+func TestCurrencyConverterRate(t *testing.T) {
+	converter := newCurrencyConverter(
+		models.ExchangeRate{FromCurrency: "EUR", ToCurrency: "RUB", Rate: 100},
+		models.ExchangeRate{FromCurrency: "RUB", ToCurrency: "USD", Rate: 0.0125},
+	)
+
+	tests := []struct {
+		from, to string
+		rate     float64
+	}{
+		{"RUB", "RUB", 1},
+		{"EUR", "RUB", 100},  // прямой курс
+		{"RUB", "EUR", 0.01}, // обратный курс
+		{"EUR", "USD", 1.25}, // кросс-курс через базовую валюту
+		{"USD", "EUR", 0.8},  // кросс-курс из обратных курсов
+		{"USD", "RUB", 80},
+	}
+
+	for _, tt := range tests {
+		rate, err := converter.Rate(tt.from, tt.to)
+		assert.NoError(t, err, "%s/%s", tt.from, tt.to)
+		assert.InDelta(t, tt.rate, rate, 1e-9, "%s/%s", tt.from, tt.to)
+	}
+
+	amount, err := converter.Convert(1999, "EUR", "RUB")
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(199900), amount)
+}
+
+func TestCurrencyConverterCrossRateRoundsOnce(t *testing.T) {
+	converter := newCurrencyConverter(
+		models.ExchangeRate{FromCurrency: "USD", ToCurrency: "RUB", Rate: 91.25},
+		models.ExchangeRate{FromCurrency: "RUB", ToCurrency: "EUR", Rate: 0.011},
+	)
+
+	// 4.00 * 91.25 * 0.011 = 4.015, во float64 кросс-курс давал 4.01
+	amount, err := converter.Convert(400, "USD", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(402), amount)
+
+	rate, err := converter.Rate("USD", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.00375, rate)
+}
+
+func TestCurrencyConverterMissingRate(t *testing.T) {
+	converter := newCurrencyConverter(models.ExchangeRate{FromCurrency: "EUR", ToCurrency: "RUB", Rate: 100})
+
+	_, err := converter.Rate("USD", "EUR")
+	assert.ErrorIs(t, err, models.ErrExchangeRateNotFound)
+
+	_, err = converter.Convert(100, "USD", "RUB")
+	assert.ErrorIs(t, err, models.ErrExchangeRateNotFound)
+}
+
+func TestSetExchangeRate(t *testing.T) {
+	mockRepo := new(MockExchangeRateRepository)
+	exchangeRateService := service.NewExchangeRateService(mockRepo)
+
+	mockRepo.On("SaveExchangeRates", []models.ExchangeRate{{FromCurrency: "EUR", ToCurrency: "RUB", Rate: 98.5}}).Return(nil)
+
+	// Тест: коды валют приводятся к верхнему регистру
+	rate := &models.ExchangeRate{FromCurrency: " eur", ToCurrency: "rub", Rate: 98.5}
+	err := exchangeRateService.SetExchangeRate(rate)
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", rate.FromCurrency)
+
+	invalidRates := []models.ExchangeRate{
+		{FromCurrency: "EUR", ToCurrency: "GBP", Rate: 1},
+		{FromCurrency: "EUR", ToCurrency: "EUR", Rate: 1},
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: 0},
+	}
+	for _, invalid := range invalidRates {
+		err = exchangeRateService.SetExchangeRate(&invalid)
+		assert.ErrorIs(t, err, models.ErrInvalidExchangeRate, fmt.Sprintf("%+v", invalid))
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLoadExchangeRates(t *testing.T) {
+	mockRepo := new(MockExchangeRateRepository)
+	exchangeRateService := service.NewExchangeRateService(mockRepo)
+
+	mockRepo.On("SaveExchangeRates", []models.ExchangeRate{
+		{FromCurrency: "EUR", ToCurrency: "RUB", Rate: 98.5},
+		{FromCurrency: "USD", ToCurrency: "RUB", Rate: 91.25},
+	}).Return(nil)
+
+	loaded, err := exchangeRateService.LoadExchangeRates(strings.NewReader("from_currency,to_currency,rate\neur,RUB,98.5\nUSD, RUB, 91.25\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, loaded)
+
+	// Невалидная строка отменяет загрузку всего файла
+	_, err = exchangeRateService.LoadExchangeRates(strings.NewReader("from_currency,to_currency,rate\nEUR,RUB,98.5\nUSD,RUB,abc\n"))
+	assert.ErrorIs(t, err, models.ErrInvalidExchangeRate)
+	assert.Contains(t, err.Error(), "row 3")
+
+	_, err = exchangeRateService.LoadExchangeRates(strings.NewReader("currency,rate\nEUR,98.5\n"))
+	assert.ErrorIs(t, err, models.ErrInvalidExchangeRate)
+
+	mockRepo.On("SaveExchangeRates", mock.Anything).Return(errors.New("db error")).Once()
+	_, err = exchangeRateService.LoadExchangeRates(strings.NewReader("from_currency,to_currency,rate\nEUR,USD,1.1\n"))
+	assert.Error(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// MockCustomerRepository для мока CustomerRepositoryInterface
+type MockCustomerRepository struct {
+	mock.Mock
+}
+
+func (m *MockCustomerRepository) CreateCustomer(customer *models.Customer) error {
+	args := m.Called(customer)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepository) UpdateCustomer(customer *models.Customer) error {
+	args := m.Called(customer)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepository) DeleteCustomer(customerID int) error {
+	args := m.Called(customerID)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepository) GetCustomerByID(customerID int) (*models.Customer, error) {
+	args := m.Called(customerID)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Customer), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCustomerRepository) GetAllCustomers() ([]models.Customer, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]models.Customer), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCustomerRepository) FindOrCreateCustomerByName(name string) (*models.Customer, error) {
+	args := m.Called(name)
+	if fn, ok := args.Get(0).(func(string) *models.Customer); ok {
+		return fn(name), args.Error(1)
+	}
+	if result := args.Get(0); result != nil {
+		return result.(*models.Customer), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestCreateCustomer(t *testing.T) {
+	mockRepo := new(MockCustomerRepository)
+	customerService := service.NewCustomerService(mockRepo)
+
+	customer := &models.Customer{
+		Name:      " John Doe ",
+		Email:     "John.Doe@Example.com",
+		Addresses: []models.Address{{Line1: "Tverskaya st. 1", City: "Moscow", Country: "ru"}},
+	}
+	mockRepo.On("CreateCustomer", customer).Return(nil)
+
+	// Тест: имя и адрес нормализуются, email приводится к нижнему регистру
+	err := customerService.CreateCustomer(customer)
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", customer.Name)
+	assert.Equal(t, "john.doe@example.com", customer.Email)
+	assert.Equal(t, "RU", customer.Addresses[0].Country)
+
+	// Тест: без адресов сохраняется пустой список
+	withoutAddresses := &models.Customer{Name: "Jane Doe"}
+	mockRepo.On("CreateCustomer", withoutAddresses).Return(nil)
+	err = customerService.CreateCustomer(withoutAddresses)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Address{}, withoutAddresses.Addresses)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateCustomerValidation(t *testing.T) {
+	mockRepo := new(MockCustomerRepository)
+	customerService := service.NewCustomerService(mockRepo)
+
+	invalid := []models.Customer{
+		{Name: "  "},
+		{Name: "John Doe", Email: "not-an-email"},
+		{Name: "John Doe", Email: "John Doe <john@example.com>"},
+		{Name: "John Doe", Addresses: []models.Address{{Line1: "Tverskaya st. 1", Country: "RU"}}},
+	}
+
+	for _, customer := range invalid {
+		err := customerService.CreateCustomer(&customer)
+		assert.ErrorIs(t, err, models.ErrInvalidCustomerData, fmt.Sprintf("%+v", customer))
+	}
+
+	mockRepo.AssertNotCalled(t, "CreateCustomer", mock.Anything)
+}
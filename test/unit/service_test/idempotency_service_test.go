@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) ReserveIdempotencyKey(record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	args := m.Called(record)
+	if result := args.Get(0); result != nil {
+		return result.(*models.IdempotencyRecord), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) SaveIdempotencyResponse(userID int, key string, status int, headers map[string]string, body []byte) error {
+	args := m.Called(userID, key, status, headers, body)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) DeleteIdempotencyKey(userID int, key string) error {
+	args := m.Called(userID, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) DeleteExpiredIdempotencyKeys(now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func TestIdempotencyBegin(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour)
+
+	// Новый ключ: запрос нужно выполнить
+	mockRepo.On("ReserveIdempotencyKey", mock.MatchedBy(func(record *models.IdempotencyRecord) bool {
+		return record.Key == "new" && record.ExpiresAt.Sub(record.CreatedAt) == 24*time.Hour
+	})).Return(nil, nil)
+
+	stored, err := idempotencyService.Begin(1, "new", "hash")
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// Повтор с тем же телом: отдается сохраненный ответ
+	completed := &models.IdempotencyRecord{UserID: 1, Key: "done", RequestHash: "hash", ResponseStatus: 201, ResponseBody: []byte(`{}`)}
+	mockRepo.On("ReserveIdempotencyKey", mock.MatchedBy(func(record *models.IdempotencyRecord) bool {
+		return record.Key == "done"
+	})).Return(completed, nil)
+
+	stored, err = idempotencyService.Begin(1, "done", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, completed, stored)
+
+	// Тот же ключ с другим телом запроса
+	_, err = idempotencyService.Begin(1, "done", "other-hash")
+	assert.ErrorIs(t, err, models.ErrIdempotencyKeyReused)
+
+	// Исходный запрос еще выполняется
+	mockRepo.On("ReserveIdempotencyKey", mock.MatchedBy(func(record *models.IdempotencyRecord) bool {
+		return record.Key == "pending"
+	})).Return(&models.IdempotencyRecord{UserID: 1, Key: "pending", RequestHash: "hash"}, nil)
+
+	_, err = idempotencyService.Begin(1, "pending", "hash")
+	assert.ErrorIs(t, err, models.ErrIdempotencyKeyInUse)
+
+	// Слишком длинный ключ
+	_, err = idempotencyService.Begin(1, strings.Repeat("k", service.MaxIdempotencyKeyLength+1), "hash")
+	assert.ErrorIs(t, err, models.ErrInvalidIdempotencyKey)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyCompleteAndRelease(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	idempotencyService := service.NewIdempotencyService(mockRepo, time.Hour)
+
+	headers := map[string]string{"ETag": `"1"`}
+	mockRepo.On("SaveIdempotencyResponse", 1, "key", 201, headers, []byte(`{}`)).Return(nil)
+	mockRepo.On("DeleteIdempotencyKey", 1, "failed").Return(nil)
+
+	err := idempotencyService.Complete(1, "key", 201, headers, []byte(`{}`))
+	assert.NoError(t, err)
+
+	err = idempotencyService.Release(1, "failed")
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyPurgeExpired(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	idempotencyService := service.NewIdempotencyService(mockRepo, time.Hour)
+
+	mockRepo.On("DeleteExpiredIdempotencyKeys", mock.MatchedBy(func(now time.Time) bool {
+		return time.Since(now) < time.Minute
+	})).Return(3, nil)
+
+	purged, err := idempotencyService.PurgeExpired()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -6,7 +6,9 @@ import (
 	"TestTask/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MockOrderRepository struct {
@@ -18,16 +20,65 @@ func (m *MockOrderRepository) CreateOrder(order *models.Order) error {
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) UpdateOrder(order *models.Order) error {
-	args := m.Called(order)
+func (m *MockOrderRepository) UpdateOrder(order *models.Order, oldStatus string, change *models.OrderStatusChange) error {
+	args := m.Called(order, oldStatus, change)
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) UpdateOrderStatus(change *models.OrderStatusChange) (int, error) {
+	args := m.Called(change)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockOrderRepository) CancelOrder(change *models.OrderStatusChange, cancellation *models.OrderCancellation) (int, error) {
+	args := m.Called(change, cancellation)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderStatusHistory(orderID int) ([]models.OrderStatusChange, error) {
+	args := m.Called(orderID)
+	if result := args.Get(0); result != nil {
+		return result.([]models.OrderStatusChange), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) CreateOrderNote(note *models.OrderNote) error {
+	args := m.Called(note)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetOrderNotes(orderID int, includeInternal bool) ([]models.OrderNote, error) {
+	args := m.Called(orderID, includeInternal)
+	if result := args.Get(0); result != nil {
+		return result.([]models.OrderNote), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockOrderRepository) DeleteOrder(orderID int) error {
 	args := m.Called(orderID)
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) RestoreOrder(orderID int) error {
+	args := m.Called(orderID)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) PurgeDeletedOrders(before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockOrderRepository) CancelStalePendingOrders(createdBefore, cancelledAt time.Time, limit int) ([]int, error) {
+	args := m.Called(createdBefore, cancelledAt, limit)
+	if result := args.Get(0); result != nil {
+		return result.([]int), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockOrderRepository) GetOrderByID(orderID int) (*models.Order, error) {
 	args := m.Called(orderID)
 	if result := args.Get(0); result != nil {
@@ -44,74 +95,766 @@ func (m *MockEventService) PublishOrderStatusChanged(orderID int, oldStatus, new
 	m.Called(orderID, oldStatus, newStatus)
 }
 
-func (m *MockOrderRepository) GetOrdersByFilters(status string, minPrice, maxPrice float64) ([]models.Order, error) {
-	args := m.Called(status, minPrice, maxPrice)
-	return args.Get(0).([]models.Order), args.Error(1)
+func (m *MockEventService) PublishOrderCancelled(orderID int, oldStatus string, cancellation *models.OrderCancellation) {
+	m.Called(orderID, oldStatus, cancellation)
+}
+
+func (m *MockOrderRepository) GetOrdersByFilters(filter models.OrderFilter) (*models.OrderPage, error) {
+	args := m.Called(filter)
+	if result := args.Get(0); result != nil {
+		return result.(*models.OrderPage), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) StreamOrdersByFilters(filter models.OrderFilter, fn func(order *models.Order) error) error {
+	args := m.Called(filter, fn)
+	if orders, ok := args.Get(0).([]models.Order); ok {
+		for i := range orders {
+			if err := fn(&orders[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// adminActor пользователь с доступом ко всем заказам
+var adminActor = models.Actor{UserID: 7, Role: models.RoleAdmin}
+
+// newCustomerRepository мок справочника клиентов, который находит клиента с ID 1 по любому имени
+func newCustomerRepository() *MockCustomerRepository {
+	customerRepo := new(MockCustomerRepository)
+	customerRepo.On("FindOrCreateCustomerByName", mock.Anything).Return(func(name string) *models.Customer {
+		return &models.Customer{ID: 1, Name: name}
+	}, nil).Maybe()
+	return customerRepo
 }
 
 func TestCreateOrder(t *testing.T) {
 	mockRepo := new(MockOrderRepository)
 	mockCache := cache.NewCacheService() // Добавляем инстанс CacheService
 	mockEventService := new(MockEventService)
-	orderService := service.NewOrderService(mockRepo, mockCache, mockEventService) // Передаем cache сюда
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0) // Передаем cache сюда
 
+	// Клиент пытается передать собственные цены
 	order := &models.Order{
 		CustomerName: "John Doe",
-		TotalPrice:   99.99,
-		ProductID:    1,
+		TotalPrice:   1,
+		Items: []models.OrderItem{
+			{ProductID: 1, Quantity: 2, UnitPrice: 1},
+			{ProductID: 2, Quantity: 1},
+		},
 	}
 
-	// Мокаем успешное выполнение создания заказа
+	// Мокаем каталог продуктов и успешное выполнение создания заказа
+	mockProductRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Name: "Product A", Price: 1010, Currency: "RUB"}, nil)
+	mockProductRepo.On("GetProductByID", 2).Return(&models.Product{ID: 2, Name: "Product B", Price: 525, Currency: "RUB"}, nil)
 	mockRepo.On("CreateOrder", order).Return(nil)
 
-	// Тест: успешное создание
+	// Тест: успешное создание, цены взяты из каталога
 	err := orderService.CreateOrder(order)
 	assert.NoError(t, err)
+	assert.Equal(t, models.Money(1010), order.Items[0].UnitPrice)
+	assert.Equal(t, models.Money(525), order.Items[1].UnitPrice)
+	assert.Equal(t, models.Money(2545), order.TotalPrice)
+	assert.Equal(t, "pending", order.Status)
 
 	// Мокаем ошибку для invalid данных
 	invalidOrder := &models.Order{
 		CustomerName: "",
-		TotalPrice:   99.99,
-		ProductID:    1,
+		TotalPrice:   9999,
+		Items:        []models.OrderItem{{ProductID: 1, Quantity: 1, UnitPrice: 9999}},
 	}
 	err = orderService.CreateOrder(invalidOrder)
 	assert.Error(t, err)
 	assert.Equal(t, "invalid order data", err.Error())
 
+	// Заказ без позиций невалиден
+	emptyOrder := &models.Order{
+		CustomerName: "John Doe",
+		TotalPrice:   9999,
+	}
+	err = orderService.CreateOrder(emptyOrder)
+	assert.Error(t, err)
+	assert.Equal(t, "invalid order data", err.Error())
+
+	// Позиция с нулевым количеством невалидна
+	zeroQuantityOrder := &models.Order{
+		CustomerName: "John Doe",
+		TotalPrice:   9999,
+		Items:        []models.OrderItem{{ProductID: 1, Quantity: 0, UnitPrice: 9999}},
+	}
+	err = orderService.CreateOrder(zeroQuantityOrder)
+	assert.Error(t, err)
+	assert.Equal(t, "invalid order data", err.Error())
+
 	// Проверка, что мок был вызван
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateOrderWithCoupon(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), mockCouponRepo, newTaxCalculator(models.TaxRule{Rate: 0.2}), newCurrencyConverter(), cache.NewCacheService(), mockEventService, 0, 0)
+
+	mockProductRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Name: "Product A", Price: 4000, Currency: "RUB"}, nil)
+	mockCouponRepo.On("GetCouponByCode", "WELCOME10").Return(&models.Coupon{ID: 3, Code: "WELCOME10", DiscountType: models.DiscountTypePercentage, DiscountValue: 1000}, nil)
+	mockCouponRepo.On("GetCouponByCode", "UNKNOWN").Return(nil, models.ErrCouponNotFound)
+	mockRepo.On("CreateOrder", mock.Anything).Return(nil)
+
+	// Скидка вычитается из подытога, налог уменьшается пропорционально скидке
+	order := &models.Order{
+		CustomerName: "John Doe",
+		CouponCode:   "WELCOME10",
+		Items:        []models.OrderItem{{ProductID: 1, Quantity: 2}},
+	}
+	err := orderService.CreateOrder(order)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(8000), order.Subtotal)
+	assert.Equal(t, models.Money(800), order.DiscountAmount)
+	assert.Equal(t, models.Money(1440), order.TaxAmount)
+	assert.Equal(t, models.Money(8640), order.TotalPrice)
+
+	// Неизвестный код купона
+	order = &models.Order{
+		CustomerName: "John Doe",
+		CouponCode:   "UNKNOWN",
+		Items:        []models.OrderItem{{ProductID: 1, Quantity: 2}},
+	}
+	err = orderService.CreateOrder(order)
+	assert.ErrorIs(t, err, models.ErrCouponNotApplicable)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestCreateOrderOutOfStock(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	order := &models.Order{
+		CustomerName: "John Doe",
+		Items:        []models.OrderItem{{ProductID: 1, Quantity: 100}},
+	}
+
+	// Мокаем нехватку товара на складе
+	mockProductRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Name: "Product A", Price: 99, Currency: "RUB"}, nil)
+	mockRepo.On("CreateOrder", order).Return(models.ErrOutOfStock)
+
+	err := orderService.CreateOrder(order)
+	assert.ErrorIs(t, err, models.ErrOutOfStock)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateOrderWithTax(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	taxes := newTaxCalculator(
+		models.TaxRule{Region: "DE", Rate: 0.19},
+		models.TaxRule{Region: "DE", Category: "books", Rate: 0.07},
+	)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), taxes, newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	order := &models.Order{
+		CustomerName: "John Doe",
+		Region:       " de ",
+		Items: []models.OrderItem{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, Quantity: 1},
+		},
+	}
+
+	mockProductRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Price: 1000, Category: "books", Currency: "RUB"}, nil)
+	mockProductRepo.On("GetProductByID", 2).Return(&models.Product{ID: 2, Price: 5000, Category: "electronics", Currency: "RUB"}, nil)
+	mockRepo.On("CreateOrder", order).Return(nil)
+
+	// Тест: налог рассчитывается по ставке категории, если она задана для региона
+	err := orderService.CreateOrder(order)
+	assert.NoError(t, err)
+	assert.Equal(t, "DE", order.Region)
+	assert.Equal(t, models.Money(7000), order.Subtotal)
+	assert.Equal(t, models.Money(1090), order.TaxAmount)
+	assert.Equal(t, models.Money(8090), order.TotalPrice)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateOrderInForeignCurrency(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	currencies := newCurrencyConverter(
+		models.ExchangeRate{FromCurrency: "EUR", ToCurrency: "RUB", Rate: 100},
+		models.ExchangeRate{FromCurrency: "USD", ToCurrency: "RUB", Rate: 80},
+	)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), currencies, mockCache, mockEventService, 0, 0)
+
+	order := &models.Order{
+		CustomerName: "John Doe",
+		Currency:     "eur",
+		Items: []models.OrderItem{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, Quantity: 1},
+		},
+	}
+
+	mockProductRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Price: 150000, Currency: "RUB"}, nil)
+	mockProductRepo.On("GetProductByID", 2).Return(&models.Product{ID: 2, Price: 1000, Currency: "USD"}, nil)
+	mockRepo.On("CreateOrder", order).Return(nil)
+
+	// Тест: цены переводятся в валюту заказа, итог в базовой валюте сохраняется вместе с курсом
+	err := orderService.CreateOrder(order)
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", order.Currency)
+	assert.Equal(t, models.Money(1500), order.Items[0].UnitPrice)
+	assert.Equal(t, models.Money(800), order.Items[1].UnitPrice)
+	assert.Equal(t, models.Money(3800), order.TotalPrice)
+	assert.Equal(t, 100.0, order.ExchangeRate)
+	assert.Equal(t, models.Money(380000), order.BaseTotalPrice)
+
+	// Тест: неподдерживаемая валюта заказа
+	err = orderService.CreateOrder(&models.Order{CustomerName: "John Doe", Currency: "GBP", Items: []models.OrderItem{{ProductID: 1, Quantity: 1}}})
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestCreateOrderForCustomer(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	mockCustomerRepo := newCustomerRepository()
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, mockCustomerRepo, new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	order := &models.Order{
+		CustomerID:   4,
+		CustomerName: "john doe",
+		Items:        []models.OrderItem{{ProductID: 1, Quantity: 1}},
+	}
+
+	mockCustomerRepo.On("GetCustomerByID", 4).Return(&models.Customer{ID: 4, Name: "John Doe"}, nil)
+	mockCustomerRepo.On("GetCustomerByID", 5).Return(nil, models.ErrCustomerNotFound)
+	mockProductRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Price: 1000, Currency: "RUB"}, nil)
+	mockRepo.On("CreateOrder", order).Return(nil)
+
+	// Тест: имя клиента берется из справочника клиентов
+	err := orderService.CreateOrder(order)
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", order.CustomerName)
+
+	// Тест: несуществующий клиент
+	err = orderService.CreateOrder(&models.Order{CustomerID: 5, Items: []models.OrderItem{{ProductID: 1, Quantity: 1}}})
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestCreateOrderLinksCustomerByName(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	mockCustomerRepo := new(MockCustomerRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, mockCustomerRepo, new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	order := &models.Order{
+		CustomerName: "  john doe ",
+		Items:        []models.OrderItem{{ProductID: 1, Quantity: 1}},
+	}
+
+	mockCustomerRepo.On("FindOrCreateCustomerByName", "john doe").Return(&models.Customer{ID: 4, Name: "John Doe"}, nil)
+	mockProductRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Price: 1000, Currency: "RUB"}, nil)
+	mockRepo.On("CreateOrder", order).Return(nil)
+
+	// Тест: заказ без customer_id привязывается к существующему клиенту с тем же именем
+	err := orderService.CreateOrder(order)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, order.CustomerID)
+	assert.Equal(t, "John Doe", order.CustomerName)
+
+	// Тест: имя из одних пробелов невалидно
+	err = orderService.CreateOrder(&models.Order{CustomerName: "   ", Items: []models.OrderItem{{ProductID: 1, Quantity: 1}}})
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	mockCustomerRepo.AssertNumberOfCalls(t, "FindOrCreateCustomerByName", 1)
+	mockRepo.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestCreateOrderUnknownProduct(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	order := &models.Order{
+		CustomerName: "John Doe",
+		Items:        []models.OrderItem{{ProductID: 42, Quantity: 1}},
+	}
+
+	// Мокаем отсутствие продукта в каталоге
+	mockProductRepo.On("GetProductByID", 42).Return((*models.Product)(nil), nil)
+
+	err := orderService.CreateOrder(order)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	mockRepo.AssertNotCalled(t, "CreateOrder", mock.Anything)
+}
+
+func TestBulkCreateOrders(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	orders := []models.Order{
+		{CustomerName: "John Doe", Items: []models.OrderItem{{ProductID: 1, Quantity: 1}}},
+		{CustomerName: "", Items: []models.OrderItem{{ProductID: 1, Quantity: 1}}},
+		{CustomerName: "Jane Doe", Items: []models.OrderItem{{ProductID: 1, Quantity: 5}}},
+	}
+
+	mockProductRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Name: "Product A", Price: 1000, Currency: "RUB"}, nil)
+	mockRepo.On("CreateOrder", &orders[0]).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Order).ID = 10
+	}).Return(nil)
+	mockRepo.On("CreateOrder", &orders[2]).Return(models.ErrOutOfStock)
+
+	// Тест: ошибки отдельных заказов не мешают остальным
+	results, err := orderService.BulkCreateOrders(orders)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 10, results[0].OrderID)
+	assert.ErrorIs(t, results[1].Err, models.ErrInvalidOrderData)
+	assert.Equal(t, 1, results[1].Index)
+	assert.ErrorIs(t, results[2].Err, models.ErrOutOfStock)
+
+	// Тест: пустой пакет невалиден
+	_, err = orderService.BulkCreateOrders(nil)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkTransitionOrders(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	mockRepo.On("GetOrderByID", 1).Return(&models.Order{ID: 1, CustomerName: "John Doe", Status: "confirmed"}, nil)
+	mockRepo.On("GetOrderByID", 2).Return(&models.Order{ID: 2, CustomerName: "Jane Doe", Status: "delivered"}, nil)
+	mockRepo.On("UpdateOrderStatus", mock.MatchedBy(func(change *models.OrderStatusChange) bool {
+		return change.OrderID == 1 && change.NewStatus == "shipped" && change.ChangedBy == 7
+	})).Return(2, nil)
+	mockEventService.On("PublishOrderStatusChanged", 1, "confirmed", "shipped").Return()
+
+	updates := []models.OrderStatusUpdate{
+		{OrderID: 1, Status: "shipped", Reason: "Handed to courier"},
+		{OrderID: 2, Status: "shipped"},
+	}
+
+	// Тест: недопустимый переход второго заказа не отменяет первый
+	results, err := orderService.BulkTransitionOrders(updates, adminActor)
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "shipped", results[0].Order.Status)
+	assert.ErrorIs(t, results[1].Err, models.ErrInvalidStatusTransition)
+	assert.Equal(t, 2, results[1].OrderID)
+
+	// Событие публикуется только для измененного заказа
+	mockEventService.AssertNumberOfCalls(t, "PublishOrderStatusChanged", 1)
+
+	// Тест: слишком большой пакет
+	_, err = orderService.BulkTransitionOrders(make([]models.OrderStatusUpdate, service.MaxBulkOrders+1), adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+}
+
 func TestUpdateOrder(t *testing.T) {
 	mockRepo := new(MockOrderRepository)
 	mockCache := cache.NewCacheService()
 	mockEventService := new(MockEventService) // Используем MockEventService
+	mockProductRepo := new(MockProductRepository)
 
-	orderService := service.NewOrderService(mockRepo, mockCache, mockEventService)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
 
 	existingOrder := &models.Order{
 		ID:           1,
 		CustomerName: "John Doe",
-		TotalPrice:   99.99,
+		TotalPrice:   9999,
 		Status:       "pending",
-		ProductID:    1,
 	}
 
 	updatedOrder := &models.Order{
 		ID:           1,
 		CustomerName: "John Doe Updated",
-		TotalPrice:   199.99,
-		Status:       "completed",
-		ProductID:    1,
+		TotalPrice:   19999,
+		Status:       "confirmed",
 	}
 
 	mockRepo.On("GetOrderByID", existingOrder.ID).Return(existingOrder, nil)
-	mockRepo.On("UpdateOrder", updatedOrder).Return(nil)
-	mockEventService.On("PublishOrderStatusChanged", updatedOrder.ID, "pending", "completed").Return()
+	mockRepo.On("UpdateOrder", updatedOrder, "pending", mock.MatchedBy(func(change *models.OrderStatusChange) bool {
+		return change.OldStatus == "pending" && change.NewStatus == "confirmed" && change.ChangedBy == 7
+	})).Return(nil)
+	mockEventService.On("PublishOrderStatusChanged", updatedOrder.ID, "pending", "confirmed").Return()
 
-	err := orderService.UpdateOrder(updatedOrder)
+	err := orderService.UpdateOrder(updatedOrder, adminActor)
 	assert.NoError(t, err)
-	mockEventService.AssertCalled(t, "PublishOrderStatusChanged", updatedOrder.ID, "pending", "completed")
+	// Итоговая сумма не перезаписывается клиентом
+	assert.Equal(t, models.Money(9999), updatedOrder.TotalPrice)
+	mockEventService.AssertCalled(t, "PublishOrderStatusChanged", updatedOrder.ID, "pending", "confirmed")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	items := []models.OrderItem{{ID: 10, OrderID: 1, ProductID: 1, Quantity: 2, UnitPrice: 5000}}
+	existingOrder := &models.Order{
+		ID:           1,
+		CustomerName: "John Doe",
+		TotalPrice:   10000,
+		Status:       "pending",
+		Items:        items,
+		Version:      3,
+	}
+
+	mockRepo.On("GetOrderByID", 1).Return(existingOrder, nil)
+	mockRepo.On("UpdateOrder", mock.MatchedBy(func(order *models.Order) bool {
+		return order.CustomerName == "John Doe" && order.Status == "confirmed" && order.Version == 3
+	}), "pending", mock.MatchedBy(func(change *models.OrderStatusChange) bool {
+		return change.OldStatus == "pending" && change.NewStatus == "confirmed" && change.ChangedBy == 7
+	})).Return(nil)
+	mockEventService.On("PublishOrderStatusChanged", 1, "pending", "confirmed").Return()
+
+	// Тест: патч меняет только статус, имя клиента и позиции сохраняются
+	result, err := orderService.PatchOrder(1, []byte(`{"status": "confirmed", "total_price": 1}`), 3, adminActor)
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", result.CustomerName)
+	assert.Equal(t, "confirmed", result.Status)
+	assert.Equal(t, models.Money(10000), result.TotalPrice)
+	assert.Equal(t, items, result.Items)
+
+	// Тест: null удаляет имя клиента, валидация не проходит
+	_, err = orderService.PatchOrder(1, []byte(`{"customer_name": null}`), 3, adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	// Тест: патч должен быть JSON-объектом
+	_, err = orderService.PatchOrder(1, []byte(`"confirmed"`), 3, adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	mockRepo.AssertNumberOfCalls(t, "UpdateOrder", 1)
+	mockEventService.AssertExpectations(t)
+}
+
+func TestUpdateOrderVersionConflict(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	existingOrder := &models.Order{
+		ID:           1,
+		CustomerName: "John Doe",
+		TotalPrice:   9999,
+		Status:       "pending",
+		Version:      3,
+	}
+	mockCache.SetOrder(existingOrder.ID, existingOrder)
+
+	updatedOrder := &models.Order{
+		ID:           1,
+		CustomerName: "John Doe Updated",
+		Status:       "pending",
+		Version:      2,
+	}
+
+	mockRepo.On("GetOrderByID", existingOrder.ID).Return(existingOrder, nil)
+	mockRepo.On("UpdateOrder", updatedOrder, "pending", (*models.OrderStatusChange)(nil)).Return(models.ErrVersionConflict)
+
+	// Тест: клиент прислал устаревшую версию заказа
+	err := orderService.UpdateOrder(updatedOrder, adminActor)
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+
+	// Устаревшая копия заказа удаляется из кэша
+	_, found := mockCache.GetOrder(existingOrder.ID)
+	assert.False(t, found)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateOrderRejectsIllegalTransition(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	existingOrder := &models.Order{
+		ID:           1,
+		CustomerName: "John Doe",
+		TotalPrice:   9999,
+		Status:       "cancelled",
+	}
+
+	updatedOrder := &models.Order{
+		ID:           1,
+		CustomerName: "John Doe",
+		TotalPrice:   9999,
+		Status:       "pending",
+	}
+
+	mockRepo.On("GetOrderByID", existingOrder.ID).Return(existingOrder, nil)
+
+	// Тест: отмененный заказ нельзя вернуть в pending
+	err := orderService.UpdateOrder(updatedOrder, adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	mockRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything, mock.Anything)
+	mockEventService.AssertNotCalled(t, "PublishOrderStatusChanged", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransitionOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	existingOrder := &models.Order{
+		ID:           1,
+		CustomerName: "John Doe",
+		TotalPrice:   9999,
+		Status:       "confirmed",
+	}
+
+	mockRepo.On("GetOrderByID", 1).Return(existingOrder, nil)
+	mockRepo.On("UpdateOrderStatus", mock.MatchedBy(func(change *models.OrderStatusChange) bool {
+		return change.OrderID == 1 && change.OldStatus == "confirmed" && change.NewStatus == "shipped" &&
+			change.ChangedBy == 7 && change.Reason == "Handed to courier"
+	})).Return(3, nil)
+	mockEventService.On("PublishOrderStatusChanged", 1, "confirmed", "shipped").Return()
+
+	// Тест: допустимый переход confirmed -> shipped
+	result, err := orderService.TransitionOrder(1, "shipped", adminActor, "Handed to courier")
+	assert.NoError(t, err)
+	assert.Equal(t, "shipped", result.Status)
+	assert.Equal(t, 3, result.Version)
+
+	mockRepo.AssertExpectations(t)
+	mockEventService.AssertExpectations(t)
+}
+
+func TestTransitionOrderRejectsIllegalTransition(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	existingOrder := &models.Order{
+		ID:           1,
+		CustomerName: "John Doe",
+		TotalPrice:   9999,
+		Status:       "pending",
+	}
+
+	mockRepo.On("GetOrderByID", 1).Return(existingOrder, nil)
+
+	// Тест: нельзя перескочить из pending сразу в delivered
+	_, err := orderService.TransitionOrder(1, "delivered", adminActor, "")
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	// Тест: неизвестный статус
+	_, err = orderService.TransitionOrder(1, "completed", adminActor, "")
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	mockRepo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything)
+	mockEventService.AssertNotCalled(t, "PublishOrderStatusChanged", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	mockRepo.On("GetOrderByID", 1).Return(&models.Order{ID: 1, CustomerName: "John Doe", Status: "confirmed", UserID: 3}, nil)
+	mockRepo.On("CancelOrder",
+		mock.MatchedBy(func(change *models.OrderStatusChange) bool {
+			return change.OrderID == 1 && change.OldStatus == "confirmed" && change.NewStatus == "cancelled" &&
+				change.ChangedBy == 3 && change.Reason == "customer_request"
+		}),
+		mock.MatchedBy(func(cancellation *models.OrderCancellation) bool {
+			return cancellation.OrderID == 1 && cancellation.ReasonCode == "customer_request" &&
+				cancellation.Note == "Changed my mind" && cancellation.CancelledBy == 3
+		}),
+	).Return(4, nil)
+	mockEventService.On("PublishOrderCancelled", 1, "confirmed", mock.AnythingOfType("*models.OrderCancellation")).Return()
+
+	// Тест: владелец отменяет свой заказ
+	result, err := orderService.CancelOrder(1, "customer_request", "Changed my mind", models.Actor{UserID: 3, Role: models.RoleUser})
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", result.Status)
+	assert.Equal(t, 4, result.Version)
+	assert.Equal(t, "customer_request", result.Cancellation.ReasonCode)
+
+	mockRepo.AssertExpectations(t)
+	mockEventService.AssertExpectations(t)
+	mockEventService.AssertNotCalled(t, "PublishOrderStatusChanged", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelOrderValidation(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	mockRepo.On("GetOrderByID", 1).Return(&models.Order{ID: 1, CustomerName: "John Doe", Status: "shipped", UserID: 3}, nil)
+
+	// Тест: неизвестный код причины
+	_, err := orderService.CancelOrder(1, "bored", "", adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	// Тест: для причины other комментарий обязателен
+	_, err = orderService.CancelOrder(1, "other", " ", adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	// Тест: слишком длинный комментарий
+	_, err = orderService.CancelOrder(1, "duplicate", strings.Repeat("a", service.MaxCancellationNoteLength+1), adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	// Тест: отгруженный заказ отменить нельзя
+	_, err = orderService.CancelOrder(1, "duplicate", "", adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	// Тест: чужой заказ неотличим от несуществующего
+	_, err = orderService.CancelOrder(1, "duplicate", "", models.Actor{UserID: 4, Role: models.RoleUser})
+	assert.ErrorIs(t, err, models.ErrOrderNotFound)
+
+	mockRepo.AssertNotCalled(t, "CancelOrder", mock.Anything, mock.Anything)
+	mockEventService.AssertNotCalled(t, "PublishOrderCancelled", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetOrderStatusHistory(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	history := []models.OrderStatusChange{
+		{ID: 1, OrderID: 1, OldStatus: "pending", NewStatus: "confirmed", ChangedBy: 7, CreatedAt: time.Now()},
+	}
+
+	mockRepo.On("GetOrderByID", 1).Return(&models.Order{ID: 1, Status: "confirmed"}, nil)
+	mockRepo.On("GetOrderStatusHistory", 1).Return(history, nil)
+
+	// Тест: история существующего заказа
+	result, err := orderService.GetOrderStatusHistory(1, adminActor)
+	assert.NoError(t, err)
+	assert.Equal(t, history, result)
+
+	// Тест: несуществующий заказ
+	mockRepo.On("GetOrderByID", 2).Return(nil, models.ErrOrderNotFound)
+	_, err = orderService.GetOrderStatusHistory(2, adminActor)
+	assert.ErrorIs(t, err, models.ErrOrderNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAddOrderNote(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	owner := models.Actor{UserID: 3, Role: models.RoleUser}
+
+	mockRepo.On("GetOrderByID", 1).Return(&models.Order{ID: 1, UserID: 3, Status: "pending"}, nil)
+	mockRepo.On("CreateOrderNote", mock.AnythingOfType("*models.OrderNote")).Return(nil)
+
+	// Тест: администратор оставляет внутреннюю заметку, автор берется из actor
+	note, err := orderService.AddOrderNote(1, "  Call the customer before shipping ", true, adminActor)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.OrderNote{OrderID: 1, AuthorID: 7, Text: "Call the customer before shipping", IsInternal: true}, note)
+
+	// Тест: владелец заказа оставляет видимую ему заметку
+	note, err = orderService.AddOrderNote(1, "Please call before delivery", false, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, note.AuthorID)
+
+	// Тест: пользователь не может оставить внутреннюю заметку
+	_, err = orderService.AddOrderNote(1, "Internal", true, owner)
+	assert.ErrorIs(t, err, models.ErrOrderNoteForbidden)
+
+	// Тест: чужой заказ
+	_, err = orderService.AddOrderNote(1, "Hello", false, models.Actor{UserID: 4, Role: models.RoleUser})
+	assert.ErrorIs(t, err, models.ErrOrderNotFound)
+
+	// Тест: пустая заметка
+	_, err = orderService.AddOrderNote(1, "   ", false, adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderData)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateOrderNote", 2)
+}
+
+func TestGetOrderNotes(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	internal := models.OrderNote{ID: 1, OrderID: 1, AuthorID: 7, Text: "Fraud check passed", IsInternal: true}
+	public := models.OrderNote{ID: 2, OrderID: 1, AuthorID: 3, Text: "Please call before delivery"}
+
+	mockRepo.On("GetOrderByID", 1).Return(&models.Order{ID: 1, UserID: 3, Status: "pending"}, nil)
+	mockRepo.On("GetOrderNotes", 1, true).Return([]models.OrderNote{internal, public}, nil)
+	mockRepo.On("GetOrderNotes", 1, false).Return([]models.OrderNote{public}, nil)
+
+	// Тест: администратор видит все заметки
+	notes, err := orderService.GetOrderNotes(1, adminActor)
+	assert.NoError(t, err)
+	assert.Len(t, notes, 2)
+
+	// Тест: владелец заказа видит только видимые ему заметки
+	notes, err = orderService.GetOrderNotes(1, models.Actor{UserID: 3, Role: models.RoleUser})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.OrderNote{public}, notes)
+
+	// Тест: чужой заказ
+	_, err = orderService.GetOrderNotes(1, models.Actor{UserID: 4, Role: models.RoleUser})
+	assert.ErrorIs(t, err, models.ErrOrderNotFound)
+
 	mockRepo.AssertExpectations(t)
 }
 
@@ -119,7 +862,8 @@ func TestDeleteOrder(t *testing.T) {
 	mockRepo := new(MockOrderRepository)
 	mockCache := cache.NewCacheService() // Добавляем инстанс CacheService
 	mockEventService := new(MockEventService)
-	orderService := service.NewOrderService(mockRepo, mockCache, mockEventService) // Передаем cache сюда
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0) // Передаем cache сюда
 
 	// Мокаем успешное выполнение удаления
 	mockRepo.On("DeleteOrder", 1).Return(nil)
@@ -132,30 +876,127 @@ func TestDeleteOrder(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestRestoreOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	mockRepo.On("RestoreOrder", 1).Return(nil)
+	mockRepo.On("RestoreOrder", 2).Return(models.ErrOutOfStock)
+
+	err := orderService.RestoreOrder(1)
+	assert.NoError(t, err)
+
+	// Восстановить заказ нельзя, если товара на складе уже не хватает
+	err = orderService.RestoreOrder(2)
+	assert.ErrorIs(t, err, models.ErrOutOfStock)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPurgeDeletedOrders(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 30*24*time.Hour, 0)
+
+	// Граница очистки должна отстоять от текущего момента на срок хранения
+	mockRepo.On("PurgeDeletedOrders", mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before.Add(30*24*time.Hour)) < time.Minute
+	})).Return(3, nil)
+
+	purged, err := orderService.PurgeDeletedOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+
+	// Без настроенного срока хранения очистка не выполняется
+	orderService = service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+	_, err = orderService.PurgeDeletedOrders()
+	assert.Error(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCancelStalePendingOrders(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 72*time.Hour)
+
+	mockCache.SetOrder(1, &models.Order{ID: 1, Status: "pending"})
+
+	// Граница отмены должна отстоять от текущего момента на время жизни неподтвержденного заказа
+	stale := mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before.Add(72*time.Hour)) < time.Minute
+	})
+
+	// Первая пачка заполнена полностью, поэтому запрашивается следующая
+	fullBatch := make([]int, service.AutoCancelBatchSize)
+	for i := range fullBatch {
+		fullBatch[i] = i + 1
+	}
+	mockRepo.On("CancelStalePendingOrders", stale, mock.AnythingOfType("time.Time"), service.AutoCancelBatchSize).Return(fullBatch, nil).Once()
+	mockRepo.On("CancelStalePendingOrders", stale, mock.AnythingOfType("time.Time"), service.AutoCancelBatchSize).Return([]int{500}, nil).Once()
+	mockEventService.On("PublishOrderCancelled", mock.Anything, "pending", mock.MatchedBy(func(cancellation *models.OrderCancellation) bool {
+		return cancellation.ReasonCode == models.CancellationReasonExpired
+	})).Return()
+
+	cancelled, err := orderService.CancelStalePendingOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, service.AutoCancelBatchSize+1, cancelled)
+
+	// Отмененный заказ удаляется из кэша
+	_, found := mockCache.GetOrder(1)
+	assert.False(t, found)
+
+	mockRepo.AssertExpectations(t)
+	mockEventService.AssertNotCalled(t, "PublishOrderStatusChanged", mock.Anything, mock.Anything, mock.Anything)
+	mockEventService.AssertNumberOfCalls(t, "PublishOrderCancelled", service.AutoCancelBatchSize+1)
+}
+
+func TestCancelStalePendingOrdersDisabled(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	// Без настроенного времени жизни заказы не отменяются
+	cancelled, err := orderService.CancelStalePendingOrders()
+	assert.NoError(t, err)
+	assert.Zero(t, cancelled)
+
+	mockRepo.AssertNotCalled(t, "CancelStalePendingOrders", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestGetOrderByID(t *testing.T) {
 	mockRepo := new(MockOrderRepository)
 	mockCache := cache.NewCacheService() // Добавляем инстанс CacheService
 	mockEventService := new(MockEventService)
-	orderService := service.NewOrderService(mockRepo, mockCache, mockEventService) // Передаем cache сюда
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0) // Передаем cache сюда
 
 	order := &models.Order{
 		ID:           1,
 		CustomerName: "John Doe",
-		TotalPrice:   99.99,
-		ProductID:    1,
+		TotalPrice:   9999,
 	}
 
 	// Мокаем успешное получение заказа
 	mockRepo.On("GetOrderByID", 1).Return(order, nil)
 
 	// Тест: успешное получение
-	result, err := orderService.GetOrderByID(1)
+	result, err := orderService.GetOrderByID(1, adminActor)
 	assert.NoError(t, err)
 	assert.Equal(t, order, result)
 
 	// Тест: ошибка для несуществующего заказа
 	mockRepo.On("GetOrderByID", 2).Return(nil, nil)
-	result, err = orderService.GetOrderByID(2)
+	result, err = orderService.GetOrderByID(2, adminActor)
 	assert.NoError(t, err)
 	assert.Nil(t, result)
 
@@ -163,25 +1004,211 @@ func TestGetOrderByID(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetOrderByIDOwnership(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	order := &models.Order{ID: 1, CustomerName: "John Doe", TotalPrice: 9999, UserID: 3}
+	mockRepo.On("GetOrderByID", 1).Return(order, nil).Once()
+
+	// Тест: владелец получает свой заказ
+	result, err := orderService.GetOrderByID(1, models.Actor{UserID: 3, Role: models.RoleUser})
+	assert.NoError(t, err)
+	assert.Equal(t, order, result)
+
+	// Тест: чужой заказ из кэша неотличим от несуществующего
+	_, err = orderService.GetOrderByID(1, models.Actor{UserID: 4, Role: models.RoleUser})
+	assert.ErrorIs(t, err, models.ErrOrderNotFound)
+
+	// Тест: Admin видит любой заказ
+	result, err = orderService.GetOrderByID(1, models.Actor{UserID: 4, Role: models.RoleAdmin})
+	assert.NoError(t, err)
+	assert.Equal(t, order, result)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCancellationRequiresCancelOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	mockRepo.On("GetOrderByID", 1).Return(&models.Order{ID: 1, CustomerName: "John Doe", Status: "confirmed"}, nil)
+
+	// Тест: перевод в cancelled через смену статуса отклоняется, причина отмены не была бы записана
+	_, err := orderService.TransitionOrder(1, "cancelled", adminActor, "")
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	// Тест: то же для обновления заказа целиком
+	err = orderService.UpdateOrder(&models.Order{ID: 1, CustomerName: "John Doe", Status: "cancelled"}, adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+
+	// Тест: пакетная смена статусов возвращает ошибку для такого заказа
+	results, err := orderService.BulkTransitionOrders([]models.OrderStatusUpdate{{OrderID: 1, Status: "cancelled"}}, adminActor)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, models.ErrInvalidStatusTransition)
+
+	mockRepo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything, mock.Anything)
+	mockEventService.AssertNotCalled(t, "PublishOrderStatusChanged", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransitionOrderRejectsForeignOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	mockRepo.On("GetOrderByID", 1).Return(&models.Order{ID: 1, CustomerName: "John Doe", Status: "pending", UserID: 3}, nil)
+
+	// Тест: пользователь не может изменить статус чужого заказа
+	_, err := orderService.TransitionOrder(1, "cancelled", models.Actor{UserID: 4, Role: models.RoleUser}, "")
+	assert.ErrorIs(t, err, models.ErrOrderNotFound)
+
+	mockRepo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything)
+	mockEventService.AssertNotCalled(t, "PublishOrderStatusChanged", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetOrdersByFiltersScopesUsers(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	userPage := &models.OrderPage{Orders: []models.Order{{ID: 1, UserID: 3}}, TotalCount: 1}
+	adminPage := &models.OrderPage{Orders: []models.Order{{ID: 1, UserID: 3}, {ID: 2, UserID: 4}}, TotalCount: 2}
+
+	// Пользователю с ролью User фильтр по владельцу добавляется всегда, даже если передан чужой ID
+	mockRepo.On("GetOrdersByFilters", models.OrderFilter{UserID: 3, Sort: "-created_at", Limit: 20}).Return(userPage, nil).Once()
+	mockRepo.On("GetOrdersByFilters", models.OrderFilter{Sort: "-created_at", Limit: 20}).Return(adminPage, nil).Once()
+
+	result, err := orderService.GetOrdersByFilters(models.OrderFilter{UserID: 4}, models.Actor{UserID: 3, Role: models.RoleUser})
+	assert.NoError(t, err)
+	assert.Equal(t, userPage, result)
+
+	// Тест: страница пользователя не отдается из кэша администратору
+	result, err = orderService.GetOrdersByFilters(models.OrderFilter{}, adminActor)
+	assert.NoError(t, err)
+	assert.Equal(t, adminPage, result)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetOrdersByFilters(t *testing.T) {
 	mockRepo := new(MockOrderRepository)
 	mockCache := cache.NewCacheService() // Добавляем инстанс CacheService
 	mockEventService := new(MockEventService)
-	orderService := service.NewOrderService(mockRepo, mockCache, mockEventService) // Передаем cache сюда
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0) // Передаем cache сюда
 
 	orders := []models.Order{
-		{ID: 1, CustomerName: "John Doe", TotalPrice: 99.99, ProductID: 1},
-		{ID: 2, CustomerName: "Jane Doe", TotalPrice: 149.99, ProductID: 2},
+		{ID: 1, CustomerName: "John Doe", TotalPrice: 9999, Items: []models.OrderItem{{ProductID: 1, Quantity: 1, UnitPrice: 9999}}},
+		{ID: 2, CustomerName: "Jane Doe", TotalPrice: 14999, Items: []models.OrderItem{{ProductID: 2, Quantity: 1, UnitPrice: 14999}}},
 	}
 
-	// Мокаем успешное выполнение фильтрации заказов
-	mockRepo.On("GetOrdersByFilters", "pending", float64(0), float64(200)).Return(orders, nil)
+	page := &models.OrderPage{Orders: orders, NextCursor: "next", TotalCount: 5}
+
+	// Мокаем успешное выполнение фильтрации заказов, пустые сортировка и лимит заменяются значениями по умолчанию
+	expectedFilter := models.OrderFilter{Statuses: []string{"pending"}, MaxPrice: 20000, Sort: "-created_at", Limit: 20}
+	mockRepo.On("GetOrdersByFilters", expectedFilter).Return(page, nil).Once()
 
 	// Тест: успешное получение заказов
-	result, err := orderService.GetOrdersByFilters("pending", 0, 200)
+	result, err := orderService.GetOrdersByFilters(models.OrderFilter{Statuses: []string{"pending"}, MaxPrice: 20000}, adminActor)
+	assert.NoError(t, err)
+	assert.Equal(t, page, result)
+
+	// Тест: повторный запрос отдается из кэша
+	result, err = orderService.GetOrdersByFilters(models.OrderFilter{Statuses: []string{"pending"}, MaxPrice: 20000}, adminActor)
+	assert.NoError(t, err)
+	assert.Equal(t, page, result)
+
+	// Тест: следующая страница имеет собственный ключ кэша
+	nextFilter := models.OrderFilter{Statuses: []string{"pending"}, MaxPrice: 20000, Sort: "-created_at", Limit: 20, Cursor: "next"}
+	nextPage := &models.OrderPage{Orders: []models.Order{}, TotalCount: 5}
+	mockRepo.On("GetOrdersByFilters", nextFilter).Return(nextPage, nil).Once()
+
+	result, err = orderService.GetOrdersByFilters(models.OrderFilter{Statuses: []string{"pending"}, MaxPrice: 20000, Cursor: "next"}, adminActor)
 	assert.NoError(t, err)
-	assert.Equal(t, orders, result)
+	assert.Equal(t, nextPage, result)
 
 	// Проверка, что мок был вызван
 	mockRepo.AssertExpectations(t)
 }
+
+func TestExportOrders(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	orders := []models.Order{{ID: 1, Status: "pending"}, {ID: 2, Status: "pending"}}
+
+	// Лимит и курсор не передаются в репозиторий, сортировка берется по умолчанию
+	mockRepo.On("StreamOrdersByFilters", models.OrderFilter{
+		Statuses: []string{"pending"},
+		Sort:     service.DefaultOrdersSort,
+	}, mock.Anything).Return(orders, nil)
+
+	var exported []int
+	err := orderService.ExportOrders(models.OrderFilter{Statuses: []string{"pending"}, Limit: 5, Cursor: "abc"}, func(order *models.Order) error {
+		exported = append(exported, order.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, exported)
+
+	// Тест: невалидный фильтр отклоняется до обращения к базе
+	err = orderService.ExportOrders(models.OrderFilter{Statuses: []string{"unknown"}}, func(order *models.Order) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidFilter)
+
+	mockRepo.AssertNumberOfCalls(t, "StreamOrdersByFilters", 1)
+}
+
+func TestGetOrdersByFiltersValidation(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	invalidFilters := []models.OrderFilter{
+		{Statuses: []string{"pending", "completed"}},
+		{MinPrice: -100},
+		{MinPrice: 20000, MaxPrice: 10000},
+		{CreatedFrom: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), CreatedTo: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ProductID: -3},
+	}
+
+	for _, filter := range invalidFilters {
+		_, err := orderService.GetOrdersByFilters(filter, adminActor)
+		assert.ErrorIs(t, err, models.ErrInvalidFilter)
+	}
+
+	mockRepo.AssertNotCalled(t, "GetOrdersByFilters", mock.Anything)
+}
+
+func TestGetOrdersByFiltersRejectsLimit(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := cache.NewCacheService()
+	mockEventService := new(MockEventService)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockRepo, mockProductRepo, newCustomerRepository(), new(MockCouponRepository), newTaxCalculator(), newCurrencyConverter(), mockCache, mockEventService, 0, 0)
+
+	_, err := orderService.GetOrdersByFilters(models.OrderFilter{Limit: 1000}, adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidFilter)
+
+	_, err = orderService.GetOrdersByFilters(models.OrderFilter{Limit: -1}, adminActor)
+	assert.ErrorIs(t, err, models.ErrInvalidFilter)
+
+	mockRepo.AssertNotCalled(t, "GetOrdersByFilters", mock.Anything)
+}
@@ -5,6 +5,7 @@ import (
 	"TestTask/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"strings"
 	"testing"
 )
 
@@ -38,13 +39,18 @@ func (m *MockProductRepository) GetAllProducts() ([]models.Product, error) {
 	return args.Get(0).([]models.Product), args.Error(1)
 }
 
+func (m *MockProductRepository) ImportProducts(rows []models.ProductImportRow) error {
+	args := m.Called(rows)
+	return args.Error(0)
+}
+
 func TestCreateProduct(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockRepo)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
 
 	product := &models.Product{
 		Name:  "Product A",
-		Price: 99.99,
+		Price: 9999,
 	}
 
 	// Мокаем успешное выполнение создания продукта
@@ -57,7 +63,7 @@ func TestCreateProduct(t *testing.T) {
 	// Тест: ошибка для невалидных данных
 	invalidProduct := &models.Product{
 		Name:  "",
-		Price: -10.00,
+		Price: -1000,
 	}
 	err = productService.CreateProduct(invalidProduct)
 	assert.Error(t, err)
@@ -69,26 +75,28 @@ func TestCreateProduct(t *testing.T) {
 
 func TestUpdateProduct(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockRepo)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
 
 	product := &models.Product{
 		ID:    1,
 		Name:  "Product A",
-		Price: 99.99,
+		Price: 9999,
 	}
 
-	// Мокаем успешное выполнение обновления
+	// Мокаем успешное выполнение обновления; валюта не указана, поэтому сохраняется текущая
+	mockRepo.On("GetProductByID", 1).Return(&models.Product{ID: 1, Name: "Product A", Price: 9999, Currency: models.CurrencyEUR}, nil)
 	mockRepo.On("UpdateProduct", product).Return(nil)
 
 	// Тест: успешное обновление
 	err := productService.UpdateProduct(product)
 	assert.NoError(t, err)
+	assert.Equal(t, models.CurrencyEUR, product.Currency)
 
 	// Тест: ошибка для невалидных данных
 	invalidProduct := &models.Product{
 		ID:    1,
 		Name:  "",
-		Price: -20.00,
+		Price: -2000,
 	}
 	err = productService.UpdateProduct(invalidProduct)
 	assert.Error(t, err)
@@ -98,9 +106,46 @@ func TestUpdateProduct(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestPatchProduct(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
+
+	product := &models.Product{
+		ID:       1,
+		Name:     "Product A",
+		Price:    9999,
+		Quantity: 10,
+		Currency: "RUB",
+	}
+
+	mockRepo.On("GetProductByID", 1).Return(product, nil)
+	mockRepo.On("GetProductByID", 2).Return((*models.Product)(nil), nil)
+	mockRepo.On("UpdateProduct", &models.Product{ID: 1, Name: "Product A", Price: 7999, Quantity: 10, Currency: "RUB"}).Return(nil)
+
+	// Тест: меняется только цена, остальные поля сохраняются
+	result, err := productService.PatchProduct(1, []byte(`{"price": 79.99}`))
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(7999), result.Price)
+	assert.Equal(t, 10, result.Quantity)
+
+	// Тест: null удаляет обязательное поле, валидация не проходит
+	_, err = productService.PatchProduct(1, []byte(`{"name": null}`))
+	assert.ErrorIs(t, err, models.ErrInvalidProductData)
+
+	// Тест: патч должен быть JSON-объектом
+	_, err = productService.PatchProduct(1, []byte(`[1, 2]`))
+	assert.ErrorIs(t, err, models.ErrInvalidProductData)
+
+	// Тест: продукт не найден
+	_, err = productService.PatchProduct(2, []byte(`{"price": 79.99}`))
+	assert.ErrorIs(t, err, models.ErrProductNotFound)
+
+	mockRepo.AssertNumberOfCalls(t, "UpdateProduct", 1)
+}
+
 func TestDeleteProduct(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockRepo)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
 
 	// Мокаем успешное удаление
 	mockRepo.On("DeleteProductByID", 1).Return(nil)
@@ -115,12 +160,12 @@ func TestDeleteProduct(t *testing.T) {
 
 func TestGetProductByID(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockRepo)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
 
 	product := &models.Product{
 		ID:    1,
 		Name:  "Product A",
-		Price: 99.99,
+		Price: 9999,
 	}
 
 	// Мокаем успешное получение продукта
@@ -145,11 +190,11 @@ func TestGetProductByID(t *testing.T) {
 
 func TestGetAllProducts(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	productService := service.NewProductService(mockRepo)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
 
 	products := []models.Product{
-		{ID: 1, Name: "Product A", Price: 99.99},
-		{ID: 2, Name: "Product B", Price: 149.99},
+		{ID: 1, Name: "Product A", Price: 9999},
+		{ID: 2, Name: "Product B", Price: 14999},
 	}
 
 	// Мокаем успешное выполнение получения всех продуктов
@@ -163,3 +208,107 @@ func TestGetAllProducts(t *testing.T) {
 	// Проверяем вызов мока
 	mockRepo.AssertExpectations(t)
 }
+
+func TestImportProducts(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
+
+	file := strings.Join([]string{
+		"name,price,quantity,id",
+		"Product A,99.99,10,",
+		"Product B,-1,5,",
+		"Product C,49.50,3,7",
+		"Product D,abc,1,",
+		"Product E,10,1,7",
+		strings.Repeat("F", service.MaxProductNameLength+1) + ",10,1,",
+	}, "\n")
+
+	// В репозиторий передаются только валидные строки; репозиторий проставляет результат
+	mockRepo.On("ImportProducts", mock.MatchedBy(func(rows []models.ProductImportRow) bool {
+		return len(rows) == 2 && rows[0].Row == 2 && rows[0].Product.Name == "Product A" &&
+			rows[1].Row == 4 && rows[1].Product.ID == 7
+	})).Run(func(args mock.Arguments) {
+		rows := args.Get(0).([]models.ProductImportRow)
+		rows[0].ProductID, rows[0].Status = 11, models.ProductImportCreated
+		rows[1].ProductID, rows[1].Status = 7, models.ProductImportUpdated
+	}).Return(nil)
+
+	report, err := productService.ImportProducts(strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 4, report.Failed)
+
+	// Строки отчета идут в порядке файла
+	assert.Len(t, report.Rows, 6)
+	assert.Equal(t, 2, report.Rows[0].Row)
+	assert.Equal(t, 11, report.Rows[0].ProductID)
+	assert.Equal(t, models.ProductImportFailed, report.Rows[1].Status)
+	assert.Equal(t, "invalid product data", report.Rows[1].Error)
+	assert.Equal(t, "invalid price \"abc\"", report.Rows[3].Error)
+	assert.Contains(t, report.Rows[4].Error, "already used in row 4")
+	assert.Equal(t, "invalid product data: name must be at most 255 characters", report.Rows[5].Error)
+
+	// Тест: заголовок без обязательной колонки
+	_, err = productService.ImportProducts(strings.NewReader("name,price\nProduct A,1"))
+	assert.ErrorIs(t, err, models.ErrInvalidProductData)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportProductsWithoutCurrencyColumn(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
+
+	file := strings.Join([]string{
+		"id,name,price,quantity",
+		",Product A,99.99,10",
+		"7,Product B,49.50,3",
+	}, "\n")
+
+	// Новый продукт получает базовую валюту, у существующего валюта не передается и остается прежней
+	mockRepo.On("ImportProducts", mock.MatchedBy(func(rows []models.ProductImportRow) bool {
+		return len(rows) == 2 && rows[0].Product.Currency == models.CurrencyRUB && rows[1].Product.Currency == ""
+	})).Run(func(args mock.Arguments) {
+		rows := args.Get(0).([]models.ProductImportRow)
+		rows[0].ProductID, rows[0].Status = 11, models.ProductImportCreated
+		rows[1].ProductID, rows[1].Status = 7, models.ProductImportUpdated
+	}).Return(nil)
+
+	report, err := productService.ImportProducts(strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportProductsWithCategory(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	productService := service.NewProductService(mockRepo, models.CurrencyRUB)
+
+	file := strings.Join([]string{
+		"id,name,price,quantity,category",
+		",Product A,99.99,10, books ",
+		"7,Product B,49.50,3,",
+	}, "\n")
+
+	// Пустая ячейка при наличии колонки очищает категорию
+	mockRepo.On("ImportProducts", mock.MatchedBy(func(rows []models.ProductImportRow) bool {
+		return len(rows) == 2 && rows[0].HasCategory && rows[0].Product.Category == "books" &&
+			rows[1].HasCategory && rows[1].Product.Category == ""
+	})).Return(nil)
+
+	_, err := productService.ImportProducts(strings.NewReader(file))
+	assert.NoError(t, err)
+
+	// Без колонки category категория не передается
+	mockRepo.On("ImportProducts", mock.MatchedBy(func(rows []models.ProductImportRow) bool {
+		return len(rows) == 1 && !rows[0].HasCategory
+	})).Return(nil)
+
+	_, err = productService.ImportProducts(strings.NewReader("id,name,price,quantity\n7,Product B,49.50,3"))
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,108 @@
+package service_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// MockTaxRuleRepository для мока TaxRuleRepositoryInterface
+type MockTaxRuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxRuleRepository) GetTaxRules(region string) ([]models.TaxRule, error) {
+	args := m.Called(region)
+	if result := args.Get(0); result != nil {
+		return result.([]models.TaxRule), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// newTaxCalculator возвращает калькулятор с заданными правилами для любого региона
+func newTaxCalculator(rules ...models.TaxRule) *service.TaxCalculator {
+	mockRepo := new(MockTaxRuleRepository)
+	mockRepo.On("GetTaxRules", mock.Anything).Return(rules, nil).Maybe()
+	return service.NewTaxCalculator(mockRepo)
+}
+
+func TestTaxCalculatorExclusive(t *testing.T) {
+	calculator := newTaxCalculator(models.TaxRule{Rate: 0.2})
+
+	subtotal, tax, err := calculator.Calculate("DE", []service.TaxLine{
+		{Category: "books", Amount: 1000},
+		{Category: "food", Amount: 550},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(1550), subtotal)
+	assert.Equal(t, models.Money(310), tax)
+}
+
+func TestTaxCalculatorInclusive(t *testing.T) {
+	calculator := newTaxCalculator(models.TaxRule{Rate: 0.19, Inclusive: true})
+
+	// Налог выделяется из цены, итог совпадает с ценой каталога
+	subtotal, tax, err := calculator.Calculate("DE", []service.TaxLine{{Amount: 1190}})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(1000), subtotal)
+	assert.Equal(t, models.Money(190), tax)
+}
+
+func TestTaxCalculatorRoundsOnce(t *testing.T) {
+	calculator := newTaxCalculator(models.TaxRule{Rate: 0.2, Inclusive: true})
+
+	// Налог в позициях 0.01 и 0.02 ровно 0.005 и округляется вверх; во float64 сумма получалась 0.00499...
+	subtotal, tax, err := calculator.Calculate("DE", []service.TaxLine{{Amount: 1}, {Amount: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(2), subtotal)
+	assert.Equal(t, models.Money(1), tax)
+}
+
+func TestTaxCalculatorRulePrecedence(t *testing.T) {
+	calculator := newTaxCalculator(
+		models.TaxRule{ID: 1, Rate: 0.2},
+		models.TaxRule{ID: 2, Category: "books", Rate: 0.1},
+		models.TaxRule{ID: 3, Region: "DE", Rate: 0.19},
+		models.TaxRule{ID: 4, Region: "DE", Category: "books", Rate: 0.07},
+	)
+
+	tests := []struct {
+		region   string
+		category string
+		tax      models.Money
+	}{
+		{"DE", "books", 700},
+		{"DE", "food", 1900},
+		{"FR", "books", 1000},
+		{"FR", "food", 2000},
+	}
+
+	for _, tt := range tests {
+		_, tax, err := calculator.Calculate(tt.region, []service.TaxLine{{Category: tt.category, Amount: 10000}})
+		assert.NoError(t, err)
+		assert.Equal(t, tt.tax, tax, "region %s, category %s", tt.region, tt.category)
+	}
+}
+
+func TestTaxCalculatorNoRules(t *testing.T) {
+	calculator := newTaxCalculator()
+
+	subtotal, tax, err := calculator.Calculate("", []service.TaxLine{{Amount: 1234}})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(1234), subtotal)
+	assert.Equal(t, models.Money(0), tax)
+}
+
+func TestTaxCalculatorRepositoryError(t *testing.T) {
+	mockRepo := new(MockTaxRuleRepository)
+	calculator := service.NewTaxCalculator(mockRepo)
+
+	mockRepo.On("GetTaxRules", "DE").Return(nil, errors.New("db error"))
+
+	_, _, err := calculator.Calculate("DE", []service.TaxLine{{Amount: 100}})
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,462 @@
+package service_test
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// MockWebhookRepository для мока WebhookRepositoryInterface
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) CreateWebhook(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) UpdateWebhook(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) DeleteWebhook(webhookID int) error {
+	args := m.Called(webhookID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetWebhookByID(webhookID int) (*models.Webhook, error) {
+	args := m.Called(webhookID)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Webhook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetAllWebhooks() ([]models.Webhook, error) {
+	args := m.Called()
+	if result := args.Get(0); result != nil {
+		return result.([]models.Webhook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetActiveWebhooksByEvent(eventType string) ([]models.Webhook, error) {
+	args := m.Called(eventType)
+	if result := args.Get(0); result != nil {
+		return result.([]models.Webhook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWebhookRepository) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetWebhookDeliveryByID(deliveryID int) (*models.WebhookDelivery, error) {
+	args := m.Called(deliveryID)
+	if result := args.Get(0); result != nil {
+		return result.(*models.WebhookDelivery), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetWebhookDeliveries(webhookID int) ([]models.WebhookDelivery, error) {
+	args := m.Called(webhookID)
+	if result := args.Get(0); result != nil {
+		return result.([]models.WebhookDelivery), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWebhookRepository) ClaimStalePendingDeliveries(staleBefore time.Time, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(staleBefore, limit)
+	if result := args.Get(0); result != nil {
+		return result.([]models.WebhookDelivery), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// MockProducer для мока ProducerInterface
+type MockProducer struct {
+	mock.Mock
+}
+
+func (m *MockProducer) Publish(key, value []byte) error {
+	args := m.Called(key, value)
+	return args.Error(0)
+}
+
+func (m *MockProducer) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// webhookReceiver принимает запросы вебхука и отвечает статусами из statuses по очереди
+type webhookReceiver struct {
+	server  *httptest.Server
+	mu      sync.Mutex
+	headers []http.Header
+	bodies  [][]byte
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	receiver := &webhookReceiver{}
+	receiver.server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		receiver.mu.Lock()
+		call := len(receiver.bodies)
+		receiver.headers = append(receiver.headers, r.Header.Clone())
+		receiver.bodies = append(receiver.bodies, body)
+		receiver.mu.Unlock()
+
+		if call < len(statuses) {
+			rw.WriteHeader(statuses[call])
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.server.Close)
+	return receiver
+}
+
+// received возвращает заголовки и тела принятых запросов
+func (r *webhookReceiver) received() ([]http.Header, [][]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.headers, r.bodies
+}
+
+func TestNotifyDeliversSignedEvent(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, receiver.server.Client(), 3, time.Millisecond, time.Minute)
+
+	webhook := models.Webhook{ID: 1, URL: receiver.server.URL, Secret: "secret", EventTypes: []string{service.EventOrderStatusChanged}, IsActive: true}
+	mockRepo.On("GetActiveWebhooksByEvent", service.EventOrderStatusChanged).Return([]models.Webhook{webhook}, nil)
+	mockRepo.On("CreateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.WebhookDelivery).ID = 10
+	}).Return(nil)
+
+	var delivered models.WebhookDelivery
+	mockRepo.On("UpdateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Run(func(args mock.Arguments) {
+		delivered = *args.Get(0).(*models.WebhookDelivery)
+	}).Return(nil).Once()
+
+	webhookService.Notify(service.EventOrderStatusChanged, map[string]interface{}{"order_id": 5, "new_status": "confirmed"})
+	webhookService.Wait()
+
+	headers, bodies := receiver.received()
+	assert.Len(t, bodies, 1)
+	assert.Equal(t, service.EventOrderStatusChanged, headers[0].Get(service.WebhookEventHeader))
+	assert.Equal(t, "10", headers[0].Get(service.WebhookDeliveryHeader))
+	assert.Equal(t, service.SignWebhookPayload("secret", bodies[0]), headers[0].Get(service.WebhookSignatureHeader))
+
+	var event struct {
+		Event string                 `json:"event"`
+		Data  map[string]interface{} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(bodies[0], &event))
+	assert.Equal(t, service.EventOrderStatusChanged, event.Event)
+	assert.Equal(t, "confirmed", event.Data["new_status"])
+
+	assert.Equal(t, models.WebhookDeliverySucceeded, delivered.Status)
+	assert.Equal(t, 1, delivered.Attempts)
+	assert.Equal(t, http.StatusOK, delivered.ResponseStatus)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNotifyRetriesUntilAttemptsAreExhausted(t *testing.T) {
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable)
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, receiver.server.Client(), 3, time.Millisecond, time.Minute)
+
+	webhook := models.Webhook{ID: 1, URL: receiver.server.URL, Secret: "secret", EventTypes: []string{service.EventOrderCancelled}, IsActive: true}
+	mockRepo.On("GetActiveWebhooksByEvent", service.EventOrderCancelled).Return([]models.Webhook{webhook}, nil)
+	mockRepo.On("CreateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil)
+
+	var statuses []string
+	var delivered models.WebhookDelivery
+	mockRepo.On("UpdateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Run(func(args mock.Arguments) {
+		delivered = *args.Get(0).(*models.WebhookDelivery)
+		statuses = append(statuses, delivered.Status)
+	}).Return(nil).Times(3)
+
+	webhookService.Notify(service.EventOrderCancelled, map[string]interface{}{"order_id": 5})
+	webhookService.Wait()
+
+	_, bodies := receiver.received()
+	assert.Len(t, bodies, 3)
+	assert.Equal(t, []string{models.WebhookDeliveryPending, models.WebhookDeliveryPending, models.WebhookDeliveryFailed}, statuses)
+	assert.Equal(t, 3, delivered.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivered.ResponseStatus)
+	assert.Equal(t, "unexpected response status 503", delivered.LastError)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNotifyRecoversAfterRetry(t *testing.T) {
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, receiver.server.Client(), 5, time.Millisecond, time.Minute)
+
+	webhook := models.Webhook{ID: 1, URL: receiver.server.URL, Secret: "secret", EventTypes: []string{service.EventOrderCancelled}, IsActive: true}
+	mockRepo.On("GetActiveWebhooksByEvent", service.EventOrderCancelled).Return([]models.Webhook{webhook}, nil)
+	mockRepo.On("CreateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil)
+
+	var delivered models.WebhookDelivery
+	mockRepo.On("UpdateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Run(func(args mock.Arguments) {
+		delivered = *args.Get(0).(*models.WebhookDelivery)
+	}).Return(nil).Times(2)
+
+	webhookService.Notify(service.EventOrderCancelled, map[string]interface{}{"order_id": 5})
+	webhookService.Wait()
+
+	_, bodies := receiver.received()
+	assert.Len(t, bodies, 2)
+	assert.Equal(t, models.WebhookDeliverySucceeded, delivered.Status)
+	assert.Equal(t, 2, delivered.Attempts)
+	assert.Empty(t, delivered.LastError)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNotifyWithoutSubscriptions(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, http.DefaultClient, 3, time.Millisecond, time.Minute)
+
+	mockRepo.On("GetActiveWebhooksByEvent", service.EventOrderStatusChanged).Return([]models.Webhook{}, nil)
+
+	webhookService.Notify(service.EventOrderStatusChanged, map[string]interface{}{"order_id": 5})
+	webhookService.Wait()
+
+	mockRepo.AssertNotCalled(t, "CreateWebhookDelivery", mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNotifyDoesNotWaitForRepository(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, http.DefaultClient, 3, time.Millisecond, time.Minute)
+
+	// Таблица вебхуков отвечает только после того, как Notify вернул управление
+	release := make(chan time.Time)
+	mockRepo.On("GetActiveWebhooksByEvent", service.EventOrderStatusChanged).WaitUntil(release).Return([]models.Webhook{}, nil)
+
+	returned := make(chan struct{})
+	go func() {
+		webhookService.Notify(service.EventOrderStatusChanged, map[string]interface{}{"order_id": 5})
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Notify is blocked by the webhook repository")
+	}
+
+	close(release)
+	webhookService.Wait()
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReplayDelivery(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, receiver.server.Client(), 3, time.Millisecond, time.Minute)
+
+	payload := []byte(`{"event":"order_cancelled","data":{"order_id":5}}`)
+	original := &models.WebhookDelivery{
+		ID: 10, WebhookID: 1, EventType: service.EventOrderCancelled, Payload: payload,
+		Status: models.WebhookDeliveryFailed, Attempts: 5,
+	}
+	webhook := &models.Webhook{ID: 1, URL: receiver.server.URL, Secret: "secret", EventTypes: []string{service.EventOrderCancelled}, IsActive: false}
+
+	mockRepo.On("GetWebhookDeliveryByID", 10).Return(original, nil)
+	mockRepo.On("GetWebhookByID", 1).Return(webhook, nil)
+	mockRepo.On("CreateWebhookDelivery", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		return delivery.WebhookID == 1 && string(delivery.Payload) == string(payload) && delivery.Status == models.WebhookDeliveryPending
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.WebhookDelivery).ID = 11
+	}).Return(nil)
+	mockRepo.On("UpdateWebhookDelivery", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		return delivery.ID == 11 && delivery.Status == models.WebhookDeliverySucceeded
+	})).Return(nil).Once()
+
+	replay, err := webhookService.ReplayDelivery(10)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, replay.ID)
+	webhookService.Wait()
+
+	headers, bodies := receiver.received()
+	assert.Len(t, bodies, 1)
+	assert.Equal(t, payload, bodies[0])
+	assert.Equal(t, "11", headers[0].Get(service.WebhookDeliveryHeader))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, http.DefaultClient, 3, time.Millisecond, time.Minute)
+
+	invalid := []models.Webhook{
+		{URL: "", EventTypes: []string{service.EventOrderCancelled}},
+		{URL: "ftp://partner.example.com", EventTypes: []string{service.EventOrderCancelled}},
+		{URL: "/hooks/orders", EventTypes: []string{service.EventOrderCancelled}},
+		{URL: "https://partner.example.com", EventTypes: nil},
+		{URL: "https://partner.example.com", EventTypes: []string{"order_created"}},
+	}
+	for _, webhook := range invalid {
+		err := webhookService.CreateWebhook(&webhook)
+		assert.ErrorIs(t, err, models.ErrInvalidWebhookData, webhook.URL)
+	}
+
+	webhook := models.Webhook{
+		URL:        " https://partner.example.com/hooks ",
+		EventTypes: []string{service.EventOrderCancelled, service.EventOrderCancelled},
+		IsActive:   true,
+	}
+	mockRepo.On("CreateWebhook", &webhook).Return(nil)
+
+	err := webhookService.CreateWebhook(&webhook)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://partner.example.com/hooks", webhook.URL)
+	assert.Equal(t, []string{service.EventOrderCancelled}, webhook.EventTypes)
+	assert.Len(t, webhook.Secret, 64)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateWebhookKeepsSecret(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, http.DefaultClient, 3, time.Millisecond, time.Minute)
+
+	mockRepo.On("GetWebhookByID", 1).Return(&models.Webhook{ID: 1, Secret: "old-secret"}, nil)
+	mockRepo.On("UpdateWebhook", mock.AnythingOfType("*models.Webhook")).Return(nil)
+
+	webhook := models.Webhook{ID: 1, URL: "https://partner.example.com", EventTypes: []string{service.EventOrderStatusChanged}}
+	err := webhookService.UpdateWebhook(&webhook)
+	assert.NoError(t, err)
+	assert.Equal(t, "old-secret", webhook.Secret)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEventServiceNotifiesWebhooks(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, receiver.server.Client(), 1, time.Millisecond, time.Minute)
+	mockProducer := new(MockProducer)
+	eventService := service.NewEventService(mockProducer, webhookService)
+
+	webhook := models.Webhook{ID: 1, URL: receiver.server.URL, Secret: "secret", EventTypes: []string{service.EventOrderStatusChanged}, IsActive: true}
+	mockRepo.On("GetActiveWebhooksByEvent", service.EventOrderStatusChanged).Return([]models.Webhook{webhook}, nil)
+	mockRepo.On("CreateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil)
+	mockRepo.On("UpdateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil)
+	mockProducer.On("Publish", []byte(nil), mock.Anything).Return(nil)
+
+	eventService.PublishOrderStatusChanged(5, models.OrderStatusPending, models.OrderStatusConfirmed)
+	webhookService.Wait()
+
+	// Сообщение Kafka публикуется в прежнем формате, без типа события
+	message := mockProducer.Calls[0].Arguments.Get(1).([]byte)
+	assert.JSONEq(t, `{"order_id":5,"old_status":"pending","new_status":"confirmed"}`, string(message))
+
+	_, bodies := receiver.received()
+	assert.Len(t, bodies, 1)
+	var event struct {
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(bodies[0], &event))
+	assert.Equal(t, service.EventOrderStatusChanged, event.Event)
+	assert.JSONEq(t, string(message), string(event.Data))
+	mockRepo.AssertExpectations(t)
+	mockProducer.AssertExpectations(t)
+}
+
+// MockWebhookNotifier для мока WebhookNotifierInterface
+type MockWebhookNotifier struct {
+	mock.Mock
+}
+
+func (m *MockWebhookNotifier) Notify(eventType string, data interface{}) {
+	m.Called(eventType, data)
+}
+
+func TestEventServicePublishesCancellation(t *testing.T) {
+	mockNotifier := new(MockWebhookNotifier)
+	mockProducer := new(MockProducer)
+	eventService := service.NewEventService(mockProducer, mockNotifier)
+
+	mockNotifier.On("Notify", mock.Anything, mock.Anything).Return()
+	mockProducer.On("Publish", []byte(nil), mock.Anything).Return(nil)
+
+	cancelledAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+	eventService.PublishOrderCancelled(5, models.OrderStatusConfirmed, &models.OrderCancellation{
+		OrderID: 5, ReasonCode: models.CancellationReasonOther, Note: "Duplicate", CancelledBy: 3, CancelledAt: cancelledAt,
+	})
+
+	// В Kafka уходит одно сообщение о смене статуса с полями причины отмены
+	mockProducer.AssertNumberOfCalls(t, "Publish", 1)
+	message := mockProducer.Calls[0].Arguments.Get(1).([]byte)
+	assert.JSONEq(t, `{"order_id":5,"old_status":"confirmed","new_status":"cancelled","reason_code":"other",
+		"note":"Duplicate","cancelled_by":3,"cancelled_at":"2025-02-01T10:00:00Z"}`, string(message))
+
+	// Подписчики вебхуков получают и смену статуса, и отмену
+	mockNotifier.AssertNumberOfCalls(t, "Notify", 2)
+	assert.Equal(t, service.EventOrderStatusChanged, mockNotifier.Calls[0].Arguments.String(0))
+	assert.Equal(t, service.EventOrderCancelled, mockNotifier.Calls[1].Arguments.String(0))
+}
+
+func TestResendPendingDeliveries(t *testing.T) {
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+	mockRepo := new(MockWebhookRepository)
+	webhookService := service.NewWebhookService(mockRepo, receiver.server.Client(), 3, time.Millisecond, time.Minute)
+
+	payload := []byte(`{"event":"order_cancelled","data":{"order_id":5}}`)
+	pending := []models.WebhookDelivery{
+		// Прервана перезапуском после двух неудачных попыток: остается одна попытка
+		{ID: 10, WebhookID: 1, EventType: service.EventOrderCancelled, Payload: payload, Status: models.WebhookDeliveryPending, Attempts: 2},
+		{ID: 11, WebhookID: 2, EventType: service.EventOrderCancelled, Payload: payload, Status: models.WebhookDeliveryPending},
+	}
+	mockRepo.On("ClaimStalePendingDeliveries", mock.AnythingOfType("time.Time"), service.PendingDeliveriesBatchSize).Return(pending, nil).Once()
+	mockRepo.On("GetWebhookByID", 1).Return(&models.Webhook{ID: 1, URL: receiver.server.URL, Secret: "secret", IsActive: true}, nil)
+	mockRepo.On("GetWebhookByID", 2).Return(&models.Webhook{ID: 2, URL: receiver.server.URL, Secret: "secret", IsActive: false}, nil)
+
+	var delivered []models.WebhookDelivery
+	mockRepo.On("UpdateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Run(func(args mock.Arguments) {
+		delivered = append(delivered, *args.Get(0).(*models.WebhookDelivery))
+	}).Return(nil).Twice()
+
+	resent, err := webhookService.ResendPendingDeliveries()
+	webhookService.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resent)
+
+	// Отключенная подписка не получает событие, ее доставка помечается как неудачная
+	_, bodies := receiver.received()
+	assert.Len(t, bodies, 1)
+	assert.Equal(t, payload, bodies[0])
+
+	assert.Len(t, delivered, 2)
+	assert.Equal(t, 11, delivered[0].ID)
+	assert.Equal(t, models.WebhookDeliveryFailed, delivered[0].Status)
+	assert.Equal(t, "webhook is inactive", delivered[0].LastError)
+	assert.Equal(t, 10, delivered[1].ID)
+	assert.Equal(t, models.WebhookDeliveryFailed, delivered[1].Status)
+	assert.Equal(t, 3, delivered[1].Attempts)
+	mockRepo.AssertExpectations(t)
+}